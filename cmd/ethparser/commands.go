@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/ilkamo/ethparser-go/parser"
+	"github.com/ilkamo/ethparser-go/server"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const transactionsPageSize = 1000
+
+// runCommand runs the parser until the context is canceled. The HTTP API is served as well, unless
+// the address is empty.
+func (c cli) runCommand(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("run", "")
+
+	var (
+		common  commonFlags
+		options parserFlags
+	)
+	common.register(fs)
+	options.register(fs)
+	address := fs.String("addr", ":8080", "address the HTTP API listens on, the API is disabled when empty")
+
+	if err := parseFlags(fs, args, c.lookupEnv); err != nil {
+		return err
+	}
+
+	logger, err := c.newLogger(common.logLevel)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.newParser(ctx, common, options, logger, true)
+	if err != nil {
+		return err
+	}
+
+	if *address == "" {
+		return p.Run(ctx)
+	}
+
+	return server.New(p, logger, server.WithAddress(*address)).Run(ctx)
+}
+
+// blockCommand prints a block as indented JSON.
+func (c cli) blockCommand(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("block", "<number|latest>")
+
+	var common commonFlags
+	common.register(fs)
+
+	if err := parseFlags(fs, args, c.lookupEnv); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: block expects exactly one block number", errUsage)
+	}
+
+	logger, err := c.newLogger(common.logLevel)
+	if err != nil {
+		return err
+	}
+
+	ethClient, err := c.newEthClient(common, logger)
+	if err != nil {
+		return fmt.Errorf("could not create Ethereum client: %w", err)
+	}
+
+	var blockNumber uint64
+	if fs.Arg(0) == "latest" {
+		if blockNumber, err = ethClient.GetMostRecentBlockNumber(ctx); err != nil {
+			return fmt.Errorf("could not get most recent block: %w", err)
+		}
+	} else if blockNumber, err = strconv.ParseUint(fs.Arg(0), 10, 64); err != nil {
+		return fmt.Errorf("%w: invalid block number %q", errUsage, fs.Arg(0))
+	}
+
+	block, err := ethClient.GetBlockByNumber(ctx, blockNumber)
+	if err != nil {
+		return fmt.Errorf("could not get block %d: %w", blockNumber, err)
+	}
+
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(newBlockOutput(block))
+}
+
+// watchCommand runs the parser observing a single address and prints its transactions as JSON lines
+// until the context is canceled.
+func (c cli) watchCommand(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("watch", "<address>")
+
+	var (
+		common  commonFlags
+		options parserFlags
+	)
+	common.register(fs)
+	options.register(fs)
+	since := fs.Uint64("since", 0, "also print the past transactions of the address starting from this block")
+
+	if err := parseFlags(fs, args, c.lookupEnv); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: watch expects exactly one address", errUsage)
+	}
+
+	address := fs.Arg(0)
+
+	logger, err := c.newLogger(common.logLevel)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.newParser(ctx, common, options, logger, true)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := p.Events(ctx)
+
+	var subscribed bool
+	if *since > 0 {
+		subscribed = p.SubscribeSince(address, *since)
+	} else {
+		subscribed = p.Subscribe(address)
+	}
+
+	if !subscribed {
+		return fmt.Errorf("could not subscribe to address %s", address)
+	}
+
+	runErrs := make(chan error, 1)
+	go func() {
+		runErrs <- p.Run(ctx)
+		cancel()
+	}()
+
+	encoder := json.NewEncoder(c.stdout)
+
+	// The address backfill and the live loop can both publish the blocks processed while the backfill starts:
+	// the transactions already printed are skipped.
+	printed := make(map[string]struct{})
+
+	for event := range events {
+		if event.Type != types.EventTypeTransaction || !involves(event.Transaction, address) {
+			continue
+		}
+
+		if _, ok := printed[event.Transaction.ID()]; ok {
+			continue
+		}
+
+		printed[event.Transaction.ID()] = struct{}{}
+
+		if err := encoder.Encode(newTransactionOutput(event.Transaction)); err != nil {
+			cancel()
+			return errors.Join(fmt.Errorf("could not write transaction: %w", err), <-runErrs)
+		}
+	}
+
+	return <-runErrs
+}
+
+// backfillCommand processes a block range and prints the transactions of the addresses as JSON lines,
+// ordered by block number.
+func (c cli) backfillCommand(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("backfill", "<from> <to>")
+
+	var (
+		common    commonFlags
+		options   parserFlags
+		addresses addressesFlag
+	)
+	common.register(fs)
+	options.register(fs)
+	fs.Var(&addresses, "address", "address to backfill, can be repeated or comma separated")
+
+	if err := parseFlags(fs, args, c.lookupEnv); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 || len(addresses) == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: backfill expects a block range and at least one address", errUsage)
+	}
+
+	from, fromErr := strconv.ParseUint(fs.Arg(0), 10, 64)
+	to, toErr := strconv.ParseUint(fs.Arg(1), 10, 64)
+	if fromErr != nil || toErr != nil {
+		return fmt.Errorf("%w: invalid block range %q %q", errUsage, fs.Arg(0), fs.Arg(1))
+	}
+
+	logger, err := c.newLogger(common.logLevel)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.newParser(ctx, common, options, logger, false)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		if !p.Subscribe(address) {
+			return fmt.Errorf("could not subscribe to address %s", address)
+		}
+	}
+
+	if err := p.Backfill(ctx, from, to); err != nil {
+		return fmt.Errorf("could not backfill: %w", err)
+	}
+
+	encoder := json.NewEncoder(c.stdout)
+
+	for _, address := range addresses {
+		if err := c.printTransactions(ctx, p, encoder, address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newParser creates a parser with the options of the flags. When followHead is true and no start block is set,
+// the parser starts from the most recent block instead of the genesis block.
+func (c cli) newParser(
+	ctx context.Context,
+	common commonFlags,
+	options parserFlags,
+	logger *slog.Logger,
+	followHead bool,
+) (*parser.Parser, error) {
+	ethClient, err := c.newEthClient(common, logger)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Ethereum client: %w", err)
+	}
+
+	opts := append(options.options(), parser.WithEthereumClient(ethClient))
+
+	if followHead && !setFlags(options.fs)["start-block"] {
+		mostRecentBlock, err := ethClient.GetMostRecentBlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not get most recent block: %w", err)
+		}
+
+		opts = append(opts, parser.WithStartBlock(mostRecentBlock))
+	}
+
+	p, err := parser.NewParser(common.endpoint, logger, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create parser: %w", err)
+	}
+
+	return p, nil
+}
+
+// printTransactions prints all the transactions of an address, page by page.
+func (c cli) printTransactions(ctx context.Context, p *parser.Parser, encoder *json.Encoder, address string) error {
+	for offset := 0; ; offset += transactionsPageSize {
+		transactions, err := p.ListTransactions(ctx, address, offset, transactionsPageSize)
+		if err != nil {
+			if errors.Is(err, types.ErrAddressNotFound) {
+				return nil
+			}
+
+			return fmt.Errorf("could not list transactions of %s: %w", address, err)
+		}
+
+		for _, tx := range transactions {
+			if err := encoder.Encode(newTransactionOutput(tx)); err != nil {
+				return fmt.Errorf("could not write transaction: %w", err)
+			}
+		}
+
+		if len(transactions) < transactionsPageSize {
+			return nil
+		}
+	}
+}
+
+func (c cli) newFlagSet(command, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(c.stderr, "Usage: ethparser %s [flags] %s\n\nFlags:\n", command, arguments)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// involves tells if the address sent or received the transaction, or is the contract it deployed.
+func involves(tx types.Transaction, address string) bool {
+	if address == "" {
+		return false
+	}
+
+	return strings.EqualFold(tx.From, address) ||
+		strings.EqualFold(tx.To, address) ||
+		strings.EqualFold(tx.ContractAddress, address)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/internal/ethereum"
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
+	"github.com/ilkamo/ethparser-go/internal/rpcpool"
+	"github.com/ilkamo/ethparser-go/parser"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const envPrefix = "ETHPARSER_"
+
+var errUsage = errors.New("invalid usage")
+
+// commonFlags are the flags shared by all the commands.
+type commonFlags struct {
+	endpoint       string
+	rpcStrategy    string
+	rpcRateLimit   float64
+	rpcBurst       int
+	rpcMaxInFlight int
+	rpcBearerToken string
+	rpcAPIKey      string
+	rpcAPIKeyName  string
+	rpcJWTSecret   string
+	traceAPI       string
+	logLevel       string
+}
+
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.endpoint, "endpoint", "https://cloudflare-eth.com",
+		"Ethereum JSON-RPC endpoint (http, https, ws or wss url, or IPC socket path), several endpoints can be comma separated to fail over")
+	fs.StringVar(&f.rpcStrategy, "rpc-strategy", string(rpcpool.StrategyPrimaryFallback),
+		"order in which several endpoints are tried: primary-fallback, round-robin or lowest-latency")
+	fs.Float64Var(&f.rpcRateLimit, "rpc-rate-limit", 0,
+		"maximum JSON-RPC calls per second sent to each http endpoint, 0 for no limit")
+	fs.IntVar(&f.rpcBurst, "rpc-burst", 1, "JSON-RPC calls that can exceed the rate limit in a burst")
+	fs.IntVar(&f.rpcMaxInFlight, "rpc-max-in-flight", 0,
+		"maximum concurrent requests sent to each http endpoint, 0 for no limit")
+	fs.StringVar(&f.rpcBearerToken, "rpc-bearer-token", "",
+		"bearer token of the http endpoints, prefer the "+envName("rpc-bearer-token")+" env var")
+	fs.StringVar(&f.rpcAPIKey, "rpc-api-key", "",
+		"API key of the http endpoints, prefer the "+envName("rpc-api-key")+" env var")
+	fs.StringVar(&f.rpcAPIKeyName, "rpc-api-key-header", "X-API-Key", "header carrying the API key")
+	fs.StringVar(&f.rpcJWTSecret, "rpc-jwt-secret", "",
+		"path of the hex encoded secret signing the JWT of the http endpoints, e.g. the jwt.hex of the node")
+	fs.StringVar(&f.traceAPI, "trace-api", string(ethereum.TraceAPIDebug),
+		"API tracing the internal transfers: debug (debug_traceBlockByNumber) or trace (trace_block)")
+	fs.StringVar(&f.logLevel, "log-level", "info", "minimum level of the logs written to stderr: debug, info, warn or error")
+}
+
+// endpoints returns the endpoints of the comma separated list.
+func (f *commonFlags) endpoints() []string {
+	var endpoints []string
+	for _, endpoint := range strings.Split(f.endpoint, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints
+}
+
+// jsonrpcOptions returns the options of the JSON-RPC clients of the http endpoints.
+func (f *commonFlags) jsonrpcOptions(logger types.Logger) ([]jsonrpc.Option, error) {
+	opts := []jsonrpc.Option{
+		jsonrpc.WithLogger(logger),
+		jsonrpc.WithRateLimit(f.rpcRateLimit, f.rpcBurst),
+		jsonrpc.WithMaxInFlight(f.rpcMaxInFlight),
+		jsonrpc.WithBearerToken(f.rpcBearerToken),
+		jsonrpc.WithAPIKey(f.rpcAPIKeyName, f.rpcAPIKey),
+	}
+
+	if f.rpcJWTSecret != "" {
+		secret, err := readJWTSecret(f.rpcJWTSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, jsonrpc.WithJWTSecret(secret))
+	}
+
+	return opts, nil
+}
+
+// readJWTSecret reads a hex encoded JWT secret, with or without 0x prefix, as written by geth or erigon.
+func readJWTSecret(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read jwt secret: %w", err)
+	}
+
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(content)), "0x"))
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("jwt secret %s is not a hex encoded secret", path)
+	}
+
+	return secret, nil
+}
+
+// parserFlags maps the parser options to flags. An option is only applied when its flag is set, so that
+// the defaults of the parser are kept otherwise.
+type parserFlags struct {
+	fs                          *flag.FlagSet
+	blockProcessTimeout         time.Duration
+	noNewBlocksPause            time.Duration
+	newHeads                    bool
+	maxBlocksInParallel         int
+	maxReorgDepth               int
+	confirmations               uint64
+	unconfirmedTransactions     bool
+	tokenTransfers              bool
+	internalTransfers           bool
+	blockRetryBackoff           time.Duration
+	maxBlockRetryBackoff        time.Duration
+	startBlock                  uint64
+	backfillMaxBlocksInParallel int
+	eventsBufferSize            int
+	webhookMaxAttempts          int
+	webhookRetryBackoff         time.Duration
+	maxWebhookRetryBackoff      time.Duration
+	webhookPollInterval         time.Duration
+}
+
+func (f *parserFlags) register(fs *flag.FlagSet) {
+	f.fs = fs
+
+	fs.DurationVar(&f.blockProcessTimeout, "block-process-timeout", 0, "timeout of an iteration of the parser")
+	fs.DurationVar(&f.noNewBlocksPause, "no-new-blocks-pause", 0, "pause when there are no new blocks")
+	fs.BoolVar(&f.newHeads, "new-heads", false,
+		"process new blocks as soon as they are notified, requires a single ws://, wss:// or IPC endpoint")
+	fs.IntVar(&f.maxBlocksInParallel, "max-blocks-in-parallel", 0, "maximum number of blocks processed in parallel")
+	fs.IntVar(&f.maxReorgDepth, "max-reorg-depth", 0, "number of processed block hashes remembered to handle reorgs")
+	fs.Uint64Var(&f.confirmations, "confirmations", 0, "confirmations required before processing a block")
+	fs.BoolVar(&f.unconfirmedTransactions, "unconfirmed", false, "track the transactions of unconfirmed blocks")
+	fs.BoolVar(&f.tokenTransfers, "token-transfers", false,
+		"detect the ERC-20, ERC-721 and ERC-1155 token transfers of the addresses, costing a call per block")
+	fs.BoolVar(&f.internalTransfers, "internal-transfers", false,
+		"detect the ETH transferred to and from the addresses by contracts, requires a node supporting -trace-api")
+	fs.DurationVar(&f.blockRetryBackoff, "block-retry-backoff", time.Second, "initial backoff of failed blocks")
+	fs.DurationVar(&f.maxBlockRetryBackoff, "max-block-retry-backoff", time.Minute, "maximum backoff of failed blocks")
+	fs.Uint64Var(&f.startBlock, "start-block", 0, "first block to process, the most recent block when not set")
+	fs.IntVar(&f.backfillMaxBlocksInParallel, "backfill-max-blocks-in-parallel", 0,
+		"maximum number of blocks processed in parallel by backfills")
+	fs.IntVar(&f.eventsBufferSize, "events-buffer-size", 0, "buffer size of each events subscriber")
+	fs.IntVar(&f.webhookMaxAttempts, "webhook-max-attempts", 10, "attempts of a webhook delivery before dead-lettering")
+	fs.DurationVar(&f.webhookRetryBackoff, "webhook-retry-backoff", 5*time.Second,
+		"initial backoff of failed webhook deliveries")
+	fs.DurationVar(&f.maxWebhookRetryBackoff, "max-webhook-retry-backoff", time.Hour,
+		"maximum backoff of failed webhook deliveries")
+	fs.DurationVar(&f.webhookPollInterval, "webhook-poll-interval", 0, "interval between webhook deliveries checks")
+}
+
+// options returns the parser options of the flags that are set.
+func (f *parserFlags) options() []parser.Option {
+	set := setFlags(f.fs)
+
+	var opts []parser.Option
+
+	if set["block-process-timeout"] {
+		opts = append(opts, parser.WithBlockProcessTimeout(f.blockProcessTimeout))
+	}
+
+	if set["no-new-blocks-pause"] {
+		opts = append(opts, parser.WithNoNewBlocksPause(f.noNewBlocksPause))
+	}
+
+	if set["new-heads"] && f.newHeads {
+		opts = append(opts, parser.WithNewHeadsSubscription())
+	}
+
+	if set["max-blocks-in-parallel"] {
+		opts = append(opts, parser.WithMaxBlocksToProcessInParallel(f.maxBlocksInParallel))
+	}
+
+	if set["max-reorg-depth"] {
+		opts = append(opts, parser.WithMaxReorgDepth(f.maxReorgDepth))
+	}
+
+	if set["confirmations"] {
+		opts = append(opts, parser.WithConfirmations(f.confirmations))
+	}
+
+	if set["unconfirmed"] {
+		opts = append(opts, parser.WithUnconfirmedTransactions(f.unconfirmedTransactions))
+	}
+
+	if set["token-transfers"] {
+		opts = append(opts, parser.WithTokenTransfers(f.tokenTransfers))
+	}
+
+	if set["internal-transfers"] {
+		opts = append(opts, parser.WithInternalTransfers(f.internalTransfers))
+	}
+
+	if set["block-retry-backoff"] || set["max-block-retry-backoff"] {
+		opts = append(opts, parser.WithBlockRetryBackoff(f.blockRetryBackoff, f.maxBlockRetryBackoff))
+	}
+
+	if set["start-block"] {
+		opts = append(opts, parser.WithStartBlock(f.startBlock))
+	}
+
+	if set["backfill-max-blocks-in-parallel"] {
+		opts = append(opts, parser.WithBackfillMaxBlocksToProcessInParallel(f.backfillMaxBlocksInParallel))
+	}
+
+	if set["events-buffer-size"] {
+		opts = append(opts, parser.WithEventsBufferSize(f.eventsBufferSize))
+	}
+
+	if set["webhook-max-attempts"] || set["webhook-retry-backoff"] || set["max-webhook-retry-backoff"] {
+		opts = append(opts,
+			parser.WithWebhookRetries(f.webhookMaxAttempts, f.webhookRetryBackoff, f.maxWebhookRetryBackoff))
+	}
+
+	if set["webhook-poll-interval"] {
+		opts = append(opts, parser.WithWebhookPollInterval(f.webhookPollInterval))
+	}
+
+	return opts
+}
+
+// parseFlags parses the arguments, then sets the flags that are not in the arguments from their
+// environment variables: flag `-max-reorg-depth` is read from `ETHPARSER_MAX_REORG_DEPTH`.
+func parseFlags(fs *flag.FlagSet, args []string, lookupEnv func(key string) (string, bool)) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	set := setFlags(fs)
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] {
+			return
+		}
+
+		value, ok := lookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+
+		if err := fs.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("%w: invalid value %q for %s: %w", errUsage, value, envName(f.Name), err))
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	return set
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// newLogger returns a logger writing text logs to stderr.
+func (c cli) newLogger(level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("%w: invalid log level %q", errUsage, level)
+	}
+
+	return slog.New(slog.NewTextHandler(c.stderr, &slog.HandlerOptions{Level: l})), nil
+}
+
+// addressesFlag is a flag that can be repeated to collect several addresses.
+type addressesFlag []string
+
+func (a *addressesFlag) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *addressesFlag) Set(value string) error {
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			*a = append(*a, address)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+)
+
+func newTestFlagSet() (*flag.FlagSet, *commonFlags, *parserFlags) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	common := &commonFlags{}
+	common.register(fs)
+
+	options := &parserFlags{}
+	options.register(fs)
+
+	return fs, common, options
+}
+
+func TestParseFlags(t *testing.T) {
+	t.Run("should read unset flags from the environment", func(t *testing.T) {
+		fs, common, options := newTestFlagSet()
+
+		env := map[string]string{
+			"ETHPARSER_ENDPOINT":      "https://env:80",
+			"ETHPARSER_CONFIRMATIONS": "12",
+		}
+		lookupEnv := func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		}
+
+		err := parseFlags(fs, []string{"-endpoint", "https://flag:80", "-max-reorg-depth", "5"}, lookupEnv)
+		require.NoError(t, err)
+
+		require.Equal(t, "https://flag:80", common.endpoint, "flags should take precedence over env vars")
+		require.Equal(t, uint64(12), options.confirmations)
+		require.Equal(t, 5, options.maxReorgDepth)
+		require.Len(t, options.options(), 2, "should only apply the options that are set")
+	})
+
+	t.Run("should return error for invalid values", func(t *testing.T) {
+		fs, _, _ := newTestFlagSet()
+
+		lookupEnv := func(key string) (string, bool) {
+			return "not a duration", key == "ETHPARSER_NO_NEW_BLOCKS_PAUSE"
+		}
+
+		err := parseFlags(fs, nil, lookupEnv)
+		require.ErrorIs(t, err, errUsage)
+		require.ErrorContains(t, err, "ETHPARSER_NO_NEW_BLOCKS_PAUSE")
+
+		fs, _, _ = newTestFlagSet()
+		require.ErrorIs(t, parseFlags(fs, []string{"-unknown"}, lookupEnv), errUsage)
+	})
+}
+
+func TestParserFlags_options(t *testing.T) {
+	t.Run("should apply paired options when any of them is set", func(t *testing.T) {
+		fs, _, options := newTestFlagSet()
+
+		err := parseFlags(fs, []string{"-max-block-retry-backoff", "2m", "-webhook-max-attempts", "3"}, noEnv)
+		require.NoError(t, err)
+
+		require.Equal(t, time.Second, options.blockRetryBackoff)
+		require.Equal(t, 2*time.Minute, options.maxBlockRetryBackoff)
+		require.Len(t, options.options(), 2)
+	})
+}
+
+func TestCommonFlags_endpoints(t *testing.T) {
+	t.Run("should split the comma separated endpoints", func(t *testing.T) {
+		common := commonFlags{endpoint: "https://a:80, https://b:80,"}
+		require.Equal(t, []string{"https://a:80", "https://b:80"}, common.endpoints())
+	})
+}
+
+func TestCommonFlags_jsonrpcOptions(t *testing.T) {
+	t.Run("should read the limits of the endpoints", func(t *testing.T) {
+		fs, common, _ := newTestFlagSet()
+
+		err := parseFlags(fs, []string{"-rpc-rate-limit", "12.5", "-rpc-max-in-flight", "4"}, noEnv)
+		require.NoError(t, err)
+
+		require.Equal(t, 12.5, common.rpcRateLimit)
+		require.Equal(t, 1, common.rpcBurst)
+		require.Equal(t, 4, common.rpcMaxInFlight)
+
+		opts, err := common.jsonrpcOptions(&mock.Logger{})
+		require.NoError(t, err)
+		require.Len(t, opts, 5)
+	})
+
+	t.Run("should read the jwt secret", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwt.hex")
+		require.NoError(t, os.WriteFile(path, []byte("0x0102ff\n"), 0o600))
+
+		secret, err := readJWTSecret(path)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0x01, 0x02, 0xff}, secret)
+
+		opts, err := (&commonFlags{rpcJWTSecret: path}).jsonrpcOptions(&mock.Logger{})
+		require.NoError(t, err)
+		require.Len(t, opts, 6)
+	})
+
+	t.Run("should return error for invalid jwt secrets", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwt.hex")
+		require.NoError(t, os.WriteFile(path, []byte("not hex"), 0o600))
+
+		_, err := readJWTSecret(path)
+		require.Error(t, err)
+
+		_, err = (&commonFlags{rpcJWTSecret: filepath.Join(t.TempDir(), "missing")}).jsonrpcOptions(&mock.Logger{})
+		require.Error(t, err)
+	})
+}
+
+func TestNewEthClient(t *testing.T) {
+	t.Run("should create a pool for several endpoints", func(t *testing.T) {
+		_, err := newEthClient(commonFlags{endpoint: "https://a:80", traceAPI: "debug"}, &mock.Logger{})
+		require.NoError(t, err)
+
+		common := commonFlags{endpoint: "https://a:80,https://b:80", rpcStrategy: "round-robin", traceAPI: "trace"}
+		_, err = newEthClient(common, &mock.Logger{})
+		require.NoError(t, err)
+	})
+
+	t.Run("should return usage error for unknown strategies", func(t *testing.T) {
+		common := commonFlags{endpoint: "https://a:80,https://b:80", rpcStrategy: "random", traceAPI: "debug"}
+		_, err := newEthClient(common, &mock.Logger{})
+		require.ErrorIs(t, err, errUsage)
+	})
+
+	t.Run("should return usage error for unknown trace apis", func(t *testing.T) {
+		_, err := newEthClient(commonFlags{endpoint: "https://a:80", traceAPI: "parity"}, &mock.Logger{})
+		require.ErrorIs(t, err, errUsage)
+	})
+}
+
+func TestEnvName(t *testing.T) {
+	t.Run("should map flag names to env vars", func(t *testing.T) {
+		require.Equal(t, "ETHPARSER_MAX_REORG_DEPTH", envName("max-reorg-depth"))
+	})
+}
+
+func TestAddressesFlag(t *testing.T) {
+	t.Run("should collect repeated and comma separated addresses", func(t *testing.T) {
+		var addresses addressesFlag
+
+		require.NoError(t, addresses.Set("0x1, 0x2"))
+		require.NoError(t, addresses.Set("0x3"))
+		require.Equal(t, addressesFlag{"0x1", "0x2", "0x3"}, addresses)
+		require.Equal(t, "0x1,0x2,0x3", addresses.String())
+	})
+}
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
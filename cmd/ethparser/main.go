@@ -0,0 +1,127 @@
+// Command ethparser runs and queries the parser from the command line.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ilkamo/ethparser-go/internal/ethereum"
+	"github.com/ilkamo/ethparser-go/internal/rpcpool"
+	"github.com/ilkamo/ethparser-go/parser"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const usage = `Usage: ethparser <command> [flags] [arguments]
+
+Commands:
+  run                   run the parser and serve the HTTP API
+  block <number>        print a block, the most recent one when the number is "latest"
+  watch <address>       stream the transactions of an address as JSON lines
+  backfill <from> <to>  process a block range and print the transactions of the addresses as JSON lines
+
+Run "ethparser <command> -h" to list the flags of a command. Every flag can also be set with an
+environment variable prefixed with ETHPARSER_, e.g. ETHPARSER_ENDPOINT for -endpoint.
+`
+
+func main() {
+	// The commands are stopped gracefully on SIGINT and SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	c := cli{
+		stdout:       os.Stdout,
+		stderr:       os.Stderr,
+		lookupEnv:    os.LookupEnv,
+		newEthClient: newEthClient,
+	}
+
+	err := c.run(ctx, os.Args[1:])
+
+	stop()
+
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// cli holds the dependencies of the commands so that they can be replaced in tests.
+type cli struct {
+	stdout       io.Writer
+	stderr       io.Writer
+	lookupEnv    func(key string) (string, bool)
+	newEthClient func(common commonFlags, logger types.Logger) (parser.EthereumClient, error)
+}
+
+// newEthClient creates the Ethereum client of the endpoints. Several endpoints are wrapped
+// in a pool that fails over between them according to the RPC strategy.
+func newEthClient(common commonFlags, logger types.Logger) (parser.EthereumClient, error) {
+	jsonrpcOpts, err := common.jsonrpcOptions(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	traceAPI, err := ethereum.ParseTraceAPI(common.traceAPI)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	endpoints := common.endpoints()
+	if len(endpoints) <= 1 {
+		return ethereum.NewClient(
+			common.endpoint,
+			ethereum.WithJSONRPCOptions(jsonrpcOpts...),
+			ethereum.WithTraceAPI(traceAPI),
+		)
+	}
+
+	strategy, err := rpcpool.ParseStrategy(common.rpcStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	pool, err := rpcpool.NewFromURLs(
+		endpoints,
+		jsonrpcOpts,
+		rpcpool.WithStrategy(strategy),
+		rpcpool.WithLogger(logger),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create rpc pool: %w", err)
+	}
+
+	return ethereum.NewClient("", ethereum.WithRPCClient(pool), ethereum.WithTraceAPI(traceAPI))
+}
+
+// run executes the command named by the first argument.
+func (c cli) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(c.stderr, usage)
+		return errUsage
+	}
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"run":      c.runCommand,
+		"block":    c.blockCommand,
+		"watch":    c.watchCommand,
+		"backfill": c.backfillCommand,
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		_, _ = fmt.Fprint(c.stdout, usage)
+		return nil
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		_, _ = fmt.Fprint(c.stderr, usage)
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	return command(ctx, args[1:])
+}
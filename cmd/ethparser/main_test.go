@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/parser"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const observedAddress = "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+
+// syncBuffer is a bytes.Buffer safe for concurrent use, written by the commands while read by the tests.
+type syncBuffer struct {
+	buffer bytes.Buffer
+	sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.Lock()
+	defer b.Unlock()
+
+	return strings.Split(strings.TrimSpace(b.buffer.String()), "\n")
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.buffer.String()
+}
+
+// testChain returns a chain of blocks, each containing a transaction of the observed address.
+func testChain(to uint64) map[uint64]types.Block {
+	blocks := make(map[uint64]types.Block)
+
+	parentHash := ""
+	for n := uint64(1); n <= to; n++ {
+		hash := fmt.Sprintf("0xb%d", n)
+		blocks[n] = types.Block{
+			Number:     n,
+			Hash:       hash,
+			ParentHash: parentHash,
+			Transactions: []types.Transaction{
+				{
+					BlockHash:   hash,
+					BlockNumber: n,
+					Hash:        fmt.Sprintf("0xt%d", n),
+					From:        observedAddress,
+					To:          "0x225295d8C90fe127932c6fe78dae6D5a4B975098",
+					Value:       *big.NewInt(int64(n)),
+				},
+			},
+		}
+		parentHash = hash
+	}
+
+	return blocks
+}
+
+func newTestCLI(ethClient parser.EthereumClient, env map[string]string) (cli, *syncBuffer) {
+	stdout := &syncBuffer{}
+
+	return cli{
+		stdout: stdout,
+		stderr: &syncBuffer{},
+		lookupEnv: func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		},
+		newEthClient: func(commonFlags, types.Logger) (parser.EthereumClient, error) {
+			return ethClient, nil
+		},
+	}, stdout
+}
+
+func TestCLI_run(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should return usage error for missing or unknown commands", func(t *testing.T) {
+		c, _ := newTestCLI(mock.EthereumClient{}, nil)
+
+		require.ErrorIs(t, c.run(ctx, nil), errUsage)
+		require.ErrorIs(t, c.run(ctx, []string{"unknown"}), errUsage)
+	})
+
+	t.Run("should print usage", func(t *testing.T) {
+		c, stdout := newTestCLI(mock.EthereumClient{}, nil)
+
+		require.NoError(t, c.run(ctx, []string{"help"}))
+		require.Contains(t, stdout.String(), "Usage: ethparser <command>")
+	})
+
+	t.Run("should run the parser until the context is canceled", func(t *testing.T) {
+		c, _ := newTestCLI(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: testChain(3)}, nil)
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		err := c.run(ctx, []string{"run", "-addr", "", "-no-new-blocks-pause", "1ms", "-log-level", "error"})
+		require.NoError(t, err)
+	})
+}
+
+func TestCLI_blockCommand(t *testing.T) {
+	ctx := context.TODO()
+	chain := testChain(3)
+
+	t.Run("should print a block", func(t *testing.T) {
+		c, stdout := newTestCLI(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: chain}, nil)
+
+		require.NoError(t, c.run(ctx, []string{"block", "2"}))
+
+		var block blockOutput
+		require.NoError(t, json.Unmarshal([]byte(stdout.String()), &block))
+		require.Equal(t, newBlockOutput(chain[2]), block)
+		require.Contains(t, stdout.String(), "\n  \"hash\"", "should be indented")
+	})
+
+	t.Run("should print the latest block", func(t *testing.T) {
+		c, stdout := newTestCLI(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: chain}, nil)
+
+		require.NoError(t, c.run(ctx, []string{"block", "latest"}))
+
+		var block blockOutput
+		require.NoError(t, json.Unmarshal([]byte(stdout.String()), &block))
+		require.Equal(t, uint64(3), block.Number)
+		require.Equal(t, "3", block.Transactions[0].Value)
+	})
+
+	t.Run("should return error for invalid block numbers", func(t *testing.T) {
+		c, _ := newTestCLI(mock.EthereumClient{}, nil)
+
+		require.ErrorIs(t, c.run(ctx, []string{"block"}), errUsage)
+		require.ErrorIs(t, c.run(ctx, []string{"block", "abc"}), errUsage)
+	})
+}
+
+func TestCLI_watchCommand(t *testing.T) {
+	t.Run("should stream the past and new transactions of the address", func(t *testing.T) {
+		c, stdout := newTestCLI(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: testChain(3)}, nil)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- c.run(ctx, []string{
+				"watch", "-since", "1", "-no-new-blocks-pause", "1ms", "-log-level", "error", observedAddress,
+			})
+		}()
+
+		require.Eventually(t, func() bool {
+			return len(stdout.lines()) == 3
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-errs)
+
+		lines := stdout.lines()
+		require.Len(t, lines, 3, "should print every transaction once")
+
+		hashes := make(map[string]bool)
+		for _, line := range lines {
+			var tx transactionOutput
+			require.NoError(t, json.Unmarshal([]byte(line), &tx))
+			hashes[tx.Hash] = true
+		}
+		require.Equal(t, map[string]bool{"0xt1": true, "0xt2": true, "0xt3": true}, hashes)
+	})
+
+	t.Run("should return error without address", func(t *testing.T) {
+		c, _ := newTestCLI(mock.EthereumClient{}, nil)
+
+		require.ErrorIs(t, c.run(context.TODO(), []string{"watch"}), errUsage)
+	})
+}
+
+func TestCLI_backfillCommand(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should print the transactions of the range", func(t *testing.T) {
+		c, stdout := newTestCLI(mock.EthereumClient{MostRecentBlock: 5, BlocksByNumber: testChain(5)}, map[string]string{
+			"ETHPARSER_LOG_LEVEL": "error",
+		})
+
+		require.NoError(t, c.run(ctx, []string{"backfill", "-address", observedAddress, "2", "4"}))
+
+		lines := stdout.lines()
+		require.Len(t, lines, 3)
+
+		for i, line := range lines {
+			var tx transactionOutput
+			require.NoError(t, json.Unmarshal([]byte(line), &tx))
+			require.Equal(t, fmt.Sprintf("0xt%d", i+2), tx.Hash, "should be ordered by block number")
+		}
+	})
+
+	t.Run("should return error for invalid arguments", func(t *testing.T) {
+		c, _ := newTestCLI(mock.EthereumClient{}, nil)
+
+		require.ErrorIs(t, c.run(ctx, []string{"backfill", "1", "2"}), errUsage)
+		require.ErrorIs(t, c.run(ctx, []string{"backfill", "-address", observedAddress, "1"}), errUsage)
+		require.ErrorIs(t, c.run(ctx, []string{"backfill", "-address", observedAddress, "a", "2"}), errUsage)
+	})
+}
+
+func TestInvolves(t *testing.T) {
+	t.Run("should match the sender, the recipient and the deployed contract", func(t *testing.T) {
+		tx := types.Transaction{From: "0xfrom", To: "0xto"}
+		require.True(t, involves(tx, "0xFROM"))
+		require.True(t, involves(tx, "0xto"))
+		require.False(t, involves(tx, "0xcontract"))
+
+		creation := types.Transaction{From: "0xfrom", ContractAddress: "0xcontract"}
+		require.True(t, involves(creation, "0xCONTRACT"))
+		require.False(t, involves(creation, ""), "should not match the missing recipient")
+	})
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Output data structures of the commands.
+
+type blockOutput struct {
+	Number                uint64              `json:"number"`
+	Hash                  string              `json:"hash"`
+	ParentHash            string              `json:"parentHash"`
+	Timestamp             time.Time           `json:"timestamp"`
+	Miner                 string              `json:"miner,omitempty"`
+	GasLimit              uint64              `json:"gasLimit,omitempty"`
+	GasUsed               uint64              `json:"gasUsed,omitempty"`
+	BaseFeePerGas         string              `json:"baseFeePerGas,omitempty"`
+	LogsBloom             string              `json:"logsBloom,omitempty"`
+	StateRoot             string              `json:"stateRoot,omitempty"`
+	ReceiptsRoot          string              `json:"receiptsRoot,omitempty"`
+	TransactionsRoot      string              `json:"transactionsRoot,omitempty"`
+	Transactions          []transactionOutput `json:"transactions"`
+	Withdrawals           []withdrawalOutput  `json:"withdrawals,omitempty"`
+	WithdrawalsRoot       string              `json:"withdrawalsRoot,omitempty"`
+	BlobGasUsed           *uint64             `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas         *uint64             `json:"excessBlobGas,omitempty"`
+	ParentBeaconBlockRoot string              `json:"parentBeaconBlockRoot,omitempty"`
+}
+
+type withdrawalOutput struct {
+	Index          uint64 `json:"index"`
+	ValidatorIndex uint64 `json:"validatorIndex"`
+	Address        string `json:"address"`
+	Amount         uint64 `json:"amount"` // gwei
+}
+
+func newBlockOutput(block types.Block) blockOutput {
+	output := blockOutput{
+		Number:                block.Number,
+		Hash:                  block.Hash,
+		ParentHash:            block.ParentHash,
+		Timestamp:             block.Timestamp,
+		Miner:                 block.Miner,
+		GasLimit:              block.GasLimit,
+		GasUsed:               block.GasUsed,
+		BaseFeePerGas:         bigIntString(block.BaseFeePerGas),
+		LogsBloom:             block.LogsBloom,
+		StateRoot:             block.StateRoot,
+		ReceiptsRoot:          block.ReceiptsRoot,
+		TransactionsRoot:      block.TransactionsRoot,
+		Transactions:          make([]transactionOutput, 0, len(block.Transactions)),
+		WithdrawalsRoot:       block.WithdrawalsRoot,
+		BlobGasUsed:           block.BlobGasUsed,
+		ExcessBlobGas:         block.ExcessBlobGas,
+		ParentBeaconBlockRoot: block.ParentBeaconBlockRoot,
+	}
+
+	for _, w := range block.Withdrawals {
+		output.Withdrawals = append(output.Withdrawals, withdrawalOutput(w))
+	}
+
+	for _, tx := range block.Transactions {
+		output.Transactions = append(output.Transactions, newTransactionOutput(tx))
+	}
+
+	return output
+}
+
+type transactionOutput struct {
+	Kind            string `json:"kind,omitempty"`
+	Hash            string `json:"hash,omitempty"`       // withdrawals have no hash
+	TraceIndex      *int   `json:"traceIndex,omitempty"` // only set for internal transfers
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	ContractAddress string `json:"contractAddress,omitempty"` // only set for contract creations
+	Value           string `json:"value"`                     // decimal string, it does not fit in a JSON number
+	// The fields of the signed transaction, only set for the external transactions.
+	Type                 *uint8            `json:"type,omitempty"`
+	Nonce                *uint64           `json:"nonce,omitempty"`
+	Gas                  uint64            `json:"gas,omitempty"`
+	GasPrice             string            `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string            `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string            `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string            `json:"maxFeePerBlobGas,omitempty"`
+	ConfirmationStatus   string            `json:"confirmationStatus,omitempty"`
+	Receipt              *receiptOutput    `json:"receipt,omitempty"`
+	Withdrawal           *withdrawalOutput `json:"withdrawal,omitempty"`
+}
+
+func newTransactionOutput(tx types.Transaction) transactionOutput {
+	output := transactionOutput{
+		Kind:               string(tx.Kind),
+		Hash:               tx.Hash,
+		BlockHash:          tx.BlockHash,
+		BlockNumber:        tx.BlockNumber,
+		From:               tx.From,
+		To:                 tx.To,
+		ContractAddress:    tx.ContractAddress,
+		Value:              tx.Value.String(),
+		ConfirmationStatus: string(tx.ConfirmationStatus),
+	}
+
+	if tx.Kind == types.TransactionKindInternal {
+		traceIndex := tx.TraceIndex
+		output.TraceIndex = &traceIndex
+	}
+
+	if tx.Kind == types.TransactionKindExternal {
+		txType, nonce := uint8(tx.Type), tx.Nonce
+		output.Type = &txType
+		output.Nonce = &nonce
+		output.Gas = tx.Gas
+		output.GasPrice = tx.GasPrice.String()
+		output.MaxFeePerGas = bigIntString(tx.MaxFeePerGas)
+		output.MaxPriorityFeePerGas = bigIntString(tx.MaxPriorityFeePerGas)
+		output.MaxFeePerBlobGas = bigIntString(tx.MaxFeePerBlobGas)
+	}
+
+	if tx.Withdrawal != nil {
+		withdrawal := withdrawalOutput(*tx.Withdrawal)
+		output.Withdrawal = &withdrawal
+	}
+
+	if tx.Receipt != nil {
+		output.Receipt = &receiptOutput{
+			Status:            string(tx.Receipt.Status),
+			GasUsed:           tx.Receipt.GasUsed,
+			EffectiveGasPrice: tx.Receipt.EffectiveGasPrice.String(),
+			ContractAddress:   tx.Receipt.ContractAddress,
+			Logs:              len(tx.Receipt.Logs),
+		}
+	}
+
+	return output
+}
+
+type receiptOutput struct {
+	Status            string `json:"status,omitempty"`
+	GasUsed           uint64 `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"` // decimal string, in wei
+	ContractAddress   string `json:"contractAddress,omitempty"`
+	Logs              int    `json:"logs"` // number of logs emitted by the transaction
+}
+
+// bigIntString returns the decimal string of n, empty when n is nil.
+func bigIntString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
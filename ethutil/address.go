@@ -0,0 +1,92 @@
+package ethutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const AddressLength = 20
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address is a 20 bytes account address.
+type Address [AddressLength]byte
+
+// ParseAddress parses a 0x prefixed hex address. Mixed case addresses must carry a valid EIP-55 checksum, while
+// all lowercase or all uppercase addresses are accepted as they are, since they carry no checksum.
+func ParseAddress(s string) (Address, error) {
+	b, err := decodeFixedHex(s, AddressLength)
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %w", ErrInvalidAddress, err)
+	}
+
+	var a Address
+	copy(a[:], b)
+
+	digits := s[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && a.Checksum()[2:] != digits {
+		return Address{}, fmt.Errorf("%w: %q does not match its EIP-55 checksum", ErrInvalidAddress, s)
+	}
+
+	return a, nil
+}
+
+// Bytes returns the bytes of the address.
+func (a Address) Bytes() []byte {
+	return a[:]
+}
+
+// String returns the canonical form of the address: 0x followed by 40 lowercase hex digits.
+func (a Address) String() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// Checksum returns the EIP-55 mixed case form of the address: the letters whose nibble in the Keccak-256 hash
+// of the lowercase hex address is 8 or more are uppercased.
+// More info: https://eips.ethereum.org/EIPS/eip-55
+func (a Address) Checksum() string {
+	digits := []byte(hex.EncodeToString(a[:]))
+	hash := Keccak256(digits)
+
+	for i, d := range digits {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+
+		if d >= 'a' && nibble >= 8 {
+			digits[i] = d - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(digits)
+}
+
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Address) UnmarshalText(text []byte) error {
+	parsed, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+
+	return nil
+}
+
+// CreateAddress returns the address of the contract created by a transaction of the sender with the given nonce:
+// the last 20 bytes of the Keccak-256 hash of the RLP encoding of [sender, nonce].
+func CreateAddress(sender Address, nonce uint64) Address {
+	// Byte strings and integers cannot fail to encode.
+	encoded, _ := EncodeRLP([]interface{}{sender.Bytes(), nonce})
+
+	var a Address
+	copy(a[:], Keccak256(encoded)[keccak256Size-AddressLength:])
+
+	return a
+}
@@ -0,0 +1,87 @@
+package ethutil
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	t.Run("should accept the EIP-55 addresses and the addresses without checksum", func(t *testing.T) {
+		for _, s := range []string{
+			"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+			"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+			"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+			"0x52908400098527886E0F7030069857D2E4169EE7",
+			"0xde709f2102306220921060314715629080e2fb77",
+		} {
+			a, err := ParseAddress(s)
+			require.NoError(t, err, s)
+			require.Equal(t, strings.ToLower(s), a.String())
+		}
+	})
+
+	t.Run("should error because of malformed addresses", func(t *testing.T) {
+		for _, s := range []string{
+			"",
+			"0x",
+			"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			"0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea",
+			"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed00",
+			"0xzaaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", // wrong checksum
+		} {
+			_, err := ParseAddress(s)
+			require.ErrorIs(t, err, ErrInvalidAddress, s)
+		}
+	})
+}
+
+func TestAddress_Checksum(t *testing.T) {
+	for _, s := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		a, err := ParseAddress(strings.ToLower(s))
+		require.NoError(t, err)
+		require.Equal(t, s, a.Checksum())
+	}
+}
+
+func TestAddress_JSON(t *testing.T) {
+	t.Run("should marshal the canonical form", func(t *testing.T) {
+		a, err := ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+		require.NoError(t, err)
+
+		b, err := json.Marshal(map[string]Address{"address": a})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"address": "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`, string(b))
+
+		var decoded map[string]Address
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		require.Equal(t, a, decoded["address"])
+	})
+
+	t.Run("should error because of malformed addresses", func(t *testing.T) {
+		var a Address
+		require.ErrorIs(t, json.Unmarshal([]byte(`"0x1234"`), &a), ErrInvalidAddress)
+	})
+}
+
+func TestCreateAddress(t *testing.T) {
+	sender, err := ParseAddress("0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0")
+	require.NoError(t, err)
+
+	for nonce, expected := range []string{
+		"0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d",
+		"0x343c43a37d37dff08ae8c4a11544c718abb4fcf8",
+		"0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91",
+	} {
+		require.Equal(t, expected, CreateAddress(sender, uint64(nonce)).String())
+	}
+}
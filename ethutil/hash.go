@@ -0,0 +1,60 @@
+package ethutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const HashLength = 32
+
+var ErrInvalidHash = errors.New("invalid hash")
+
+// Hash is a 32 bytes Keccak-256 hash, e.g. of a block or of a transaction.
+type Hash [HashLength]byte
+
+// ParseHash parses a 0x prefixed hex hash.
+func ParseHash(s string) (Hash, error) {
+	b, err := decodeFixedHex(s, HashLength)
+	if err != nil {
+		return Hash{}, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	var h Hash
+	copy(h[:], b)
+
+	return h, nil
+}
+
+// Keccak256Hash returns the Keccak-256 hash of the concatenation of the data.
+func Keccak256Hash(data ...[]byte) Hash {
+	var h Hash
+	copy(h[:], Keccak256(data...))
+
+	return h
+}
+
+// Bytes returns the bytes of the hash.
+func (h Hash) Bytes() []byte {
+	return h[:]
+}
+
+// String returns the canonical form of the hash: 0x followed by 64 lowercase hex digits.
+func (h Hash) String() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	parsed, err := ParseHash(string(text))
+	if err != nil {
+		return err
+	}
+
+	*h = parsed
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package ethutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHash(t *testing.T) {
+	t.Run("should parse a hash", func(t *testing.T) {
+		h, err := ParseHash("0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF")
+		require.NoError(t, err)
+		require.Equal(t, Keccak256Hash([]byte("Transfer(address,address,uint256)")), h)
+		require.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", h.String())
+	})
+
+	t.Run("should error because of malformed hashes", func(t *testing.T) {
+		for _, s := range []string{"", "0x1234", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"} {
+			_, err := ParseHash(s)
+			require.ErrorIs(t, err, ErrInvalidHash, s)
+		}
+	})
+}
+
+func TestHash_JSON(t *testing.T) {
+	h := Keccak256Hash()
+
+	b, err := json.Marshal(h)
+	require.NoError(t, err)
+	require.Equal(t, `"0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"`, string(b))
+
+	var decoded Hash
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Equal(t, h, decoded)
+
+	require.ErrorIs(t, json.Unmarshal([]byte(`"0x"`), &decoded), ErrInvalidHash)
+}
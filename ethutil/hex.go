@@ -0,0 +1,20 @@
+package ethutil
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// decodeFixedHex decodes a 0x prefixed hex string of exactly size bytes.
+func decodeFixedHex(s string, size int) ([]byte, error) {
+	if len(s) != 2+2*size || s[0] != '0' || (s[1] != 'x' && s[1] != 'X') {
+		return nil, fmt.Errorf("%q is not 0x followed by %d hex digits", s, 2*size)
+	}
+
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("%q is not hex encoded: %w", s, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,152 @@
+package ethutil
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// Keccak-256 is the original Keccak submission used by Ethereum, which differs from the standardized SHA3-256
+// by its padding. It is implemented here to keep the module free of dependencies.
+
+const (
+	keccak256Size = 32
+	keccak256Rate = 136 // bytes absorbed per permutation: 1600 bits of state minus twice the output size
+)
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// Rotation offsets and destination lanes of the rho and pi steps, following the lanes from lane 1.
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakPiLanes   = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// Keccak256 returns the Keccak-256 hash of the concatenation of the data.
+func Keccak256(data ...[]byte) []byte {
+	h := NewKeccak256()
+	for _, d := range data {
+		_, _ = h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+// NewKeccak256 returns a hash.Hash computing the Keccak-256 hash.
+func NewKeccak256() hash.Hash {
+	return &keccak256{}
+}
+
+type keccak256 struct {
+	state   [25]uint64
+	pending []byte // input not absorbed yet, shorter than the rate
+}
+
+func (k *keccak256) Write(p []byte) (int, error) {
+	n := len(p)
+
+	if len(k.pending) > 0 {
+		missing := keccak256Rate - len(k.pending)
+		if len(p) < missing {
+			k.pending = append(k.pending, p...)
+			return n, nil
+		}
+
+		k.pending = append(k.pending, p[:missing]...)
+		k.absorb(k.pending)
+		k.pending = k.pending[:0]
+		p = p[missing:]
+	}
+
+	for len(p) >= keccak256Rate {
+		k.absorb(p[:keccak256Rate])
+		p = p[keccak256Rate:]
+	}
+
+	k.pending = append(k.pending, p...)
+
+	return n, nil
+}
+
+// Sum appends the hash of the data written so far to b, without changing the state of the hash.
+func (k *keccak256) Sum(b []byte) []byte {
+	d := keccak256{state: k.state}
+
+	block := make([]byte, keccak256Rate)
+	copy(block, k.pending)
+	block[len(k.pending)] ^= 0x01
+	block[keccak256Rate-1] ^= 0x80
+	d.absorb(block)
+
+	var out [keccak256Size]byte
+	for i := 0; i < keccak256Size/8; i++ {
+		binary.LittleEndian.PutUint64(out[i*8:], d.state[i])
+	}
+
+	return append(b, out[:]...)
+}
+
+func (k *keccak256) Reset() {
+	k.state = [25]uint64{}
+	k.pending = k.pending[:0]
+}
+
+func (k *keccak256) Size() int {
+	return keccak256Size
+}
+
+func (k *keccak256) BlockSize() int {
+	return keccak256Rate
+}
+
+func (k *keccak256) absorb(block []byte) {
+	for i := 0; i < keccak256Rate/8; i++ {
+		k.state[i] ^= binary.LittleEndian.Uint64(block[i*8:])
+	}
+
+	keccakF1600(&k.state)
+}
+
+// keccakF1600 is the Keccak-f[1600] permutation.
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+
+	for round := 0; round < 24; round++ {
+		// Theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+
+		// Rho and pi
+		current := a[1]
+		for i := 0; i < 24; i++ {
+			lane := keccakPiLanes[i]
+			current, a[lane] = a[lane], bits.RotateLeft64(current, keccakRotations[i])
+		}
+
+		// Chi
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := 0; x < 5; x++ {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+
+		// Iota
+		a[0] ^= keccakRoundConstants[round]
+	}
+}
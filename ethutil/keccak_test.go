@@ -0,0 +1,44 @@
+package ethutil
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeccak256(t *testing.T) {
+	t.Run("should hash the known vectors", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"":                                  "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+			"abc":                               "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+			"Transfer(address,address,uint256)": "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		} {
+			require.Equal(t, expected, hex.EncodeToString(Keccak256([]byte(input))), input)
+		}
+	})
+
+	t.Run("should hash the data written in chunks across blocks", func(t *testing.T) {
+		data := []byte(strings.Repeat("ethparser", 100))
+
+		h := NewKeccak256()
+		for i := 0; i < len(data); i += 7 {
+			_, err := h.Write(data[i:min(i+7, len(data))])
+			require.NoError(t, err)
+		}
+
+		require.Equal(t, Keccak256(data), h.Sum(nil))
+		require.Equal(t, Keccak256(data[:300], data[300:]), h.Sum(nil), "sum should not change the state")
+
+		h.Reset()
+		require.Equal(t, Keccak256(), h.Sum(nil))
+	})
+
+	t.Run("should hash inputs of the size of the rate", func(t *testing.T) {
+		// The padding of an input filling the rate needs a whole new block.
+		require.Equal(t,
+			"3a5912a7c5faa06ee4fe906253e339467a9ce87d533c65be3c15cb231cdb25f9",
+			hex.EncodeToString(Keccak256(make([]byte, keccak256Rate))))
+	})
+}
@@ -0,0 +1,177 @@
+package ethutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Recursive Length Prefix, the serialization of the Ethereum execution layer.
+// More info: https://ethereum.org/en/developers/docs/data-structures-and-encoding/rlp/
+
+const (
+	rlpStringOffset = 0x80
+	rlpListOffset   = 0xc0
+	rlpShortLimit   = 55 // longest payload whose length fits in the prefix
+)
+
+var (
+	ErrRLPNegativeInteger = errors.New("rlp cannot encode negative integers")
+	ErrRLPUnexpectedEnd   = errors.New("rlp data ends before the end of an item")
+	ErrRLPNonCanonical    = errors.New("rlp data is not in canonical form")
+	ErrRLPTrailingData    = errors.New("rlp data continues after the end of the item")
+)
+
+// EncodeRLP returns the RLP encoding of v, which is a []byte, a uint64, a *big.Int, or a []interface{} list of
+// those values. Integers are encoded as big endian byte strings without leading zeros.
+func EncodeRLP(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		if len(v) == 1 && v[0] < rlpStringOffset {
+			return []byte{v[0]}, nil
+		}
+
+		return append(rlpPrefix(rlpStringOffset, len(v)), v...), nil
+	case uint64:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], v)
+
+		return EncodeRLP(trimLeadingZeros(b[:]))
+	case *big.Int:
+		if v.Sign() < 0 {
+			return nil, ErrRLPNegativeInteger
+		}
+
+		return EncodeRLP(v.Bytes())
+	case []interface{}:
+		var payload []byte
+
+		for i, item := range v {
+			encoded, err := EncodeRLP(item)
+			if err != nil {
+				return nil, fmt.Errorf("could not encode list item %d: %w", i, err)
+			}
+
+			payload = append(payload, encoded...)
+		}
+
+		return append(rlpPrefix(rlpListOffset, len(payload)), payload...), nil
+	default:
+		return nil, fmt.Errorf("rlp cannot encode values of type %T", v)
+	}
+}
+
+// DecodeRLP decodes the single RLP item of data: byte strings are returned as []byte and lists as []interface{}
+// of items. The byte strings are slices of data. Non canonical encodings, e.g. a length prefix that could be
+// shorter, are rejected, so that every item has a single encoding.
+func DecodeRLP(data []byte) (interface{}, error) {
+	item, rest, err := decodeRLPItem(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, ErrRLPTrailingData
+	}
+
+	return item, nil
+}
+
+// decodeRLPItem decodes the item at the start of data and returns the data following it.
+func decodeRLPItem(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, ErrRLPUnexpectedEnd
+	}
+
+	switch prefix := data[0]; {
+	case prefix < rlpStringOffset:
+		return data[:1], data[1:], nil
+	case prefix < rlpListOffset:
+		payload, rest, err := splitRLPPayload(data, rlpStringOffset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(payload) == 1 && payload[0] < rlpStringOffset {
+			return nil, nil, fmt.Errorf("%w: single byte %#x with a length prefix", ErrRLPNonCanonical, payload[0])
+		}
+
+		return payload, rest, nil
+	default:
+		payload, rest, err := splitRLPPayload(data, rlpListOffset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items := []interface{}{}
+
+		for len(payload) > 0 {
+			var item interface{}
+			if item, payload, err = decodeRLPItem(payload); err != nil {
+				return nil, nil, fmt.Errorf("could not decode list item %d: %w", len(items), err)
+			}
+
+			items = append(items, item)
+		}
+
+		return items, rest, nil
+	}
+}
+
+// splitRLPPayload splits the payload of the string or list, depending on the offset, starting data from the data
+// following it.
+func splitRLPPayload(data []byte, offset byte) ([]byte, []byte, error) {
+	prefix, data := data[0]-offset, data[1:]
+
+	length := uint64(prefix)
+
+	if prefix > rlpShortLimit {
+		lengthSize := int(prefix - rlpShortLimit)
+		if len(data) < lengthSize {
+			return nil, nil, ErrRLPUnexpectedEnd
+		}
+
+		if data[0] == 0 {
+			return nil, nil, fmt.Errorf("%w: length with leading zeros", ErrRLPNonCanonical)
+		}
+
+		length = 0
+		for _, b := range data[:lengthSize] {
+			length = length<<8 | uint64(b)
+		}
+
+		if length <= rlpShortLimit {
+			return nil, nil, fmt.Errorf("%w: short payload with a long length prefix", ErrRLPNonCanonical)
+		}
+
+		data = data[lengthSize:]
+	}
+
+	if length > uint64(len(data)) {
+		return nil, nil, ErrRLPUnexpectedEnd
+	}
+
+	return data[:length], data[length:], nil
+}
+
+// rlpPrefix returns the prefix of a string or list, depending on the offset, with a payload of the given length.
+func rlpPrefix(offset byte, length int) []byte {
+	if length <= rlpShortLimit {
+		return []byte{offset + byte(length)}
+	}
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(length))
+	lengthBytes := trimLeadingZeros(b[:])
+
+	return append([]byte{offset + rlpShortLimit + byte(len(lengthBytes))}, lengthBytes...)
+}
+
+func trimLeadingZeros(b []byte) []byte {
+	for len(b) > 0 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	return b
+}
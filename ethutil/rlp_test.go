@@ -0,0 +1,93 @@
+package ethutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeRLP(t *testing.T) {
+	t.Run("should encode the known vectors", func(t *testing.T) {
+		longString := []byte("Lorem ipsum dolor sit amet, consectetur adipisicing elit")
+		sixtyBytes := bytes.Repeat([]byte{'a'}, 60)
+
+		for name, tc := range map[string]struct {
+			value    interface{}
+			expected string
+		}{
+			"empty string":    {[]byte{}, "80"},
+			"single byte":     {[]byte{0x0f}, "0f"},
+			"byte over 0x7f":  {[]byte{0x80}, "8180"},
+			"short string":    {[]byte("dog"), "83646f67"},
+			"long string":     {longString, "b838" + hex.EncodeToString(longString)},
+			"zero":            {uint64(0), "80"},
+			"small integer":   {uint64(15), "0f"},
+			"integer":         {uint64(1024), "820400"},
+			"big integer":     {new(big.Int).Lsh(big.NewInt(1), 64), "89010000000000000000"},
+			"empty list":      {[]interface{}{}, "c0"},
+			"list of strings": {[]interface{}{[]byte("cat"), []byte("dog")}, "c88363617483646f67"},
+			"set of three":    {[]interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}, "c3c0c1c0"},
+			"long list":       {[]interface{}{sixtyBytes}, "f83eb83c" + hex.EncodeToString(sixtyBytes)},
+		} {
+			got, err := EncodeRLP(tc.value)
+			require.NoError(t, err, name)
+			require.Equal(t, tc.expected, hex.EncodeToString(got), name)
+		}
+	})
+
+	t.Run("should error because of unsupported values", func(t *testing.T) {
+		_, err := EncodeRLP(big.NewInt(-1))
+		require.ErrorIs(t, err, ErrRLPNegativeInteger)
+
+		_, err = EncodeRLP([]interface{}{"not bytes"})
+		require.ErrorContains(t, err, "could not encode list item 0")
+	})
+}
+
+func TestDecodeRLP(t *testing.T) {
+	t.Run("should decode what was encoded", func(t *testing.T) {
+		for _, value := range []interface{}{
+			[]byte{},
+			[]byte{0x0f},
+			[]byte{0x80},
+			bytes.Repeat([]byte{'a'}, 1024),
+			[]interface{}{},
+			[]interface{}{[]byte("cat"), []interface{}{[]byte("dog"), []interface{}{}}},
+			[]interface{}{bytes.Repeat([]byte{'a'}, 60)},
+		} {
+			encoded, err := EncodeRLP(value)
+			require.NoError(t, err)
+
+			decoded, err := DecodeRLP(encoded)
+			require.NoError(t, err)
+			require.Equal(t, value, decoded)
+		}
+	})
+
+	t.Run("should error because of malformed data", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			data     string
+			expected error
+		}{
+			"empty":                      {"", ErrRLPUnexpectedEnd},
+			"truncated string":           {"83646f", ErrRLPUnexpectedEnd},
+			"truncated length":           {"b9", ErrRLPUnexpectedEnd},
+			"truncated list item":        {"c283646f67", ErrRLPUnexpectedEnd},
+			"trailing data":              {"8080", ErrRLPTrailingData},
+			"single byte with prefix":    {"810f", ErrRLPNonCanonical},
+			"length with leading zeros":  {"b90038" + strings.Repeat("61", 56), ErrRLPNonCanonical},
+			"short string in long form":  {"b801" + "80", ErrRLPNonCanonical},
+			"non canonical item of list": {"c2810f", ErrRLPNonCanonical},
+		} {
+			data, err := hex.DecodeString(tc.data)
+			require.NoError(t, err)
+
+			_, err = DecodeRLP(data)
+			require.ErrorIs(t, err, tc.expected, name)
+		}
+	})
+}
@@ -3,7 +3,10 @@ package ethereum
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 
 	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
 	"github.com/ilkamo/ethparser-go/types"
@@ -11,23 +14,37 @@ import (
 
 type RPCClient interface {
 	Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+	CallBatch(ctx context.Context, batch []jsonrpc.BatchElem) error
 }
 
+// SubscriptionClient is implemented by the RPC clients supporting subscriptions, like jsonrpc.WebSocketClient
+// and jsonrpc.IPCClient.
+type SubscriptionClient interface {
+	Subscribe(ctx context.Context, namespace string, args ...interface{}) (types.RPCSubscription, error)
+}
+
+// ErrSubscriptionsNotSupported is returned when subscribing with an RPC client that does not support subscriptions.
+var ErrSubscriptionsNotSupported = errors.New("rpc client does not support subscriptions")
+
 type Option func(c *Client)
 
 type Client struct {
-	rpcClient RPCClient
+	rpcClient      RPCClient
+	jsonrpcOptions []jsonrpc.Option
+	traceAPI       TraceAPI
+	// noBlockReceipts is set once the node answered that it does not support eth_getBlockReceipts.
+	noBlockReceipts *atomic.Bool
 }
 
 func NewClient(endpoint string, opts ...Option) (Client, error) {
-	c := &Client{}
+	c := &Client{traceAPI: TraceAPIDebug, noBlockReceipts: &atomic.Bool{}}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
 	if c.rpcClient == nil {
-		rpcClient, err := jsonrpc.NewClient(endpoint)
+		rpcClient, err := newRPCClient(endpoint, c.jsonrpcOptions)
 		if err != nil {
 			return Client{}, fmt.Errorf("could not create rpc client: %w", err)
 		}
@@ -38,6 +55,24 @@ func NewClient(endpoint string, opts ...Option) (Client, error) {
 	return *c, nil
 }
 
+// newRPCClient creates the RPC client of the transport of the endpoint: WebSocket for ws:// and wss:// urls,
+// IPC for filesystem paths like /data/geth.ipc, HTTP otherwise. The options only apply to the HTTP client.
+func newRPCClient(endpoint string, jsonrpcOptions []jsonrpc.Option) (RPCClient, error) {
+	switch {
+	case strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://"):
+		return jsonrpc.NewWebSocketClient(endpoint)
+	case isIPCEndpoint(endpoint):
+		return jsonrpc.NewIPCClient(endpoint)
+	default:
+		return jsonrpc.NewClient(endpoint, jsonrpcOptions...)
+	}
+}
+
+// isIPCEndpoint reports whether the endpoint is a filesystem path rather than a url.
+func isIPCEndpoint(endpoint string) bool {
+	return endpoint != "" && !strings.Contains(endpoint, "://")
+}
+
 // GetMostRecentBlockNumber returns the number of the most recent block.
 func (c Client) GetMostRecentBlockNumber(ctx context.Context) (uint64, error) {
 	resp, err := c.rpcClient.Call(ctx, "eth_blockNumber", nil)
@@ -64,6 +99,224 @@ func (c Client) GetBlockByNumber(ctx context.Context, blockNumber uint64) (types
 		return types.Block{}, fmt.Errorf("could not call rpc method: %w", err)
 	}
 
+	return decodeBlock(resp)
+}
+
+// GetBlocksByNumber returns the blocks with the given numbers, fetched with a single batch call.
+// Blocks that could not be fetched are missing from the result, and the returned error tells why.
+func (c Client) GetBlocksByNumber(ctx context.Context, blockNumbers []uint64) (map[uint64]types.Block, error) {
+	batch := make([]jsonrpc.BatchElem, len(blockNumbers))
+	for i, blockNumber := range blockNumbers {
+		batch[i] = jsonrpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Params: []interface{}{EthNumberFromUnit64(blockNumber), true},
+		}
+	}
+
+	if err := c.rpcClient.CallBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("could not call rpc batch: %w", err)
+	}
+
+	blocks := make(map[uint64]types.Block, len(blockNumbers))
+
+	var errs []error
+	for i, elem := range batch {
+		if elem.Error != nil {
+			errs = append(errs, fmt.Errorf("could not get block %d: %w", blockNumbers[i], elem.Error))
+			continue
+		}
+
+		b, err := decodeBlock(elem.Result)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not get block %d: %w", blockNumbers[i], err))
+			continue
+		}
+
+		if b.Number != blockNumbers[i] {
+			errs = append(errs, fmt.Errorf("got block %d instead of block %d", b.Number, blockNumbers[i]))
+			continue
+		}
+
+		blocks[b.Number] = b
+	}
+
+	return blocks, errors.Join(errs...)
+}
+
+// GetTransactionReceipts returns the receipts of the transactions with the given hashes, included in the block
+// with the given hash, by transaction hash. The receipts of the block are fetched with a single
+// eth_getBlockReceipts call. When the node does not support it, the receipts of the transactions are fetched with
+// a batch of eth_getTransactionReceipt calls. It fails when a receipt is missing or belongs to another block,
+// e.g. because the block was reorged out.
+func (c Client) GetTransactionReceipts(
+	ctx context.Context,
+	blockHash string,
+	txHashes []string,
+) (map[string]types.Receipt, error) {
+	if len(txHashes) == 0 {
+		return map[string]types.Receipt{}, nil
+	}
+
+	if !c.noBlockReceipts.Load() {
+		receipts, err := c.getBlockReceipts(ctx, blockHash, txHashes)
+
+		var rpcErr *jsonrpc.Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeMethodNotFound {
+			return receipts, err
+		}
+
+		c.noBlockReceipts.Store(true)
+	}
+
+	return c.getTransactionReceipts(ctx, blockHash, txHashes)
+}
+
+func (c Client) getBlockReceipts(
+	ctx context.Context,
+	blockHash string,
+	txHashes []string,
+) (map[string]types.Receipt, error) {
+	resp, err := c.rpcClient.Call(ctx, "eth_getBlockReceipts", []interface{}{blockHash})
+	if err != nil {
+		return nil, fmt.Errorf("could not call rpc method: %w", err)
+	}
+
+	var blockReceipts []receipt
+	if err := json.Unmarshal(resp, &blockReceipts); err != nil {
+		return nil, fmt.Errorf("could not unmarshal block receipts: %w", err)
+	}
+
+	byHash := make(map[string]receipt, len(blockReceipts))
+	for _, r := range blockReceipts {
+		byHash[r.TransactionHash] = r
+	}
+
+	receipts := make(map[string]types.Receipt, len(txHashes))
+
+	for _, txHash := range txHashes {
+		r, ok := byHash[txHash]
+		if !ok {
+			return nil, fmt.Errorf("missing receipt of transaction %s in block %s", txHash, blockHash)
+		}
+
+		if receipts[txHash], err = decodeReceipt(r, blockHash); err != nil {
+			return nil, err
+		}
+	}
+
+	return receipts, nil
+}
+
+func (c Client) getTransactionReceipts(
+	ctx context.Context,
+	blockHash string,
+	txHashes []string,
+) (map[string]types.Receipt, error) {
+	batch := make([]jsonrpc.BatchElem, len(txHashes))
+	for i, txHash := range txHashes {
+		batch[i] = jsonrpc.BatchElem{Method: "eth_getTransactionReceipt", Params: []interface{}{txHash}}
+	}
+
+	if err := c.rpcClient.CallBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("could not call rpc batch: %w", err)
+	}
+
+	receipts := make(map[string]types.Receipt, len(txHashes))
+
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("could not get receipt of transaction %s: %w", txHashes[i], elem.Error)
+		}
+
+		var r *receipt
+		if err := json.Unmarshal(elem.Result, &r); err != nil {
+			return nil, fmt.Errorf("could not unmarshal receipt of transaction %s: %w", txHashes[i], err)
+		}
+
+		if r == nil {
+			return nil, fmt.Errorf("missing receipt of transaction %s", txHashes[i])
+		}
+
+		var err error
+		if receipts[txHashes[i]], err = decodeReceipt(*r, blockHash); err != nil {
+			return nil, err
+		}
+	}
+
+	return receipts, nil
+}
+
+func decodeReceipt(r receipt, blockHash string) (types.Receipt, error) {
+	if !strings.EqualFold(r.BlockHash, blockHash) {
+		return types.Receipt{}, fmt.Errorf("receipt of transaction %s belongs to block %s instead of block %s",
+			r.TransactionHash, r.BlockHash, blockHash)
+	}
+
+	decoded, err := r.ToReceipt()
+	if err != nil {
+		return types.Receipt{}, fmt.Errorf("could not decode receipt of transaction %s: %w", r.TransactionHash, err)
+	}
+
+	return decoded, nil
+}
+
+// SubscribeNewHeads returns a channel receiving the number of every new head of the chain. When the receiver
+// is slower than the chain, only the most recent head is kept. The channel is closed when the context is
+// canceled or the subscription ends. It returns ErrSubscriptionsNotSupported when the RPC client does not
+// support subscriptions.
+func (c Client) SubscribeNewHeads(ctx context.Context) (<-chan uint64, error) {
+	subscriptionClient, ok := c.rpcClient.(SubscriptionClient)
+	if !ok {
+		return nil, ErrSubscriptionsNotSupported
+	}
+
+	sub, err := subscriptionClient.Subscribe(ctx, "newHeads")
+	if err != nil {
+		return nil, fmt.Errorf("could not subscribe to new heads: %w", err)
+	}
+
+	heads := make(chan uint64, 1)
+
+	go func() {
+		defer close(heads)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case notification, ok := <-sub.Notifications():
+				if !ok {
+					return
+				}
+
+				var head struct {
+					Number string `json:"number"`
+				}
+				if err := json.Unmarshal(notification, &head); err != nil {
+					continue
+				}
+
+				number, err := Uint64FromEthNumber(head.Number)
+				if err != nil {
+					continue
+				}
+
+				// Replace the head that was not received yet.
+				select {
+				case <-heads:
+				default:
+				}
+
+				heads <- number
+			}
+		}
+	}()
+
+	return heads, nil
+}
+
+func decodeBlock(resp json.RawMessage) (types.Block, error) {
 	var b block
 	if err := json.Unmarshal(resp, &b); err != nil {
 		return types.Block{}, fmt.Errorf("could not unmarshal block: %w", err)
@@ -2,12 +2,17 @@ package ethereum
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
 	"github.com/ilkamo/ethparser-go/internal/mock"
 	"github.com/ilkamo/ethparser-go/internal/testdata"
+	"github.com/ilkamo/ethparser-go/types"
 )
 
 const endpoint = "http://localhost:1212"
@@ -23,6 +28,22 @@ func TestNewClient(t *testing.T) {
 		_, err := NewClient("")
 		require.Error(t, err)
 	})
+
+	t.Run("should select the transport of the endpoint", func(t *testing.T) {
+		c, err := NewClient("ws://localhost:8546")
+		require.NoError(t, err)
+		require.IsType(t, &jsonrpc.WebSocketClient{}, c.rpcClient)
+
+		for _, path := range []string{"/var/lib/geth/geth.ipc", "./geth.ipc", "geth.ipc"} {
+			c, err = NewClient(path)
+			require.NoError(t, err)
+			require.IsType(t, &jsonrpc.IPCClient{}, c.rpcClient, path)
+		}
+
+		c, err = NewClient(endpoint)
+		require.NoError(t, err)
+		require.IsType(t, jsonrpc.Client{}, c.rpcClient)
+	})
 }
 
 func TestClient_GetMostRecentBlockNumber(t *testing.T) {
@@ -95,3 +116,238 @@ func TestClient_GetBlockByNumber(t *testing.T) {
 		require.ErrorContains(t, err, "could not call rpc method: test error")
 	})
 }
+
+func TestClient_GetBlocksByNumber(t *testing.T) {
+	ctx := context.TODO()
+	expectedBlockNumber := uint64(19697111)
+
+	t.Run("should return the fetched blocks and the errors of the others", func(t *testing.T) {
+		mockRPCClient := &mock.RPCClient{
+			BatchResults: []types.BatchElem{
+				{Result: testdata.BlockJSON},
+				{Error: errors.New("rpc error: header not found")},
+				{Result: []byte(`null`)},
+			},
+		}
+
+		c, err := NewClient(endpoint, WithRPCClient(mockRPCClient))
+		require.NoError(t, err)
+
+		blocks, err := c.GetBlocksByNumber(ctx, []uint64{expectedBlockNumber, 19697112, 19697113})
+		require.ErrorContains(t, err, "could not get block 19697112: rpc error: header not found")
+		require.ErrorContains(t, err, "could not get block 19697113")
+		require.Len(t, blocks, 1)
+		require.Equal(t, expectedBlock, blocks[expectedBlockNumber])
+	})
+
+	t.Run("should error because of unexpected block", func(t *testing.T) {
+		mockRPCClient := &mock.RPCClient{
+			BatchResults: []types.BatchElem{{Result: testdata.BlockJSON}},
+		}
+
+		c, err := NewClient(endpoint, WithRPCClient(mockRPCClient))
+		require.NoError(t, err)
+
+		blocks, err := c.GetBlocksByNumber(ctx, []uint64{1})
+		require.ErrorContains(t, err, "got block 19697111 instead of block 1")
+		require.Empty(t, blocks)
+	})
+
+	t.Run("should error because of rpc error", func(t *testing.T) {
+		mockRPCClient := &mock.RPCClient{ShouldError: true}
+
+		c, err := NewClient(endpoint, WithRPCClient(mockRPCClient))
+		require.NoError(t, err)
+
+		_, err = c.GetBlocksByNumber(ctx, []uint64{1})
+		require.ErrorContains(t, err, "could not call rpc batch: test error")
+	})
+}
+
+func TestClient_SubscribeNewHeads(t *testing.T) {
+	t.Run("should send the number of the new heads", func(t *testing.T) {
+		notifications := make(chan json.RawMessage)
+		var namespaces []string
+
+		c, err := NewClient(endpoint, WithRPCClient(mock.SubscriptionRPCClient{
+			Notifications: notifications,
+			Namespaces:    &namespaces,
+		}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		heads, err := c.SubscribeNewHeads(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"newHeads"}, namespaces)
+
+		notifications <- json.RawMessage(`{"number":"0x10","hash":"0xabc"}`)
+		require.Equal(t, uint64(16), <-heads)
+
+		// Only the most recent head is kept for a slow receiver, invalid notifications are skipped.
+		notifications <- json.RawMessage(`{"number":"0x11"}`)
+		notifications <- json.RawMessage(`{"number":"invalid"}`)
+		notifications <- json.RawMessage(`{"number":"0x12"}`)
+		notifications <- json.RawMessage(`{}`) // received once the previous head is handled
+		require.Len(t, heads, 1)
+		require.Equal(t, uint64(18), <-heads)
+
+		cancel()
+		_, ok := <-heads
+		require.False(t, ok, "channel should be closed when the context is canceled")
+	})
+
+	t.Run("should close the channel when the subscription ends", func(t *testing.T) {
+		notifications := make(chan json.RawMessage)
+
+		c, err := NewClient(endpoint, WithRPCClient(mock.SubscriptionRPCClient{Notifications: notifications}))
+		require.NoError(t, err)
+
+		heads, err := c.SubscribeNewHeads(context.TODO())
+		require.NoError(t, err)
+
+		close(notifications)
+		_, ok := <-heads
+		require.False(t, ok)
+	})
+
+	t.Run("should return error when subscriptions are not supported", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(mock.RPCClient{}))
+		require.NoError(t, err)
+
+		_, err = c.SubscribeNewHeads(context.TODO())
+		require.ErrorIs(t, err, ErrSubscriptionsNotSupported)
+
+		c, err = NewClient(endpoint, WithRPCClient(mock.SubscriptionRPCClient{SubscribeError: errors.New("test error")}))
+		require.NoError(t, err)
+
+		_, err = c.SubscribeNewHeads(context.TODO())
+		require.ErrorContains(t, err, "could not subscribe to new heads: test error")
+	})
+}
+
+// receiptsRPCClient answers eth_getBlockReceipts and eth_getTransactionReceipt from the receipts by
+// transaction hash, and records the called methods.
+type receiptsRPCClient struct {
+	receipts         map[string]string
+	blockReceiptsErr error
+	methods          []string
+}
+
+func (r *receiptsRPCClient) Call(_ context.Context, method string, _ interface{}) (json.RawMessage, error) {
+	r.methods = append(r.methods, method)
+
+	if r.blockReceiptsErr != nil {
+		return nil, r.blockReceiptsErr
+	}
+
+	var receipts []json.RawMessage
+	for _, receipt := range r.receipts {
+		receipts = append(receipts, json.RawMessage(receipt))
+	}
+
+	return json.Marshal(receipts)
+}
+
+func (r *receiptsRPCClient) CallBatch(_ context.Context, batch []jsonrpc.BatchElem) error {
+	for i := range batch {
+		r.methods = append(r.methods, batch[i].Method)
+
+		txHash := batch[i].Params.([]interface{})[0].(string)
+		if receipt, ok := r.receipts[txHash]; ok {
+			batch[i].Result = json.RawMessage(receipt)
+		} else {
+			batch[i].Result = json.RawMessage(`null`)
+		}
+	}
+
+	return nil
+}
+
+func TestClient_GetTransactionReceipts(t *testing.T) {
+	ctx := context.TODO()
+
+	receipts := map[string]string{
+		"0xa": `{"transactionHash":"0xa","blockHash":"0xb1","status":"0x1","gasUsed":"0x5208",` +
+			`"effectiveGasPrice":"0x3b9aca00","contractAddress":null,"logs":[{"address":"0xc",` +
+			`"topics":["0xt"],"data":"0x01","logIndex":"0x2"}]}`,
+		"0xb": `{"transactionHash":"0xb","blockHash":"0xb1","status":"0x0","gasUsed":"0x1",` +
+			`"effectiveGasPrice":"0x1","contractAddress":"0xcreated","logs":[]}`,
+	}
+
+	expectedA := types.Receipt{
+		TransactionHash:   "0xa",
+		Status:            types.ReceiptStatusSuccessful,
+		GasUsed:           21000,
+		EffectiveGasPrice: *big.NewInt(1000000000),
+		Logs:              []types.Log{{Address: "0xc", Topics: []string{"0xt"}, Data: "0x01", Index: 2}},
+	}
+
+	t.Run("should get the receipts of the block", func(t *testing.T) {
+		rpcClient := &receiptsRPCClient{receipts: receipts}
+
+		c, err := NewClient(endpoint, WithRPCClient(rpcClient))
+		require.NoError(t, err)
+
+		got, err := c.GetTransactionReceipts(ctx, "0xb1", []string{"0xa"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]types.Receipt{"0xa": expectedA}, got)
+		require.Equal(t, []string{"eth_getBlockReceipts"}, rpcClient.methods)
+	})
+
+	t.Run("should fall back to the receipts of the transactions", func(t *testing.T) {
+		rpcClient := &receiptsRPCClient{
+			receipts:         receipts,
+			blockReceiptsErr: &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "method not found"},
+		}
+
+		c, err := NewClient(endpoint, WithRPCClient(rpcClient))
+		require.NoError(t, err)
+
+		got, err := c.GetTransactionReceipts(ctx, "0xb1", []string{"0xa", "0xb"})
+		require.NoError(t, err)
+		require.Equal(t, expectedA, got["0xa"])
+		require.Equal(t, types.ReceiptStatusFailed, got["0xb"].Status)
+		require.Equal(t, "0xcreated", got["0xb"].ContractAddress)
+
+		_, err = c.GetTransactionReceipts(ctx, "0xb1", []string{"0xa"})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"eth_getBlockReceipts", "eth_getTransactionReceipt", "eth_getTransactionReceipt",
+			"eth_getTransactionReceipt",
+		}, rpcClient.methods, "should remember that block receipts are not supported")
+	})
+
+	t.Run("should error because of missing receipts", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(&receiptsRPCClient{receipts: receipts}))
+		require.NoError(t, err)
+
+		_, err = c.GetTransactionReceipts(ctx, "0xb1", []string{"0xmissing"})
+		require.ErrorContains(t, err, "missing receipt of transaction 0xmissing")
+
+		c.noBlockReceipts.Store(true)
+
+		_, err = c.GetTransactionReceipts(ctx, "0xb1", []string{"0xmissing"})
+		require.ErrorContains(t, err, "missing receipt of transaction 0xmissing")
+	})
+
+	t.Run("should error because of receipts of another block", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(&receiptsRPCClient{receipts: receipts}))
+		require.NoError(t, err)
+
+		_, err = c.GetTransactionReceipts(ctx, "0xreorged", []string{"0xa"})
+		require.ErrorContains(t, err, "belongs to block 0xb1 instead of block 0xreorged")
+	})
+
+	t.Run("should return other rpc errors", func(t *testing.T) {
+		rpcClient := &receiptsRPCClient{blockReceiptsErr: errors.New("test error")}
+
+		c, err := NewClient(endpoint, WithRPCClient(rpcClient))
+		require.NoError(t, err)
+
+		_, err = c.GetTransactionReceipts(ctx, "0xb1", []string{"0xa"})
+		require.ErrorContains(t, err, "could not call rpc method: test error")
+		require.False(t, c.noBlockReceipts.Load())
+	})
+}
@@ -1,8 +1,25 @@
 package ethereum
 
+import "github.com/ilkamo/ethparser-go/internal/jsonrpc"
+
 // WithRPCClient sets the RPC client for the Ethereum client.
 func WithRPCClient(rpcClient RPCClient) Option {
 	return func(c *Client) {
 		c.rpcClient = rpcClient
 	}
 }
+
+// WithJSONRPCOptions sets the options of the JSON-RPC client created when the endpoint is served over HTTP,
+// e.g. its rate limit. It has no effect with WithRPCClient.
+func WithJSONRPCOptions(opts ...jsonrpc.Option) Option {
+	return func(c *Client) {
+		c.jsonrpcOptions = opts
+	}
+}
+
+// WithTraceAPI sets the API used by GetInternalTransfers to trace the transactions, TraceAPIDebug by default.
+func WithTraceAPI(api TraceAPI) Option {
+	return func(c *Client) {
+		c.traceAPI = api
+	}
+}
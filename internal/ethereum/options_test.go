@@ -1,10 +1,13 @@
 package ethereum
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
 	"github.com/ilkamo/ethparser-go/internal/mock"
 )
 
@@ -23,3 +26,26 @@ func TestWithRPCClient(t *testing.T) {
 		require.Equal(t, mockedRPCClient, c.rpcClient)
 	})
 }
+
+func TestWithJSONRPCOptions(t *testing.T) {
+	t.Run("should create the json-rpc client with the options", func(t *testing.T) {
+		c, err := NewClient("http://127.0.0.1:1", WithJSONRPCOptions(
+			jsonrpc.WithLogger(&mock.Logger{}),
+			jsonrpc.WithoutRetries(),
+			jsonrpc.WithRateLimit(1, 1),
+		))
+		require.NoError(t, err)
+
+		_, _ = c.GetMostRecentBlockNumber(context.TODO())
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = c.GetMostRecentBlockNumber(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded, "second call should wait for the rate limit")
+
+		rpcClient, ok := c.rpcClient.(jsonrpc.Client)
+		require.True(t, ok)
+		require.Equal(t, uint64(1), rpcClient.LimiterStats().Requests)
+	})
+}
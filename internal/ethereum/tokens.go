@@ -0,0 +1,272 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Topics of the events emitted by the token contracts on transfers, i.e. the keccak-256 hash of their signature.
+const (
+	// Transfer(address indexed from, address indexed to, uint256 value) for ERC-20 tokens and
+	// Transfer(address indexed from, address indexed to, uint256 indexed tokenId) for ERC-721 tokens.
+	transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value).
+	transferSingleTopic = "0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62"
+	// TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids,
+	// uint256[] values).
+	transferBatchTopic = "0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"
+)
+
+const abiWordSize = 32
+
+// GetTokenTransfers returns the ERC-20, ERC-721 and ERC-1155 token transfers of the block with the given hash,
+// decoded from the transfer events fetched with a single eth_getLogs call. The events that do not follow the
+// standards, e.g. a Transfer event without indexed addresses, are ignored.
+func (c Client) GetTokenTransfers(ctx context.Context, blockHash string) ([]types.TokenTransfer, error) {
+	filter := map[string]interface{}{
+		"blockHash": blockHash,
+		"topics":    [][]string{{transferTopic, transferSingleTopic, transferBatchTopic}},
+	}
+
+	resp, err := c.rpcClient.Call(ctx, "eth_getLogs", []interface{}{filter})
+	if err != nil {
+		return nil, fmt.Errorf("could not call rpc method: %w", err)
+	}
+
+	var logs []receiptLog
+	if err := json.Unmarshal(resp, &logs); err != nil {
+		return nil, fmt.Errorf("could not unmarshal logs: %w", err)
+	}
+
+	var transfers []types.TokenTransfer
+
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+
+		if !strings.EqualFold(l.BlockHash, blockHash) {
+			return nil, fmt.Errorf("log of transaction %s belongs to block %s instead of block %s",
+				l.TransactionHash, l.BlockHash, blockHash)
+		}
+
+		decoded, err := decodeTokenTransfers(l)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode log of transaction %s: %w", l.TransactionHash, err)
+		}
+
+		transfers = append(transfers, decoded...)
+	}
+
+	return transfers, nil
+}
+
+// decodeTokenTransfers returns the token transfers of a transfer event, none when the event does not follow
+// the standards.
+func decodeTokenTransfers(l receiptLog) ([]types.TokenTransfer, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(l.Data, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode log data: %w", err)
+	}
+
+	var transfers []types.TokenTransfer
+
+	switch strings.ToLower(l.Topics[0]) {
+	case transferTopic:
+		transfers = decodeTransfer(l.Topics, data)
+	case transferSingleTopic:
+		transfers = decodeTransferSingle(l.Topics, data)
+	case transferBatchTopic:
+		transfers = decodeTransferBatch(l.Topics, data)
+	}
+
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+
+	blockNumber, err := Uint64FromEthNumber(l.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode log block number: %w", err)
+	}
+
+	logIndex, err := Uint64FromEthNumber(l.LogIndex)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode log index: %w", err)
+	}
+
+	for i := range transfers {
+		transfers[i].BlockHash = l.BlockHash
+		transfers[i].BlockNumber = blockNumber
+		transfers[i].TransactionHash = l.TransactionHash
+		transfers[i].LogIndex = logIndex
+		transfers[i].Token = strings.ToLower(l.Address)
+	}
+
+	return transfers, nil
+}
+
+// decodeTransfer decodes the Transfer event, shared by ERC-20 and ERC-721: the amount of ERC-20 tokens is in
+// the data, while the id of ERC-721 tokens is indexed.
+func decodeTransfer(topics []string, data []byte) []types.TokenTransfer {
+	if len(topics) < 3 {
+		return nil
+	}
+
+	from, okFrom := topicAddress(topics[1])
+	to, okTo := topicAddress(topics[2])
+	if !okFrom || !okTo {
+		return nil
+	}
+
+	transfer := types.TokenTransfer{From: from, To: to}
+
+	switch {
+	case len(topics) == 3 && len(data) == abiWordSize:
+		transfer.Standard = types.TokenStandardERC20
+		transfer.Amount.SetBytes(data)
+	case len(topics) == 4 && len(data) == 0:
+		tokenID, ok := topicUint(topics[3])
+		if !ok {
+			return nil
+		}
+
+		transfer.Standard = types.TokenStandardERC721
+		transfer.TokenID = tokenID
+	default:
+		return nil
+	}
+
+	return []types.TokenTransfer{transfer}
+}
+
+func decodeTransferSingle(topics []string, data []byte) []types.TokenTransfer {
+	if len(topics) != 4 || len(data) != 2*abiWordSize {
+		return nil
+	}
+
+	from, okFrom := topicAddress(topics[2])
+	to, okTo := topicAddress(topics[3])
+	if !okFrom || !okTo {
+		return nil
+	}
+
+	transfer := types.TokenTransfer{Standard: types.TokenStandardERC1155, From: from, To: to}
+	transfer.TokenID.SetBytes(data[:abiWordSize])
+	transfer.Amount.SetBytes(data[abiWordSize:])
+
+	return []types.TokenTransfer{transfer}
+}
+
+func decodeTransferBatch(topics []string, data []byte) []types.TokenTransfer {
+	if len(topics) != 4 {
+		return nil
+	}
+
+	from, okFrom := topicAddress(topics[2])
+	to, okTo := topicAddress(topics[3])
+	if !okFrom || !okTo {
+		return nil
+	}
+
+	ids, okIDs := abiUintArray(data, 0)
+	values, okValues := abiUintArray(data, 1)
+	if !okIDs || !okValues || len(ids) != len(values) {
+		return nil
+	}
+
+	transfers := make([]types.TokenTransfer, len(ids))
+	for i := range ids {
+		transfers[i] = types.TokenTransfer{
+			BatchIndex: i,
+			Standard:   types.TokenStandardERC1155,
+			From:       from,
+			To:         to,
+			Amount:     values[i],
+			TokenID:    ids[i],
+		}
+	}
+
+	return transfers
+}
+
+// topicAddress returns the address held by an indexed topic, left padded with zeros to 32 bytes.
+func topicAddress(topic string) (string, bool) {
+	topic = strings.ToLower(topic)
+	if len(topic) != 2+2*abiWordSize || !HasEthNumberPrefix(topic) {
+		return "", false
+	}
+
+	if strings.Trim(topic[2:26], "0") != "" {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(topic[26:]); err != nil {
+		return "", false
+	}
+
+	return "0x" + topic[26:], true
+}
+
+func topicUint(topic string) (big.Int, bool) {
+	if len(topic) != 2+2*abiWordSize {
+		return big.Int{}, false
+	}
+
+	n, err := BigIntFromEthNumber(topic)
+	if err != nil {
+		return big.Int{}, false
+	}
+
+	return n, true
+}
+
+// abiUintArray decodes the uint256[] whose offset is the word at the given position of the ABI encoded data.
+func abiUintArray(data []byte, position int) ([]big.Int, bool) {
+	offset, ok := abiOffset(data, position*abiWordSize)
+	if !ok {
+		return nil, false
+	}
+
+	length, ok := abiOffset(data, offset)
+	if !ok {
+		return nil, false
+	}
+
+	start := offset + abiWordSize
+	if length > (len(data)-start)/abiWordSize {
+		return nil, false
+	}
+
+	values := make([]big.Int, length)
+	for i := range values {
+		values[i].SetBytes(data[start+i*abiWordSize : start+(i+1)*abiWordSize])
+	}
+
+	return values, true
+}
+
+// abiOffset decodes the word starting at the given offset of the data as an offset, or a length, within the data.
+func abiOffset(data []byte, offset int) (int, bool) {
+	if offset < 0 || offset+abiWordSize > len(data) {
+		return 0, false
+	}
+
+	var n big.Int
+	n.SetBytes(data[offset : offset+abiWordSize])
+
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, false
+	}
+
+	return int(n.Int64()), true
+}
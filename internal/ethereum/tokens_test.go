@@ -0,0 +1,173 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	tokenAddress = "0x6b175474e89094c44da98b954eedeac495271d0f"
+	fromAddress  = "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	toAddress    = "0x28c6c06298d514db089934071355e5743bf21d60"
+	operatorAddr = "0x1e0049783f008a0085193e00003d00cd54003c71"
+)
+
+// word returns the 32 bytes ABI encoding of n, without the 0x prefix.
+func word(n uint64) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+func addressTopic(address string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+}
+
+func transferLog(topics []string, data string) receiptLog {
+	return receiptLog{
+		TransactionHash: "0xtx",
+		BlockHash:       "0xb1",
+		BlockNumber:     "0x10",
+		Address:         "0x6B175474E89094C44Da98b954EedeAC495271d0F",
+		Topics:          topics,
+		Data:            "0x" + data,
+		LogIndex:        "0x3",
+	}
+}
+
+func TestDecodeTokenTransfers(t *testing.T) {
+	expected := func(standard types.TokenStandard, amount, tokenID int64) types.TokenTransfer {
+		return types.TokenTransfer{
+			BlockHash:       "0xb1",
+			BlockNumber:     16,
+			TransactionHash: "0xtx",
+			LogIndex:        3,
+			Token:           tokenAddress,
+			Standard:        standard,
+			From:            fromAddress,
+			To:              toAddress,
+			Amount:          *big.NewInt(amount),
+			TokenID:         *big.NewInt(tokenID),
+		}
+	}
+
+	t.Run("should decode an erc20 transfer", func(t *testing.T) {
+		got, err := decodeTokenTransfers(transferLog(
+			[]string{transferTopic, addressTopic(fromAddress), addressTopic(toAddress)}, word(1000)))
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{expected(types.TokenStandardERC20, 1000, 0)}, got)
+	})
+
+	t.Run("should decode an erc721 transfer", func(t *testing.T) {
+		got, err := decodeTokenTransfers(transferLog(
+			[]string{transferTopic, addressTopic(fromAddress), addressTopic(toAddress), "0x" + word(42)}, ""))
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{expected(types.TokenStandardERC721, 0, 42)}, got)
+	})
+
+	t.Run("should decode an erc1155 single transfer", func(t *testing.T) {
+		got, err := decodeTokenTransfers(transferLog([]string{
+			transferSingleTopic, addressTopic(operatorAddr), addressTopic(fromAddress), addressTopic(toAddress),
+		}, word(7)+word(5)))
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{expected(types.TokenStandardERC1155, 5, 7)}, got)
+	})
+
+	t.Run("should decode an erc1155 batch transfer", func(t *testing.T) {
+		// ids [7, 8] at offset 64 and values [5, 6] at offset 160.
+		data := word(64) + word(160) + word(2) + word(7) + word(8) + word(2) + word(5) + word(6)
+
+		got, err := decodeTokenTransfers(transferLog([]string{
+			transferBatchTopic, addressTopic(operatorAddr), addressTopic(fromAddress), addressTopic(toAddress),
+		}, data))
+		require.NoError(t, err)
+
+		second := expected(types.TokenStandardERC1155, 6, 8)
+		second.BatchIndex = 1
+		require.Equal(t, []types.TokenTransfer{expected(types.TokenStandardERC1155, 5, 7), second}, got)
+	})
+
+	t.Run("should ignore the events not following the standards", func(t *testing.T) {
+		for name, l := range map[string]receiptLog{
+			"no topics": transferLog(nil, ""),
+			"other event": transferLog(
+				[]string{"0x8c5be1e5ebec7d5bd14f71427e1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"}, word(1)),
+			"transfer without indexed addresses": transferLog(
+				[]string{transferTopic}, strings.TrimPrefix(addressTopic(fromAddress), "0x")+word(1)),
+			"erc20 transfer without amount": transferLog(
+				[]string{transferTopic, addressTopic(fromAddress), addressTopic(toAddress)}, ""),
+			"topic not holding an address": transferLog(
+				[]string{transferTopic, "0x" + strings.Repeat("f", 64), addressTopic(toAddress)}, word(1)),
+			"batch transfer with out of bounds offset": transferLog([]string{
+				transferBatchTopic, addressTopic(operatorAddr), addressTopic(fromAddress), addressTopic(toAddress),
+			}, word(64)+word(1<<40)+word(0)),
+			"batch transfer with too long array": transferLog([]string{
+				transferBatchTopic, addressTopic(operatorAddr), addressTopic(fromAddress), addressTopic(toAddress),
+			}, word(64)+word(64)+word(1000)),
+			"batch transfer with mismatching arrays": transferLog([]string{
+				transferBatchTopic, addressTopic(operatorAddr), addressTopic(fromAddress), addressTopic(toAddress),
+			}, word(64)+word(128)+word(1)+word(7)+word(0)),
+		} {
+			got, err := decodeTokenTransfers(l)
+			require.NoError(t, err, name)
+			require.Empty(t, got, name)
+		}
+	})
+
+	t.Run("should error because of invalid data", func(t *testing.T) {
+		l := transferLog([]string{transferTopic, addressTopic(fromAddress), addressTopic(toAddress)}, "zz")
+
+		_, err := decodeTokenTransfers(l)
+		require.Error(t, err)
+	})
+}
+
+func TestClient_GetTokenTransfers(t *testing.T) {
+	ctx := context.TODO()
+
+	erc20 := transferLog([]string{transferTopic, addressTopic(fromAddress), addressTopic(toAddress)}, word(1000))
+
+	response := func(logs ...receiptLog) json.RawMessage {
+		resp, err := json.Marshal(logs)
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	t.Run("should get the token transfers of the block", func(t *testing.T) {
+		removed := erc20
+		removed.Removed = true
+
+		c, err := NewClient(endpoint, WithRPCClient(mock.RPCClient{Response: response(erc20, removed)}))
+		require.NoError(t, err)
+
+		got, err := c.GetTokenTransfers(ctx, "0xB1")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		require.Equal(t, types.TokenStandardERC20, got[0].Standard)
+		require.Equal(t, *big.NewInt(1000), got[0].Amount)
+	})
+
+	t.Run("should error because of logs of another block", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(mock.RPCClient{Response: response(erc20)}))
+		require.NoError(t, err)
+
+		_, err = c.GetTokenTransfers(ctx, "0xreorged")
+		require.ErrorContains(t, err, "belongs to block 0xb1 instead of block 0xreorged")
+	})
+
+	t.Run("should error because of rpc client error", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(mock.RPCClient{ShouldError: true}))
+		require.NoError(t, err)
+
+		_, err = c.GetTokenTransfers(ctx, "0xb1")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,290 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// TraceAPI is the API used to trace the internal calls of the transactions of a block.
+type TraceAPI string
+
+const (
+	// TraceAPIDebug uses debug_traceBlockByNumber with the callTracer, supported by geth and most of the nodes.
+	TraceAPIDebug TraceAPI = "debug"
+	// TraceAPITrace uses trace_block, supported by the nodes implementing the Parity-style trace module,
+	// e.g. erigon, nethermind and reth.
+	TraceAPITrace TraceAPI = "trace"
+)
+
+// ParseTraceAPI returns the trace API with the given name.
+func ParseTraceAPI(name string) (TraceAPI, error) {
+	switch api := TraceAPI(name); api {
+	case TraceAPIDebug, TraceAPITrace:
+		return api, nil
+	default:
+		return "", fmt.Errorf("unknown trace api %q", name)
+	}
+}
+
+// GetInternalTransfers returns the ETH transferred by the contracts while executing the transactions of the block,
+// by calls, contract creations and self-destructs carrying value. Top-level transactions are not included, and
+// neither are the transfers of the calls that were reverted. The transfers are ordered as executed.
+func (c Client) GetInternalTransfers(ctx context.Context, block types.Block) ([]types.Transaction, error) {
+	if c.traceAPI == TraceAPITrace {
+		return c.getParityInternalTransfers(ctx, block)
+	}
+
+	return c.getCallTracerInternalTransfers(ctx, block)
+}
+
+// Call frame of the callTracer transport layer data structure.
+type callFrame struct {
+	Type  string      `json:"type"`
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Value string      `json:"value"` // missing for the calls that cannot carry value, e.g. STATICCALL
+	Error string      `json:"error"`
+	Calls []callFrame `json:"calls"`
+}
+
+// Transaction trace of debug_traceBlockByNumber transport layer data structure.
+type transactionTrace struct {
+	TxHash string    `json:"txHash"` // missing before geth 1.11
+	Result callFrame `json:"result"`
+	Error  string    `json:"error"`
+}
+
+func (c Client) getCallTracerInternalTransfers(ctx context.Context, block types.Block) ([]types.Transaction, error) {
+	params := []interface{}{EthNumberFromUnit64(block.Number), map[string]string{"tracer": "callTracer"}}
+
+	resp, err := c.rpcClient.Call(ctx, "debug_traceBlockByNumber", params)
+	if err != nil {
+		return nil, fmt.Errorf("could not call rpc method: %w", err)
+	}
+
+	var traces []transactionTrace
+	if err := json.Unmarshal(resp, &traces); err != nil {
+		return nil, fmt.Errorf("could not unmarshal block traces: %w", err)
+	}
+
+	// The traces are matched to the transactions by position, so they must be the traces of the same block.
+	if len(traces) != len(block.Transactions) {
+		return nil, fmt.Errorf("got %d traces for the %d transactions of block %s",
+			len(traces), len(block.Transactions), block.Hash)
+	}
+
+	var transfers []types.Transaction
+
+	for i, trace := range traces {
+		tx := block.Transactions[i]
+
+		if trace.TxHash != "" && !strings.EqualFold(trace.TxHash, tx.Hash) {
+			return nil, fmt.Errorf("trace of transaction %s does not belong to block %s", trace.TxHash, block.Hash)
+		}
+
+		if trace.Error != "" {
+			return nil, fmt.Errorf("could not trace transaction %s: %s", tx.Hash, trace.Error)
+		}
+
+		txTransfers, err := flattenCallFrames(trace.Result.Calls, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode trace of transaction %s: %w", tx.Hash, err)
+		}
+
+		transfers = append(transfers, newInternalTransfers(block, tx.Hash, txTransfers)...)
+	}
+
+	return transfers, nil
+}
+
+// flattenCallFrames appends the internal transfers of the frames and of their sub calls, depth first.
+// Reverted frames are skipped with their sub calls, since nothing they did is part of the state.
+func flattenCallFrames(frames []callFrame, transfers []types.Transaction) ([]types.Transaction, error) {
+	for _, frame := range frames {
+		if frame.Error != "" {
+			continue
+		}
+
+		switch strings.ToUpper(frame.Type) {
+		case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+			var err error
+			if transfers, err = appendTransfer(transfers, frame.From, frame.To, frame.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		if transfers, err = flattenCallFrames(frame.Calls, transfers); err != nil {
+			return nil, err
+		}
+	}
+
+	return transfers, nil
+}
+
+// Trace of trace_block transport layer data structure.
+type parityTrace struct {
+	Type   string `json:"type"`
+	Action struct {
+		CallType      string `json:"callType"`
+		From          string `json:"from"`
+		To            string `json:"to"`
+		Value         string `json:"value"`
+		Address       string `json:"address"`       // self-destructed contract
+		RefundAddress string `json:"refundAddress"` // receiver of the balance of a self-destructed contract
+		Balance       string `json:"balance"`
+	} `json:"action"`
+	Result *struct {
+		Address string `json:"address"` // created contract
+	} `json:"result"`
+	Error           string `json:"error"`
+	TraceAddress    []int  `json:"traceAddress"`
+	TransactionHash string `json:"transactionHash"`
+	BlockHash       string `json:"blockHash"`
+}
+
+func (c Client) getParityInternalTransfers(ctx context.Context, block types.Block) ([]types.Transaction, error) {
+	resp, err := c.rpcClient.Call(ctx, "trace_block", []interface{}{EthNumberFromUnit64(block.Number)})
+	if err != nil {
+		return nil, fmt.Errorf("could not call rpc method: %w", err)
+	}
+
+	var traces []parityTrace
+	if err := json.Unmarshal(resp, &traces); err != nil {
+		return nil, fmt.Errorf("could not unmarshal block traces: %w", err)
+	}
+
+	transactions := make(map[string]types.Transaction, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		transactions[strings.ToLower(tx.Hash)] = tx
+	}
+
+	var (
+		transfers   []types.Transaction
+		txTransfers []types.Transaction
+		reverted    [][]int // trace addresses of the reverted calls of the current transaction
+		current     string
+	)
+
+	flush := func() {
+		if current != "" {
+			transfers = append(transfers, newInternalTransfers(block, transactions[current].Hash, txTransfers)...)
+		}
+
+		txTransfers, reverted = nil, nil
+	}
+
+	// The traces of a transaction are contiguous and ordered depth first, starting with the top-level call.
+	for _, trace := range traces {
+		if trace.TransactionHash == "" {
+			continue // block and uncle rewards
+		}
+
+		if !strings.EqualFold(trace.BlockHash, block.Hash) {
+			return nil, fmt.Errorf("trace of transaction %s belongs to block %s instead of block %s",
+				trace.TransactionHash, trace.BlockHash, block.Hash)
+		}
+
+		txHash := strings.ToLower(trace.TransactionHash)
+		if txHash != current {
+			flush()
+
+			if _, ok := transactions[txHash]; !ok {
+				return nil, fmt.Errorf("trace of transaction %s does not belong to block %s",
+					trace.TransactionHash, block.Hash)
+			}
+
+			current = txHash
+		}
+
+		if hasRevertedAncestor(trace.TraceAddress, reverted) {
+			continue
+		}
+
+		if trace.Error != "" {
+			reverted = append(reverted, trace.TraceAddress)
+			continue
+		}
+
+		if len(trace.TraceAddress) == 0 {
+			continue // the transaction itself
+		}
+
+		var err error
+
+		switch {
+		case trace.Type == "call" && trace.Action.CallType == "call":
+			txTransfers, err = appendTransfer(txTransfers, trace.Action.From, trace.Action.To, trace.Action.Value)
+		case trace.Type == "create" && trace.Result != nil:
+			txTransfers, err = appendTransfer(txTransfers, trace.Action.From, trace.Result.Address, trace.Action.Value)
+		case trace.Type == "suicide" || trace.Type == "selfdestruct":
+			txTransfers, err = appendTransfer(
+				txTransfers, trace.Action.Address, trace.Action.RefundAddress, trace.Action.Balance)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not decode trace of transaction %s: %w", trace.TransactionHash, err)
+		}
+	}
+
+	flush()
+
+	return transfers, nil
+}
+
+// hasRevertedAncestor tells if the call with the given trace address, or one of its parents, was reverted.
+func hasRevertedAncestor(traceAddress []int, reverted [][]int) bool {
+	for _, r := range reverted {
+		if len(r) <= len(traceAddress) && slices.Equal(r, traceAddress[:len(r)]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendTransfer appends the transfer of value between the addresses, unless there is no value transferred.
+func appendTransfer(transfers []types.Transaction, from, to, value string) ([]types.Transaction, error) {
+	if value == "" {
+		return transfers, nil
+	}
+
+	parsedValue, err := BigIntFromEthNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode call value: %w", err)
+	}
+
+	if parsedValue.Sign() == 0 {
+		return transfers, nil
+	}
+
+	return append(transfers, types.Transaction{From: from, To: to, Value: parsedValue}), nil
+}
+
+// newInternalTransfers completes the transfers made while executing the transaction with the given hash.
+// They share the position in the block of the transaction, so that they are ordered right after it.
+func newInternalTransfers(block types.Block, txHash string, transfers []types.Transaction) []types.Transaction {
+	var transactionIndex uint64
+	for _, tx := range block.Transactions {
+		if tx.Hash == txHash {
+			transactionIndex = tx.TransactionIndex
+			break
+		}
+	}
+
+	for i := range transfers {
+		transfers[i].Kind = types.TransactionKindInternal
+		transfers[i].BlockHash = block.Hash
+		transfers[i].BlockNumber = block.Number
+		transfers[i].Hash = txHash
+		transfers[i].TransactionIndex = transactionIndex
+		transfers[i].TraceIndex = i
+	}
+
+	return transfers
+}
@@ -0,0 +1,200 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// tracesRPCClient answers the calls with the response of their method.
+type tracesRPCClient struct {
+	responses map[string]string
+}
+
+func (r tracesRPCClient) Call(_ context.Context, method string, _ interface{}) (json.RawMessage, error) {
+	resp, ok := r.responses[method]
+	if !ok {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "method not found"}
+	}
+
+	return json.RawMessage(resp), nil
+}
+
+func (r tracesRPCClient) CallBatch(_ context.Context, _ []jsonrpc.BatchElem) error {
+	return errors.New("not implemented")
+}
+
+func TestParseTraceAPI(t *testing.T) {
+	api, err := ParseTraceAPI("trace")
+	require.NoError(t, err)
+	require.Equal(t, TraceAPITrace, api)
+
+	_, err = ParseTraceAPI("parity")
+	require.Error(t, err)
+}
+
+func TestClient_GetInternalTransfers(t *testing.T) {
+	ctx := context.TODO()
+
+	block := types.Block{
+		Number: 16,
+		Hash:   "0xb1",
+		Transactions: []types.Transaction{
+			{Hash: "0xt1", TransactionIndex: 0, From: "0xeoa", To: "0xmultisig"},
+			{Hash: "0xt2", TransactionIndex: 1, From: "0xeoa", To: "0xdex"},
+		},
+	}
+
+	internal := func(hash string, traceIndex int, from, to string, value int64) types.Transaction {
+		var transactionIndex uint64
+		if hash == "0xt2" {
+			transactionIndex = 1
+		}
+
+		return types.Transaction{
+			Kind:             types.TransactionKindInternal,
+			BlockHash:        "0xb1",
+			BlockNumber:      16,
+			Hash:             hash,
+			TransactionIndex: transactionIndex,
+			TraceIndex:       traceIndex,
+			From:             from,
+			To:               to,
+			Value:            *big.NewInt(value),
+		}
+	}
+
+	t.Run("should flatten the call frames of the callTracer", func(t *testing.T) {
+		traces := `[
+			{"txHash": "0xt1", "result": {"type": "CALL", "from": "0xeoa", "to": "0xmultisig", "value": "0x5", "calls": [
+				{"type": "DELEGATECALL", "from": "0xmultisig", "to": "0xlib", "value": "0x5", "calls": [
+					{"type": "CALL", "from": "0xmultisig", "to": "0xowner", "value": "0x3"}
+				]},
+				{"type": "STATICCALL", "from": "0xmultisig", "to": "0xoracle"},
+				{"type": "CALL", "from": "0xmultisig", "to": "0xreverted", "value": "0x1", "error": "execution reverted",
+					"calls": [{"type": "CALL", "from": "0xreverted", "to": "0xowner", "value": "0x1"}]},
+				{"type": "CALL", "from": "0xmultisig", "to": "0xnovalue", "value": "0x0"},
+				{"type": "CREATE2", "from": "0xmultisig", "to": "0xcreated", "value": "0x2"}
+			]}},
+			{"txHash": "0xt2", "result": {"type": "CALL", "from": "0xeoa", "to": "0xdex", "value": "0x0", "calls": [
+				{"type": "SELFDESTRUCT", "from": "0xdex", "to": "0xeoa", "value": "0x7"}
+			]}}
+		]`
+
+		c, err := NewClient(endpoint, WithRPCClient(tracesRPCClient{
+			responses: map[string]string{"debug_traceBlockByNumber": traces},
+		}))
+		require.NoError(t, err)
+
+		got, err := c.GetInternalTransfers(ctx, block)
+		require.NoError(t, err)
+		require.Equal(t, []types.Transaction{
+			internal("0xt1", 0, "0xmultisig", "0xowner", 3),
+			internal("0xt1", 1, "0xmultisig", "0xcreated", 2),
+			internal("0xt2", 0, "0xdex", "0xeoa", 7),
+		}, got)
+	})
+
+	t.Run("should error because of traces of another block", func(t *testing.T) {
+		for _, traces := range []string{
+			`[{"result": {"type": "CALL"}}]`,
+			`[{"txHash": "0xother", "result": {"type": "CALL"}}, {"txHash": "0xt2", "result": {"type": "CALL"}}]`,
+		} {
+			c, err := NewClient(endpoint, WithRPCClient(tracesRPCClient{
+				responses: map[string]string{"debug_traceBlockByNumber": traces},
+			}))
+			require.NoError(t, err)
+
+			_, err = c.GetInternalTransfers(ctx, block)
+			require.Error(t, err, traces)
+		}
+	})
+
+	t.Run("should flatten the traces of trace_block", func(t *testing.T) {
+		trace := func(txHash, traceType string, traceAddress []int, action, extra string) string {
+			address, _ := json.Marshal(traceAddress)
+
+			return fmt.Sprintf(`{"type": %q, "action": %s, "blockHash": "0xb1", "transactionHash": %q, `+
+				`"traceAddress": %s%s}`, traceType, action, txHash, address, extra)
+		}
+
+		call := func(from, to, value string) string {
+			return fmt.Sprintf(`{"callType": "call", "from": %q, "to": %q, "value": %q}`, from, to, value)
+		}
+
+		traces := "[" + strings.Join([]string{
+			trace("0xt1", "call", []int{}, call("0xeoa", "0xmultisig", "0x5"), ""),
+			trace("0xt1", "call", []int{0},
+				`{"callType": "delegatecall", "from": "0xmultisig", "to": "0xlib", "value": "0x5"}`, ""),
+			trace("0xt1", "call", []int{0, 0}, call("0xmultisig", "0xowner", "0x3"), ""),
+			trace("0xt1", "call", []int{1}, call("0xmultisig", "0xreverted", "0x1"), `, "error": "Reverted"`),
+			trace("0xt1", "call", []int{1, 0}, call("0xreverted", "0xowner", "0x1"), ""),
+			trace("0xt1", "create", []int{2}, `{"from": "0xmultisig", "value": "0x2"}`,
+				`, "result": {"address": "0xcreated"}`),
+			trace("0xt2", "call", []int{}, call("0xeoa", "0xdex", "0x0"), ""),
+			trace("0xt2", "suicide", []int{0}, `{"address": "0xdex", "refundAddress": "0xeoa", "balance": "0x7"}`, ""),
+			`{"type": "reward", "action": {"author": "0xminer", "value": "0x1"}, "blockHash": "0xb1", "traceAddress": []}`,
+		}, ",") + "]"
+
+		c, err := NewClient(endpoint, WithTraceAPI(TraceAPITrace), WithRPCClient(tracesRPCClient{
+			responses: map[string]string{"trace_block": traces},
+		}))
+		require.NoError(t, err)
+
+		got, err := c.GetInternalTransfers(ctx, block)
+		require.NoError(t, err)
+		require.Equal(t, []types.Transaction{
+			internal("0xt1", 0, "0xmultisig", "0xowner", 3),
+			internal("0xt1", 1, "0xmultisig", "0xcreated", 2),
+			internal("0xt2", 0, "0xdex", "0xeoa", 7),
+		}, got)
+	})
+
+	t.Run("should skip the traces of a reverted transaction", func(t *testing.T) {
+		traces := `[
+			{"type": "call", "action": {"callType": "call", "from": "0xeoa", "to": "0xmultisig", "value": "0x0"},
+				"error": "Reverted", "blockHash": "0xb1", "transactionHash": "0xt1", "traceAddress": []},
+			{"type": "call", "action": {"callType": "call", "from": "0xmultisig", "to": "0xowner", "value": "0x3"},
+				"blockHash": "0xb1", "transactionHash": "0xt1", "traceAddress": [0]}
+		]`
+
+		c, err := NewClient(endpoint, WithTraceAPI(TraceAPITrace), WithRPCClient(tracesRPCClient{
+			responses: map[string]string{"trace_block": traces},
+		}))
+		require.NoError(t, err)
+
+		got, err := c.GetInternalTransfers(ctx, block)
+		require.NoError(t, err)
+		require.Empty(t, got)
+	})
+
+	t.Run("should error because of trace_block traces of another block", func(t *testing.T) {
+		traces := `[{"type": "call", "action": {"callType": "call"}, "blockHash": "0xb2", "transactionHash": "0xt1",
+			"traceAddress": []}]`
+
+		c, err := NewClient(endpoint, WithTraceAPI(TraceAPITrace), WithRPCClient(tracesRPCClient{
+			responses: map[string]string{"trace_block": traces},
+		}))
+		require.NoError(t, err)
+
+		_, err = c.GetInternalTransfers(ctx, block)
+		require.ErrorContains(t, err, "belongs to block 0xb2 instead of block 0xb1")
+	})
+
+	t.Run("should error when the node does not support the trace api", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRPCClient(tracesRPCClient{}))
+		require.NoError(t, err)
+
+		_, err = c.GetInternalTransfers(ctx, block)
+		require.Error(t, err)
+	})
+}
@@ -2,6 +2,8 @@ package ethereum
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"time"
 
 	"github.com/ilkamo/ethparser-go/types"
@@ -9,11 +11,24 @@ import (
 
 // Block transport layer data structure.
 type block struct {
-	Number       string        `json:"number"`
-	Hash         string        `json:"hash"`
-	ParentHash   string        `json:"parentHash"`
-	Timestamp    string        `json:"timestamp"`
-	Transactions []transaction `json:"transactions"`
+	Number                string        `json:"number"`
+	Hash                  string        `json:"hash"`
+	ParentHash            string        `json:"parentHash"`
+	Timestamp             string        `json:"timestamp"`
+	Miner                 string        `json:"miner"`
+	GasLimit              string        `json:"gasLimit"`
+	GasUsed               string        `json:"gasUsed"`
+	BaseFeePerGas         string        `json:"baseFeePerGas"` // missing before the London fork
+	LogsBloom             string        `json:"logsBloom"`
+	StateRoot             string        `json:"stateRoot"`
+	ReceiptsRoot          string        `json:"receiptsRoot"`
+	TransactionsRoot      string        `json:"transactionsRoot"`
+	Transactions          []transaction `json:"transactions"`
+	Withdrawals           []withdrawal  `json:"withdrawals"`     // missing before the Shanghai fork
+	WithdrawalsRoot       string        `json:"withdrawalsRoot"` // missing before the Shanghai fork
+	BlobGasUsed           string        `json:"blobGasUsed"`     // missing before the Cancun fork
+	ExcessBlobGas         string        `json:"excessBlobGas"`   // missing before the Cancun fork
+	ParentBeaconBlockRoot string        `json:"parentBeaconBlockRoot"`
 }
 
 func (b block) ToBlock() (types.Block, error) {
@@ -31,8 +46,35 @@ func (b block) ToBlock() (types.Block, error) {
 	}
 
 	parsedTimestamp := time.Unix(int64(elapsedSeconds), 0)
+
+	gasLimit, err := optionalUint64FromEthNumber(b.GasLimit)
+	if err != nil {
+		return types.Block{}, fmt.Errorf("could not decode block gas limit: %w", err)
+	}
+
+	gasUsed, err := optionalUint64FromEthNumber(b.GasUsed)
+	if err != nil {
+		return types.Block{}, fmt.Errorf("could not decode block gas used: %w", err)
+	}
+
+	var baseFeePerGas *big.Int
+	if b.BaseFeePerGas != "" {
+		baseFee, err := BigIntFromEthNumber(b.BaseFeePerGas)
+		if err != nil {
+			return types.Block{}, fmt.Errorf("could not decode block base fee per gas: %w", err)
+		}
+
+		baseFeePerGas = &baseFee
+	}
+
+	blobGasUsed, err := optionalUint64PointerFromEthNumber(b.BlobGasUsed)
 	if err != nil {
-		return types.Block{}, fmt.Errorf("could not decode block unix time: %w", err)
+		return types.Block{}, fmt.Errorf("could not decode block blob gas used: %w", err)
+	}
+
+	excessBlobGas, err := optionalUint64PointerFromEthNumber(b.ExcessBlobGas)
+	if err != nil {
+		return types.Block{}, fmt.Errorf("could not decode block excess blob gas: %w", err)
 	}
 
 	transactions := make([]types.Transaction, len(b.Transactions))
@@ -44,38 +86,99 @@ func (b block) ToBlock() (types.Block, error) {
 		transactions[i] = tx
 	}
 
+	var withdrawals []types.Withdrawal
+	if b.Withdrawals != nil {
+		withdrawals = make([]types.Withdrawal, len(b.Withdrawals))
+		for i, w := range b.Withdrawals {
+			if withdrawals[i], err = w.ToWithdrawal(); err != nil {
+				return types.Block{}, err
+			}
+		}
+	}
+
 	return types.Block{
-		Number:       parsedNumber,
-		Hash:         b.Hash,
-		ParentHash:   b.ParentHash,
-		Timestamp:    parsedTimestamp,
-		Transactions: transactions,
+		Number:                parsedNumber,
+		Hash:                  b.Hash,
+		ParentHash:            b.ParentHash,
+		Timestamp:             parsedTimestamp,
+		Miner:                 b.Miner,
+		GasLimit:              gasLimit,
+		GasUsed:               gasUsed,
+		BaseFeePerGas:         baseFeePerGas,
+		LogsBloom:             b.LogsBloom,
+		StateRoot:             b.StateRoot,
+		ReceiptsRoot:          b.ReceiptsRoot,
+		TransactionsRoot:      b.TransactionsRoot,
+		Transactions:          transactions,
+		Withdrawals:           withdrawals,
+		WithdrawalsRoot:       b.WithdrawalsRoot,
+		BlobGasUsed:           blobGasUsed,
+		ExcessBlobGas:         excessBlobGas,
+		ParentBeaconBlockRoot: b.ParentBeaconBlockRoot,
+	}, nil
+}
+
+// Withdrawal transport layer data structure.
+type withdrawal struct {
+	Index          string `json:"index"`
+	ValidatorIndex string `json:"validatorIndex"`
+	Address        string `json:"address"`
+	Amount         string `json:"amount"`
+}
+
+func (w withdrawal) ToWithdrawal() (types.Withdrawal, error) {
+	index, err := Uint64FromEthNumber(w.Index)
+	if err != nil {
+		return types.Withdrawal{}, fmt.Errorf("could not decode withdrawal index: %w", err)
+	}
+
+	validatorIndex, err := Uint64FromEthNumber(w.ValidatorIndex)
+	if err != nil {
+		return types.Withdrawal{}, fmt.Errorf("could not decode withdrawal validator index: %w", err)
+	}
+
+	amount, err := Uint64FromEthNumber(w.Amount)
+	if err != nil {
+		return types.Withdrawal{}, fmt.Errorf("could not decode withdrawal amount: %w", err)
+	}
+
+	return types.Withdrawal{
+		Index:          index,
+		ValidatorIndex: validatorIndex,
+		Address:        w.Address,
+		Amount:         amount,
 	}, nil
 }
 
 // Transaction transport layer data structure.
 type transaction struct {
-	BlockHash            string `json:"blockHash"`
-	BlockNumber          string `json:"blockNumber"`
-	From                 string `json:"from"`
-	Gas                  string `json:"gas"`
-	GasPrice             string `json:"gasPrice"`
-	MaxFeePerGas         string `json:"maxFeePerGas"`
-	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
-	Hash                 string `json:"hash"`
-	Input                string `json:"input"`
-	Nonce                string `json:"nonce"`
-	To                   string `json:"to"`
-	TransactionIndex     string `json:"transactionIndex"`
-	Value                string `json:"value"`
-	Type                 string `json:"type"`
-	ChainId              string `json:"chainId"`
-	V                    string `json:"v"`
-	R                    string `json:"r"`
-	S                    string `json:"s"`
-	YParity              string `json:"yParity"`
+	BlockHash            string          `json:"blockHash"`
+	BlockNumber          string          `json:"blockNumber"`
+	From                 string          `json:"from"`
+	Gas                  string          `json:"gas"`
+	GasPrice             string          `json:"gasPrice"`
+	MaxFeePerGas         string          `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string          `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     string          `json:"maxFeePerBlobGas"`
+	Hash                 string          `json:"hash"`
+	Input                string          `json:"input"`
+	Nonce                string          `json:"nonce"`
+	To                   string          `json:"to"`
+	TransactionIndex     string          `json:"transactionIndex"`
+	Value                string          `json:"value"`
+	Type                 string          `json:"type"` // missing before the Berlin fork
+	AccessList           []accessTuple   `json:"accessList"`
+	BlobVersionedHashes  []string        `json:"blobVersionedHashes"`
+	AuthorizationList    []authorization `json:"authorizationList"`
+	ChainId              string          `json:"chainId"`
+	V                    string          `json:"v"`
+	R                    string          `json:"r"`
+	S                    string          `json:"s"`
+	YParity              string          `json:"yParity"`
 }
 
+// ToTransaction converts the transaction. The fields that depend on the type of the transaction, or on the node,
+// are only decoded when they are present.
 func (t transaction) ToTransaction() (types.Transaction, error) {
 	parsedNumber, err := Uint64FromEthNumber(t.BlockNumber)
 	if err != nil {
@@ -87,12 +190,269 @@ func (t transaction) ToTransaction() (types.Transaction, error) {
 		return types.Transaction{}, fmt.Errorf("could not decode tx value: %w", err)
 	}
 
-	return types.Transaction{
-		BlockHash:   t.BlockHash,
-		BlockNumber: parsedNumber,
-		Hash:        t.Hash,
-		From:        t.From,
-		To:          t.To,
-		Value:       parsedValue,
+	txType, err := optionalUint64FromEthNumber(t.Type)
+	if err != nil || txType > math.MaxUint8 {
+		return types.Transaction{}, fmt.Errorf("could not decode tx type %q", t.Type)
+	}
+
+	tx := types.Transaction{
+		Kind:                types.TransactionKindExternal,
+		Type:                types.TransactionType(txType),
+		BlockHash:           t.BlockHash,
+		BlockNumber:         parsedNumber,
+		Hash:                t.Hash,
+		From:                t.From,
+		To:                  t.To,
+		Value:               parsedValue,
+		Input:               t.Input,
+		BlobVersionedHashes: t.BlobVersionedHashes,
+	}
+
+	uints := []struct {
+		name  string
+		value string
+		dst   *uint64
+	}{
+		{"index", t.TransactionIndex, &tx.TransactionIndex},
+		{"nonce", t.Nonce, &tx.Nonce},
+		{"gas", t.Gas, &tx.Gas},
+	}
+
+	for _, u := range uints {
+		if *u.dst, err = optionalUint64FromEthNumber(u.value); err != nil {
+			return types.Transaction{}, fmt.Errorf("could not decode tx %s: %w", u.name, err)
+		}
+	}
+
+	bigInts := []struct {
+		name  string
+		value string
+		dst   *big.Int
+	}{
+		{"gas price", t.GasPrice, &tx.GasPrice},
+		{"v", t.V, &tx.V},
+		{"r", t.R, &tx.R},
+		{"s", t.S, &tx.S},
+	}
+
+	for _, b := range bigInts {
+		if *b.dst, err = optionalBigIntFromEthNumber(b.value); err != nil {
+			return types.Transaction{}, fmt.Errorf("could not decode tx %s: %w", b.name, err)
+		}
+	}
+
+	optionalBigInts := []struct {
+		name  string
+		value string
+		dst   **big.Int
+	}{
+		{"max fee per gas", t.MaxFeePerGas, &tx.MaxFeePerGas},
+		{"max priority fee per gas", t.MaxPriorityFeePerGas, &tx.MaxPriorityFeePerGas},
+		{"max fee per blob gas", t.MaxFeePerBlobGas, &tx.MaxFeePerBlobGas},
+		{"chain id", t.ChainId, &tx.ChainID},
+	}
+
+	for _, b := range optionalBigInts {
+		if b.value == "" {
+			continue
+		}
+
+		n, err := BigIntFromEthNumber(b.value)
+		if err != nil {
+			return types.Transaction{}, fmt.Errorf("could not decode tx %s: %w", b.name, err)
+		}
+
+		*b.dst = &n
+	}
+
+	if t.AccessList != nil {
+		tx.AccessList = make([]types.AccessTuple, len(t.AccessList))
+		for i, a := range t.AccessList {
+			tx.AccessList[i] = a.ToAccessTuple()
+		}
+	}
+
+	if t.AuthorizationList != nil {
+		tx.AuthorizationList = make([]types.Authorization, len(t.AuthorizationList))
+		for i, a := range t.AuthorizationList {
+			if tx.AuthorizationList[i], err = a.ToAuthorization(); err != nil {
+				return types.Transaction{}, err
+			}
+		}
+	}
+
+	return tx, nil
+}
+
+// Access list entry transport layer data structure.
+type accessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storageKeys"`
+}
+
+func (a accessTuple) ToAccessTuple() types.AccessTuple {
+	return types.AccessTuple{
+		Address:     a.Address,
+		StorageKeys: a.StorageKeys,
+	}
+}
+
+// Authorization transport layer data structure.
+type authorization struct {
+	ChainID string `json:"chainId"`
+	Address string `json:"address"`
+	Nonce   string `json:"nonce"`
+	YParity string `json:"yParity"`
+	R       string `json:"r"`
+	S       string `json:"s"`
+}
+
+func (a authorization) ToAuthorization() (types.Authorization, error) {
+	chainID, err := BigIntFromEthNumber(a.ChainID)
+	if err != nil {
+		return types.Authorization{}, fmt.Errorf("could not decode authorization chain id: %w", err)
+	}
+
+	nonce, err := Uint64FromEthNumber(a.Nonce)
+	if err != nil {
+		return types.Authorization{}, fmt.Errorf("could not decode authorization nonce: %w", err)
+	}
+
+	yParity, err := Uint64FromEthNumber(a.YParity)
+	if err != nil || yParity > 1 {
+		return types.Authorization{}, fmt.Errorf("could not decode authorization y parity %q", a.YParity)
+	}
+
+	r, err := BigIntFromEthNumber(a.R)
+	if err != nil {
+		return types.Authorization{}, fmt.Errorf("could not decode authorization r: %w", err)
+	}
+
+	s, err := BigIntFromEthNumber(a.S)
+	if err != nil {
+		return types.Authorization{}, fmt.Errorf("could not decode authorization s: %w", err)
+	}
+
+	return types.Authorization{
+		ChainID: chainID,
+		Address: a.Address,
+		Nonce:   nonce,
+		YParity: uint8(yParity),
+		R:       r,
+		S:       s,
+	}, nil
+}
+
+// optionalUint64FromEthNumber decodes a quantity that the node may omit, as zero when it is missing.
+func optionalUint64FromEthNumber(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return Uint64FromEthNumber(s)
+}
+
+// optionalUint64PointerFromEthNumber decodes a quantity that the node may omit, as nil when it is missing.
+func optionalUint64PointerFromEthNumber(s string) (*uint64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	n, err := Uint64FromEthNumber(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// optionalBigIntFromEthNumber decodes a quantity that the node may omit, as zero when it is missing.
+func optionalBigIntFromEthNumber(s string) (big.Int, error) {
+	if s == "" {
+		return big.Int{}, nil
+	}
+
+	return BigIntFromEthNumber(s)
+}
+
+// Receipt transport layer data structure.
+type receipt struct {
+	TransactionHash   string       `json:"transactionHash"`
+	BlockHash         string       `json:"blockHash"`
+	Status            string       `json:"status"` // missing before the Byzantium fork
+	GasUsed           string       `json:"gasUsed"`
+	EffectiveGasPrice string       `json:"effectiveGasPrice"` // missing in the receipts of some nodes before London
+	ContractAddress   string       `json:"contractAddress"`
+	Logs              []receiptLog `json:"logs"`
+}
+
+func (r receipt) ToReceipt() (types.Receipt, error) {
+	var status types.ReceiptStatus
+
+	switch r.Status {
+	case "":
+	case "0x1":
+		status = types.ReceiptStatusSuccessful
+	case "0x0":
+		status = types.ReceiptStatusFailed
+	default:
+		return types.Receipt{}, fmt.Errorf("could not decode receipt status %q", r.Status)
+	}
+
+	gasUsed, err := Uint64FromEthNumber(r.GasUsed)
+	if err != nil {
+		return types.Receipt{}, fmt.Errorf("could not decode receipt gas used: %w", err)
+	}
+
+	var effectiveGasPrice big.Int
+	if r.EffectiveGasPrice != "" {
+		effectiveGasPrice, err = BigIntFromEthNumber(r.EffectiveGasPrice)
+		if err != nil {
+			return types.Receipt{}, fmt.Errorf("could not decode receipt effective gas price: %w", err)
+		}
+	}
+
+	logs := make([]types.Log, len(r.Logs))
+	for i, l := range r.Logs {
+		logs[i], err = l.ToLog()
+		if err != nil {
+			return types.Receipt{}, err
+		}
+	}
+
+	return types.Receipt{
+		TransactionHash:   r.TransactionHash,
+		Status:            status,
+		GasUsed:           gasUsed,
+		EffectiveGasPrice: effectiveGasPrice,
+		ContractAddress:   r.ContractAddress,
+		Logs:              logs,
+	}, nil
+}
+
+// Log transport layer data structure.
+type receiptLog struct {
+	TransactionHash string   `json:"transactionHash"`
+	BlockHash       string   `json:"blockHash"`
+	BlockNumber     string   `json:"blockNumber"`
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	LogIndex        string   `json:"logIndex"`
+	Removed         bool     `json:"removed"`
+}
+
+func (l receiptLog) ToLog() (types.Log, error) {
+	index, err := Uint64FromEthNumber(l.LogIndex)
+	if err != nil {
+		return types.Log{}, fmt.Errorf("could not decode log index: %w", err)
+	}
+
+	return types.Log{
+		TransactionHash: l.TransactionHash,
+		Address:         l.Address,
+		Topics:          l.Topics,
+		Data:            l.Data,
+		Index:           index,
 	}, nil
 }
@@ -3,6 +3,7 @@ package ethereum
 import (
 	"encoding/json"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,30 +14,72 @@ import (
 )
 
 var expectedBlock = types.Block{
-	Number:     19697111,
-	Hash:       "0xc8c7f99d64c6678ac5910f569167356808550b4e8fe22e8787963a62aff66d88",
-	ParentHash: "0x91c90676cab257a59cd956d7cb0bceb9b1a71d79755c23c7277a0697ccfaf8c4",
-	Timestamp:  time.Unix(1439799153, 0),
+	Number:           19697111,
+	Hash:             "0xc8c7f99d64c6678ac5910f569167356808550b4e8fe22e8787963a62aff66d88",
+	ParentHash:       "0x91c90676cab257a59cd956d7cb0bceb9b1a71d79755c23c7277a0697ccfaf8c4",
+	Timestamp:        time.Unix(1439799153, 0),
+	Miner:            "0xe6a7a1d47ff21b6321162aea7c6cb457d5476bca",
+	GasLimit:         3141592,
+	GasUsed:          0,
+	LogsBloom:        "0x" + strings.Repeat("0", 512),
+	StateRoot:        "0xadef1b7dc55d614e65ccbe24a0ddbf0ddda66ae9735c73279b27157f58d69dd2",
+	ReceiptsRoot:     "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+	TransactionsRoot: "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
 	Transactions: []types.Transaction{
 		{
+			Kind:        types.TransactionKindExternal,
 			BlockHash:   "0xed6fe3d8722be4b4614bc4fd2cc452d1d03ccdf453bc664b756a626d32ee91af",
 			BlockNumber: 19697111,
 			Hash:        "0xfa5109806d00fdfe9d0b73f9e9c2c59efd61a197900dcb03faff88c5fe263207",
 			From:        "0x2e220f48eab381507f627a3e96f5387885619e83",
 			To:          "0xb584d4be1a5470ca1a8778e9b86c81e165204599",
 			Value:       *big.NewInt(1300000000000),
+
+			Type:             types.TransactionTypeLegacy,
+			TransactionIndex: 67,
+			Nonce:            0,
+			Input: "0xe56461ad0000000000000000000000000000000000000000000000000000000000000064" +
+				"0000000000000000000000002e220f48eab381507f627a3e96f5387885619e83",
+			Gas:      43563,
+			GasPrice: *big.NewInt(10000000000),
+			ChainID:  big.NewInt(1),
+			V:        *big.NewInt(38),
+			R:        mustBigInt("0x1e31e2918fe474d421503974cc6f2cf7083b9c43de5400ea984d20a8b64168cc"),
+			S:        mustBigInt("0x6173be973c0854c292c41560d606eacdd87ccb030db78404b83944e29c7a429"),
 		},
 		{
+			Kind:        types.TransactionKindExternal,
 			BlockHash:   "0xed6fe3d8722be4b4614bc4fd2cc452d1d03ccdf453bc664b756a626d32ee91af",
 			BlockNumber: 19697111,
 			Hash:        "0xe7d8be4e841d3ccda0f790ec0c57e483b1795c2a2f4f3b0a6b37dfa1f1ee8fd2",
 			From:        "0x264bd8291fae1d75db2c5f573b07faa6715997b5",
 			To:          "0xa6e127536a7b9aca15c928f6332fc9d2cd2e93c8",
 			Value:       *big.NewInt(636084590000000000),
+
+			Type:             types.TransactionTypeAccessList,
+			TransactionIndex: 68,
+			Nonce:            813385,
+			Input:            "0x",
+			Gas:              500000,
+			GasPrice:         *big.NewInt(10000000000),
+			AccessList:       []types.AccessTuple{},
+			ChainID:          big.NewInt(1),
+			V:                mustBigInt("0x0"),
+			R:                mustBigInt("0x35723a9c703cdc70ea42a3e624f9a540f9b8ef167d7a03ada6b087117f19e03c"),
+			S:                mustBigInt("0x5cedd447dad20169a3f7ecbfff82bf9196f144f3c0f8660bbaeea0d5f1620fa5"),
 		},
 	},
 }
 
+func mustBigInt(s string) big.Int {
+	n, err := BigIntFromEthNumber(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
 func Test_block_ToBlock(t *testing.T) {
 	t.Run("should convert block to types.Block", func(t *testing.T) {
 		b := block{}
@@ -51,6 +94,40 @@ func Test_block_ToBlock(t *testing.T) {
 		require.Equal(t, expectedBlock, gotBlock)
 	})
 
+	t.Run("should convert the header fields of the forks", func(t *testing.T) {
+		b := block{}
+
+		err := json.Unmarshal([]byte(`{
+			"number": "0x1", "timestamp": "0x1", "baseFeePerGas": "0x7", "blobGasUsed": "0x20000",
+			"excessBlobGas": "0x0", "parentBeaconBlockRoot": "0xbeac", "withdrawalsRoot": "0xw1", "transactions": [],
+			"withdrawals": [{"index": "0x2", "validatorIndex": "0x3", "address": "0xa1", "amount": "0x4"}]
+		}`), &b)
+		require.NoError(t, err)
+
+		got, err := b.ToBlock()
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(7), got.BaseFeePerGas)
+		require.Equal(t, uint64(0x20000), *got.BlobGasUsed)
+		require.Equal(t, uint64(0), *got.ExcessBlobGas)
+		require.Equal(t, "0xbeac", got.ParentBeaconBlockRoot)
+		require.Equal(t, "0xw1", got.WithdrawalsRoot)
+		require.Equal(t, []types.Withdrawal{{Index: 2, ValidatorIndex: 3, Address: "0xa1", Amount: 4}}, got.Withdrawals)
+	})
+
+	t.Run("should error because of bad header fields", func(t *testing.T) {
+		for expected, b := range map[string]block{
+			"could not decode block gas limit":        {GasLimit: "1"},
+			"could not decode block base fee per gas": {BaseFeePerGas: "0x"},
+			"could not decode block excess blob gas":  {ExcessBlobGas: "0xz"},
+			"could not decode withdrawal amount":      {Withdrawals: []withdrawal{{Index: "0x0", ValidatorIndex: "0x1"}}},
+		} {
+			b.Number, b.Timestamp = "0x1", "0x1"
+
+			_, err := b.ToBlock()
+			require.ErrorContains(t, err, expected)
+		}
+	})
+
 	t.Run("should error because of bad block number", func(t *testing.T) {
 		b := block{Number: "0x"}
 		_, err := b.ToBlock()
@@ -95,3 +172,104 @@ func Test_block_ToBlock(t *testing.T) {
 		require.ErrorContains(t, err, "could not decode tx value")
 	})
 }
+
+func Test_transaction_ToTransaction(t *testing.T) {
+	t.Run("should convert a blob transaction", func(t *testing.T) {
+		tx := transaction{}
+
+		err := json.Unmarshal([]byte(`{
+			"blockNumber": "0x1", "value": "0x0", "type": "0x3", "gasPrice": "0x7", "maxFeePerGas": "0x9",
+			"maxPriorityFeePerGas": "0x2", "maxFeePerBlobGas": "0x3", "chainId": "0x1",
+			"accessList": [{"address": "0xa1", "storageKeys": ["0x01"]}],
+			"blobVersionedHashes": ["0x01b1"], "v": "0x1", "r": "0x5", "s": "0x6", "yParity": "0x1"
+		}`), &tx)
+		require.NoError(t, err)
+
+		got, err := tx.ToTransaction()
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionTypeBlob, got.Type)
+		require.Equal(t, *big.NewInt(7), got.GasPrice)
+		require.Equal(t, big.NewInt(9), got.MaxFeePerGas)
+		require.Equal(t, big.NewInt(2), got.MaxPriorityFeePerGas)
+		require.Equal(t, big.NewInt(3), got.MaxFeePerBlobGas)
+		require.Equal(t, []string{"0x01b1"}, got.BlobVersionedHashes)
+		require.Equal(t, []types.AccessTuple{{Address: "0xa1", StorageKeys: []string{"0x01"}}}, got.AccessList)
+		require.Nil(t, got.AuthorizationList)
+	})
+
+	t.Run("should convert a set code transaction", func(t *testing.T) {
+		tx := transaction{
+			BlockNumber: "0x1",
+			Value:       "0x0",
+			Type:        "0x4",
+			AuthorizationList: []authorization{
+				{ChainID: "0x0", Address: "0xd1", Nonce: "0x2", YParity: "0x1", R: "0x3", S: "0x4"},
+			},
+		}
+
+		got, err := tx.ToTransaction()
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionTypeSetCode, got.Type)
+		require.Equal(t, []types.Authorization{
+			{ChainID: mustBigInt("0x0"), Address: "0xd1", Nonce: 2, YParity: 1, R: *big.NewInt(3), S: *big.NewInt(4)},
+		}, got.AuthorizationList)
+	})
+
+	t.Run("should leave the missing fields empty", func(t *testing.T) {
+		got, err := transaction{BlockNumber: "0x1", Value: "0x0"}.ToTransaction()
+		require.NoError(t, err)
+		require.Equal(t, types.TransactionTypeLegacy, got.Type)
+		require.Nil(t, got.ChainID)
+		require.Nil(t, got.MaxFeePerGas)
+		require.Nil(t, got.AccessList)
+	})
+
+	t.Run("should error because of bad fields", func(t *testing.T) {
+		for expected, tx := range map[string]transaction{
+			"could not decode tx type":            {Type: "0x100"},
+			"could not decode tx nonce":           {Nonce: "1"},
+			"could not decode tx gas price":       {GasPrice: "0x"},
+			"could not decode tx max fee per gas": {MaxFeePerGas: "9"},
+			"could not decode tx chain id":        {ChainId: "0xz"},
+			"could not decode authorization y parity": {
+				AuthorizationList: []authorization{{ChainID: "0x1", Nonce: "0x0", YParity: "0x2"}},
+			},
+			"could not decode authorization nonce": {AuthorizationList: []authorization{{ChainID: "0x1"}}},
+		} {
+			tx.BlockNumber, tx.Value = "0x1", "0x0"
+
+			_, err := tx.ToTransaction()
+			require.ErrorContains(t, err, expected)
+		}
+	})
+}
+
+func Test_receipt_ToReceipt(t *testing.T) {
+	t.Run("should convert receipts without status", func(t *testing.T) {
+		r := receipt{TransactionHash: "0x1", GasUsed: "0x5208"}
+
+		got, err := r.ToReceipt()
+		require.NoError(t, err)
+		require.Equal(t, types.Receipt{TransactionHash: "0x1", GasUsed: 21000, Logs: []types.Log{}}, got)
+	})
+
+	t.Run("should error because of bad status", func(t *testing.T) {
+		_, err := receipt{Status: "0x2", GasUsed: "0x1"}.ToReceipt()
+		require.ErrorContains(t, err, "could not decode receipt status")
+	})
+
+	t.Run("should error because of bad gas used", func(t *testing.T) {
+		_, err := receipt{Status: "0x1", GasUsed: "0x"}.ToReceipt()
+		require.ErrorContains(t, err, "could not decode receipt gas used")
+	})
+
+	t.Run("should error because of bad effective gas price", func(t *testing.T) {
+		_, err := receipt{Status: "0x1", GasUsed: "0x1", EffectiveGasPrice: "1"}.ToReceipt()
+		require.ErrorContains(t, err, "could not decode receipt effective gas price")
+	})
+
+	t.Run("should error because of bad log index", func(t *testing.T) {
+		_, err := receipt{Status: "0x1", GasUsed: "0x1", Logs: []receiptLog{{LogIndex: ""}}}.ToReceipt()
+		require.ErrorContains(t, err, "could not decode log index")
+	})
+}
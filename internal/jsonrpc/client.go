@@ -1,9 +1,12 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -13,13 +16,18 @@ import (
 )
 
 const (
-	defaultTimeout = time.Second * 30
+	defaultTimeout      = time.Second * 30
+	defaultMaxBatchSize = 100
 )
 
+var errMissingResponse = errors.New("missing response in batch")
+
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// HTTPRequestBuilder builds the HTTP requests sent by the client: Build builds the request of a single call
+// and BuildBatch the one of a batch of calls, so that custom headers apply to both.
 type HTTPRequestBuilder interface {
 	Build(
 		ctx context.Context,
@@ -27,13 +35,19 @@ type HTTPRequestBuilder interface {
 		rpcMethod string,
 		params interface{},
 	) (*http.Request, error)
+	BuildBatch(ctx context.Context, endpoint string, requests []Request) (*http.Request, error)
 }
 
 type Client struct {
 	endpoint           string
+	redactedEndpoint   string
+	authenticators     []authenticator
 	httpClient         HTTPClient
 	httpRequestBuilder HTTPRequestBuilder
 	log                types.Logger
+	maxBatchSize       int
+	retryPolicy        RetryPolicy
+	limiter            *limiter
 	// TODO: it would be nice to have some tracing collector here for better observability in production.
 }
 
@@ -45,7 +59,16 @@ func NewClient(
 		return Client{}, fmt.Errorf("rpc endpoint is required")
 	}
 
-	c := &Client{endpoint: rpcEndpoint}
+	c := &Client{maxBatchSize: defaultMaxBatchSize, limiter: &limiter{}}
+
+	var urlAuth authenticator
+
+	c.endpoint, urlAuth = extractBasicAuth(rpcEndpoint)
+	c.redactedEndpoint = RedactURL(c.endpoint)
+
+	if urlAuth != nil {
+		c.authenticators = append(c.authenticators, urlAuth)
+	}
 
 	for _, opt := range opts {
 		opt(c)
@@ -63,6 +86,11 @@ func NewClient(
 		c.log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	}
 
+	if c.retryPolicy == nil {
+		// retry the retryable errors when no policy is provided
+		c.retryPolicy = DefaultRetryPolicy()
+	}
+
 	if c.httpRequestBuilder == nil {
 		// use default request builder when not provided
 		c.httpRequestBuilder = simpleRequestBuilder{}
@@ -72,19 +100,47 @@ func NewClient(
 }
 
 // Call sends an RPC request to the server and returns the result.
+// Failed calls are retried according to the retry policy (see WithRetryPolicy).
 func (c Client) Call(
 	ctx context.Context,
 	method string,
 	params interface{},
 ) (json.RawMessage, error) {
+	var rpcResult json.RawMessage
+
+	err := withRetries(ctx, c.retryPolicy, c.log, method, func() error {
+		var err error
+		rpcResult, err = c.call(ctx, method, params)
+
+		return err
+	})
+
+	return rpcResult, err
+}
+
+func (c Client) call(
+	ctx context.Context,
+	method string,
+	params interface{},
+) (json.RawMessage, error) {
+	release, err := c.limiter.acquire(ctx, 1)
+	if err != nil {
+		return nil, fmt.Errorf("could not wait for rate limit: %w", err)
+	}
+	defer release()
+
 	req, err := c.httpRequestBuilder.Build(ctx, c.endpoint, method, params)
 	if err != nil {
-		return nil, fmt.Errorf("could not create request: %w", err)
+		return nil, fmt.Errorf("could not create request: %w", redactURLError(err, c.redactedEndpoint))
+	}
+
+	if err := c.authenticate(req); err != nil {
+		return nil, err
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("could not send request: %w", err)
+		return nil, fmt.Errorf("could not send request: %w", redactURLError(err, c.redactedEndpoint))
 	}
 
 	defer func() {
@@ -101,18 +157,206 @@ func (c Client) Call(
 	return rpcResult, nil
 }
 
+// CallBatch sends the requests of the batch to the server as JSON-RPC 2.0 batches, split according to
+// the max batch size (see WithMaxBatchSize), and sets the result or the error of every element.
+// Responses are matched to the requests by their unique IDs, so the server can return them in any order.
+// When a batch cannot be sent or its response cannot be decoded, it is retried according to the retry policy.
+// If it still fails, the error is set to all of its elements and returned as well.
+func (c Client) CallBatch(ctx context.Context, batch []BatchElem) error {
+	var errs []error
+
+	for start := 0; start < len(batch); start += c.maxBatchSize {
+		end := start + c.maxBatchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+
+		err := withRetries(ctx, c.retryPolicy, c.log, "batch", func() error {
+			return c.callBatch(ctx, batch[start:end])
+		})
+		if err != nil {
+			for i := start; i < end; i++ {
+				batch[i].Error = err
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// callBatch sends the elements in a single HTTP request.
+func (c Client) callBatch(ctx context.Context, batch []BatchElem) error {
+	requests := make([]Request, len(batch))
+	indexes := make(map[int64]int, len(batch))
+
+	for i, elem := range batch {
+		if elem.Method == "" {
+			return fmt.Errorf("method is required")
+		}
+
+		id := nextRequestID()
+
+		requests[i] = Request{
+			JsonRPC: "2.0",
+			Method:  elem.Method,
+			Params:  elem.Params,
+			ID:      id,
+		}
+		indexes[id] = i
+	}
+
+	release, err := c.limiter.acquire(ctx, len(batch))
+	if err != nil {
+		return fmt.Errorf("could not wait for rate limit: %w", err)
+	}
+	defer release()
+
+	req, err := c.httpRequestBuilder.BuildBatch(ctx, c.endpoint, requests)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", redactURLError(err, c.redactedEndpoint))
+	}
+
+	if err := c.authenticate(req); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", redactURLError(err, c.redactedEndpoint))
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log.Error("could not close response body", "error", err)
+		}
+	}()
+
+	responses, err := c.decodeBatchResponse(resp)
+	if err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+
+	for _, rpcResponse := range responses {
+		i, ok := indexes[rpcResponse.ID]
+		if !ok {
+			c.log.Error("unexpected rpc response id", "id", rpcResponse.ID)
+			continue
+		}
+
+		delete(indexes, rpcResponse.ID)
+
+		if rpcResponse.Error != nil {
+			rpcResponse.Error.HTTPStatus = resp.StatusCode
+			batch[i].Error = rpcResponse.Error
+			continue
+		}
+
+		batch[i].Result = rpcResponse.Result
+		batch[i].Error = nil
+	}
+
+	for _, i := range indexes {
+		batch[i].Error = errMissingResponse
+	}
+
+	return nil
+}
+
+// authenticate adds the credentials of the authentication options to the request.
+func (c Client) authenticate(req *http.Request) error {
+	for _, auth := range c.authenticators {
+		if err := auth(req); err != nil {
+			return fmt.Errorf("could not authenticate request: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// LimiterStats returns the time spent by the HTTP requests waiting for the rate limit and the max in flight
+// requests since the creation of the client.
+func (c Client) LimiterStats() LimiterStats {
+	return c.limiter.stats()
+}
+
+// decodeResponse decodes the response of a single request. A non 2xx response is an *HTTPError,
+// unless it carries a JSON-RPC error.
 func (c Client) decodeResponse(
 	resp *http.Response,
 ) (json.RawMessage, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+
 	var rpcResponse Response
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
+	if err := json.Unmarshal(body, &rpcResponse); err != nil {
+		if !isSuccessStatus(resp.StatusCode) {
+			return nil, newHTTPError(resp)
+		}
+
 		return nil, fmt.Errorf("could not decode response: %w", err)
 	}
 
 	if rpcResponse.Error != nil {
-		c.log.Error("rpc error", "error", rpcResponse.Error)
-		return nil, fmt.Errorf("rpc error: %s", rpcResponse.Error.Message)
+		rpcResponse.Error.HTTPStatus = resp.StatusCode
+		rpcResponse.Error.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+
+		c.log.Error("rpc error", "code", rpcResponse.Error.Code, "message", rpcResponse.Error.Message)
+
+		return nil, rpcResponse.Error
+	}
+
+	if !isSuccessStatus(resp.StatusCode) {
+		return nil, newHTTPError(resp)
 	}
 
 	return rpcResponse.Result, nil
 }
+
+// decodeBatchResponse decodes the responses of a batch. A server that rejects the whole batch
+// replies with a single error response instead of an array.
+func (c Client) decodeBatchResponse(resp *http.Response) ([]Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '{' {
+		rpcResult, err := c.decodeResponse(&http.Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("unexpected response to batch request: %s", rpcResult)
+	}
+
+	var responses []Response
+	if err := json.Unmarshal(body, &responses); err != nil {
+		if !isSuccessStatus(resp.StatusCode) {
+			return nil, newHTTPError(resp)
+		}
+
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return responses, nil
+}
+
+func isSuccessStatus(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+}
+
+func newHTTPError(resp *http.Response) *HTTPError {
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
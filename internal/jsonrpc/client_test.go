@@ -2,9 +2,16 @@ package jsonrpc
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -28,7 +35,7 @@ func TestClient_Call(t *testing.T) {
 	ctx := context.TODO()
 
 	t.Run("should call rpc method without errors", func(t *testing.T) {
-		expectedCall := `{"jsonrpc":"2.0","id":1,"method":"test_method", "params":["param1","param2"]}`
+		expectedCall := `{"jsonrpc":"2.0","method":"test_method", "params":["param1","param2"]}`
 
 		mockHTTPClient := &mock.HTTPClient{
 			ResponseBytes: []byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`),
@@ -49,11 +56,11 @@ func TestClient_Call(t *testing.T) {
 		requestBytes, err := io.ReadAll(mockHTTPClient.GotRequest.Body)
 		require.NoError(t, err)
 
-		require.JSONEq(t, expectedCall, string(requestBytes))
+		requireRequest(t, expectedCall, requestBytes)
 	})
 
 	t.Run("should call rpc method without error returned by rpc service", func(t *testing.T) {
-		expectedCall := `{"jsonrpc":"2.0","id":1,"method":"test_method"}`
+		expectedCall := `{"jsonrpc":"2.0","method":"test_method"}`
 
 		mockHTTPClient := &mock.HTTPClient{
 			ResponseBytes: []byte(`{"jsonrpc":"2.0","error": {"code": -32602, "message": "Invalid params"}}`),
@@ -68,7 +75,7 @@ func TestClient_Call(t *testing.T) {
 		requestBytes, err := io.ReadAll(mockHTTPClient.GotRequest.Body)
 		require.NoError(t, err)
 
-		require.JSONEq(t, expectedCall, string(requestBytes))
+		requireRequest(t, expectedCall, requestBytes)
 	})
 
 	t.Run("should return error because of invalid call", func(t *testing.T) {
@@ -96,14 +103,364 @@ func TestClient_Call(t *testing.T) {
 	})
 
 	t.Run("should error because of invalid request", func(t *testing.T) {
-		mockHTTPRequestBuilder := &mock.HTTPRequestBuilder{
-			ShouldError: true,
+		c, err := NewClient(endpoint, WithHTTPRequestBuilder(&headerRequestBuilder{shouldError: true}))
+		require.NoError(t, err)
+
+		_, err = c.Call(ctx, "test", nil)
+		require.ErrorContains(t, err, "could not create request: test error")
+	})
+}
+
+// headerRequestBuilder is a custom request builder setting the X-Builder header, or failing if shouldError.
+type headerRequestBuilder struct {
+	shouldError bool
+}
+
+func (b *headerRequestBuilder) Build(
+	ctx context.Context,
+	endpoint string,
+	rpcMethod string,
+	params interface{},
+) (*http.Request, error) {
+	if b.shouldError {
+		return nil, errors.New("test error")
+	}
+
+	req, err := simpleRequestBuilder{}.Build(ctx, endpoint, rpcMethod, params)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Builder", "custom")
+
+	return req, nil
+}
+
+func (b *headerRequestBuilder) BuildBatch(ctx context.Context, endpoint string, requests []Request) (*http.Request, error) {
+	if b.shouldError {
+		return nil, errors.New("test error")
+	}
+
+	req, err := simpleRequestBuilder{}.BuildBatch(ctx, endpoint, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Builder", "custom")
+
+	return req, nil
+}
+
+// batchServer is a JSON-RPC server replying to batches in reverse order. Requests of the `fail_method`
+// get an error and requests of the `missing_method` get no response.
+func batchServer(t *testing.T, batchSizes *[]int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requests))
+
+		*batchSizes = append(*batchSizes, len(requests))
+
+		var responses []Response
+		for i := len(requests) - 1; i >= 0; i-- {
+			request := requests[i]
+
+			switch request.Method {
+			case "fail_method":
+				responses = append(responses, Response{
+					JsonRPC: "2.0",
+					ID:      request.ID,
+					Error:   &Error{Code: -32000, Message: "execution reverted"},
+				})
+			case "missing_method":
+			default:
+				result, err := json.Marshal(request.Params)
+				require.NoError(t, err)
+
+				responses = append(responses, Response{JsonRPC: "2.0", ID: request.ID, Result: result})
+			}
 		}
 
-		c, err := NewClient(endpoint, WithHTTPRequestBuilder(mockHTTPRequestBuilder))
+		require.NoError(t, json.NewEncoder(w).Encode(responses))
+	}))
+}
+
+func TestClient_CallBatch(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("should correlate out of order responses and per element errors", func(t *testing.T) {
+		var batchSizes []int
+		server := batchServer(t, &batchSizes)
+		defer server.Close()
+
+		c, err := NewClient(server.URL)
 		require.NoError(t, err)
 
-		_, err = c.Call(ctx, "test", nil)
+		batch := []BatchElem{
+			{Method: "echo", Params: []interface{}{"0x1"}},
+			{Method: "fail_method"},
+			{Method: "echo", Params: []interface{}{"0x3"}},
+			{Method: "missing_method"},
+		}
+
+		require.NoError(t, c.CallBatch(ctx, batch))
+		require.Equal(t, []int{4}, batchSizes, "should send a single request")
+
+		require.NoError(t, batch[0].Error)
+		require.JSONEq(t, `["0x1"]`, string(batch[0].Result))
+		require.ErrorContains(t, batch[1].Error, "rpc error: execution reverted")
+		require.NoError(t, batch[2].Error)
+		require.JSONEq(t, `["0x3"]`, string(batch[2].Result))
+		require.ErrorIs(t, batch[3].Error, errMissingResponse)
+	})
+
+	t.Run("should build the batches with the custom request builder", func(t *testing.T) {
+		var batchSizes []int
+		batches := batchServer(t, &batchSizes)
+		defer batches.Close()
+
+		var headers []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers = append(headers, r.Header.Get("X-Builder"))
+			batches.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		c, err := NewClient(server.URL, WithHTTPRequestBuilder(&headerRequestBuilder{}))
+		require.NoError(t, err)
+
+		require.NoError(t, c.CallBatch(ctx, []BatchElem{{Method: "echo"}, {Method: "echo"}}))
+		require.Equal(t, []string{"custom"}, headers)
+
+		c, err = NewClient(server.URL, WithHTTPRequestBuilder(&headerRequestBuilder{shouldError: true}))
+		require.NoError(t, err)
+
+		err = c.CallBatch(ctx, []BatchElem{{Method: "echo"}})
 		require.ErrorContains(t, err, "could not create request: test error")
 	})
+
+	t.Run("should split batches larger than the max batch size", func(t *testing.T) {
+		var batchSizes []int
+		server := batchServer(t, &batchSizes)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, WithMaxBatchSize(2))
+		require.NoError(t, err)
+
+		batch := make([]BatchElem, 5)
+		for i := range batch {
+			batch[i] = BatchElem{Method: "echo", Params: []interface{}{i}}
+		}
+
+		require.NoError(t, c.CallBatch(ctx, batch))
+		require.Equal(t, []int{2, 2, 1}, batchSizes)
+
+		for i, elem := range batch {
+			require.NoError(t, elem.Error)
+			require.JSONEq(t, fmt.Sprintf(`[%d]`, i), string(elem.Result))
+		}
+	})
+
+	t.Run("should set the error of a rejected batch to all its elements", func(t *testing.T) {
+		mockHTTPClient := &mock.HTTPClient{
+			ResponseBytes: []byte(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"batch too large"}}`),
+		}
+
+		c, err := NewClient(endpoint, WithHTTPClient(mockHTTPClient))
+		require.NoError(t, err)
+
+		batch := []BatchElem{{Method: "echo"}, {Method: "echo"}}
+
+		err = c.CallBatch(ctx, batch)
+		require.ErrorContains(t, err, "rpc error: batch too large")
+
+		for _, elem := range batch {
+			require.ErrorContains(t, elem.Error, "rpc error: batch too large")
+		}
+	})
+
+	t.Run("should return error because of invalid call", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithHTTPClient(&mock.HTTPClient{ShouldError: true}))
+		require.NoError(t, err)
+
+		batch := []BatchElem{{Method: "echo"}}
+
+		require.ErrorContains(t, c.CallBatch(ctx, batch), "could not send request: test error")
+		require.Error(t, batch[0].Error)
+	})
+
+	t.Run("should return error because of invalid response format", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithHTTPClient(&mock.HTTPClient{ResponseBytes: []byte(`invalid json`)}))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, c.CallBatch(ctx, []BatchElem{{Method: "echo"}}), "could not decode response")
+	})
+
+	t.Run("should return error because of missing method", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithHTTPClient(&mock.HTTPClient{}))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, c.CallBatch(ctx, []BatchElem{{}}), "method is required")
+	})
+}
+
+// flakyServer replies to the first failures requests with the failure handler, then with a valid result.
+func flakyServer(failures int, failure http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	attempts := &atomic.Int32{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(attempts.Add(1)) <= failures {
+			failure(w, r)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			var requests []Request
+			_ = json.Unmarshal(body, &requests)
+			_, _ = fmt.Fprintf(w, `[{"jsonrpc":"2.0","id":%d,"result":"0x1"}]`, requests[0].ID)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+
+	return server, attempts
+}
+
+func TestClient_CallRetries(t *testing.T) {
+	ctx := context.TODO()
+	fastRetries := WithRetryPolicy(ExponentialBackoff{MaxRetries: 3, InitialBackoff: time.Millisecond})
+
+	rateLimited := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"rate limited","data":{"x":1}}}`))
+	}
+
+	t.Run("should retry rate limited calls", func(t *testing.T) {
+		server, attempts := flakyServer(2, rateLimited)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		resp, err := c.Call(ctx, "test_method", nil)
+		require.NoError(t, err)
+		require.Equal(t, json.RawMessage(`"0x1"`), resp)
+		require.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("should return the typed error when retries are exhausted", func(t *testing.T) {
+		server, attempts := flakyServer(10, rateLimited)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		_, err = c.Call(ctx, "test_method", nil)
+
+		var rpcErr *Error
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, CodeLimitExceeded, rpcErr.Code)
+		require.Equal(t, http.StatusTooManyRequests, rpcErr.HTTPStatus)
+		require.JSONEq(t, `{"x":1}`, string(rpcErr.Data))
+		require.Equal(t, int32(4), attempts.Load(), "should try once and retry 3 times")
+	})
+
+	t.Run("should not retry non retryable errors", func(t *testing.T) {
+		server, attempts := flakyServer(10, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid params"}}`))
+		})
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		_, err = c.Call(ctx, "test_method", nil)
+		require.ErrorContains(t, err, "rpc error: invalid params")
+		require.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("should return http errors of non json responses", func(t *testing.T) {
+		server, attempts := flakyServer(1, func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`<html>unauthorized</html>`))
+		})
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		_, err = c.Call(ctx, "test_method", nil)
+
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		require.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+		require.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("should honor retry after", func(t *testing.T) {
+		server, attempts := flakyServer(1, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = c.Call(ctx, "test_method", nil)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), time.Second)
+		require.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("should not wait for a retry after the context deadline", func(t *testing.T) {
+		server, attempts := flakyServer(1, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		start := time.Now()
+		_, err = c.Call(ctx, "test_method", nil)
+		require.ErrorContains(t, err, "unexpected http status: 429 Too Many Requests")
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("should retry rate limited batches", func(t *testing.T) {
+		server, attempts := flakyServer(1, rateLimited)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, fastRetries, WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		batch := []BatchElem{{Method: "test_method"}}
+		require.NoError(t, c.CallBatch(ctx, batch))
+		require.NoError(t, batch[0].Error)
+		require.Equal(t, json.RawMessage(`"0x1"`), batch[0].Result)
+		require.Equal(t, int32(2), attempts.Load())
+	})
+
+	t.Run("should not retry when retries are disabled", func(t *testing.T) {
+		server, attempts := flakyServer(1, rateLimited)
+		defer server.Close()
+
+		c, err := NewClient(server.URL, WithoutRetries(), WithLogger(&mock.Logger{}))
+		require.NoError(t, err)
+
+		_, err = c.Call(ctx, "test_method", nil)
+		require.Error(t, err)
+		require.Equal(t, int32(1), attempts.Load())
+	})
 }
@@ -0,0 +1,131 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// JSON-RPC error codes that signal a temporary failure of the server.
+const (
+	CodeInternalError = -32603
+	CodeLimitExceeded = -32005 // rate limit of most providers, see EIP-1474
+)
+
+// CodeMethodNotFound is returned by the servers that do not support the called method.
+const CodeMethodNotFound = -32601
+
+// Error is an error returned by the JSON-RPC server.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	// HTTPStatus is the status code of the HTTP response carrying the error.
+	HTTPStatus int `json:"-"`
+	// RetryAfter is the delay requested by the server with the Retry-After header, zero when not set.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error: %s", e.Message)
+}
+
+// HTTPError is returned when the server replies with a non 2xx status code without a JSON-RPC error.
+type HTTPError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the server with the Retry-After header, zero when not set.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected http status: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsRetryable tells if a call that failed with the error can succeed when retried: rate limits,
+// temporary server failures and network errors are retryable, while malformed requests, execution
+// errors and canceled contexts are not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == CodeLimitExceeded ||
+			rpcErr.Code == CodeInternalError ||
+			isRetryableStatus(rpcErr.HTTPStatus)
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return isRetryableStatus(httpErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// RetryAfter returns the delay requested by the server with the Retry-After header of the response
+// that caused the error, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) && rpcErr.RetryAfter > 0 {
+		return rpcErr.RetryAfter, true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter, true
+	}
+
+	return 0, false
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Run("should classify errors", func(t *testing.T) {
+		testCases := []struct {
+			err       error
+			retryable bool
+		}{
+			{err: nil, retryable: false},
+			{err: errors.New("test error"), retryable: false},
+			{err: context.Canceled, retryable: false},
+			{err: fmt.Errorf("could not send request: %w", context.DeadlineExceeded), retryable: false},
+			{err: &Error{Code: CodeLimitExceeded, Message: "rate limited"}, retryable: true},
+			{err: &Error{Code: CodeInternalError, Message: "internal error"}, retryable: true},
+			{err: &Error{Code: -32000, Message: "limit", HTTPStatus: http.StatusTooManyRequests}, retryable: true},
+			{err: &Error{Code: -32602, Message: "invalid params", HTTPStatus: http.StatusOK}, retryable: false},
+			{err: &Error{Code: -32000, Message: "execution reverted"}, retryable: false},
+			{err: fmt.Errorf("wrapped: %w", &HTTPError{StatusCode: http.StatusServiceUnavailable}), retryable: true},
+			{err: &HTTPError{StatusCode: http.StatusTooManyRequests}, retryable: true},
+			{err: &HTTPError{StatusCode: http.StatusBadRequest}, retryable: false},
+			{err: &HTTPError{StatusCode: http.StatusUnauthorized}, retryable: false},
+			{err: &net.OpError{Op: "dial", Err: errors.New("no route to host")}, retryable: true},
+			{err: fmt.Errorf("could not send request: %w", io.ErrUnexpectedEOF), retryable: true},
+			{err: fmt.Errorf("could not send request: %w", syscall.ECONNRESET), retryable: true},
+		}
+
+		for _, tc := range testCases {
+			require.Equal(t, tc.retryable, IsRetryable(tc.err), "%v", tc.err)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Run("should return the delay requested by the server", func(t *testing.T) {
+		retryAfter, ok := RetryAfter(fmt.Errorf("wrapped: %w", &Error{RetryAfter: time.Second}))
+		require.True(t, ok)
+		require.Equal(t, time.Second, retryAfter)
+
+		retryAfter, ok = RetryAfter(&HTTPError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute})
+		require.True(t, ok)
+		require.Equal(t, time.Minute, retryAfter)
+
+		_, ok = RetryAfter(&HTTPError{StatusCode: http.StatusTooManyRequests})
+		require.False(t, ok)
+
+		_, ok = RetryAfter(errors.New("test error"))
+		require.False(t, ok)
+	})
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 4, 20, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should parse seconds and http dates", func(t *testing.T) {
+		require.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+		require.Equal(t, time.Minute, parseRetryAfter("Sat, 20 Apr 2024 10:01:00 GMT", now))
+	})
+
+	t.Run("should ignore invalid and past values", func(t *testing.T) {
+		require.Zero(t, parseRetryAfter("", now))
+		require.Zero(t, parseRetryAfter("-1", now))
+		require.Zero(t, parseRetryAfter("soon", now))
+		require.Zero(t, parseRetryAfter("Sat, 20 Apr 2024 09:00:00 GMT", now))
+	})
+}
+
+func TestError(t *testing.T) {
+	t.Run("errors should describe the failure", func(t *testing.T) {
+		require.Equal(t, "rpc error: rate limited", (&Error{Code: CodeLimitExceeded, Message: "rate limited"}).Error())
+		require.Equal(t, "unexpected http status: 429 Too Many Requests",
+			(&HTTPError{StatusCode: http.StatusTooManyRequests}).Error())
+	})
+}
@@ -0,0 +1,125 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// IPCClient is a JSON-RPC client over the IPC endpoint of a local node, i.e. a Unix domain socket like
+// geth.ipc. Requests are multiplexed by their IDs over a single connection, and subscriptions are supported
+// like with WebSocketClient.
+type IPCClient struct {
+	*streamClient
+}
+
+func NewIPCClient(path string, opts ...StreamOption) (*IPCClient, error) {
+	if path == "" {
+		return nil, fmt.Errorf("ipc endpoint is required")
+	}
+
+	c := newStreamClient(opts...)
+	c.dial = func(ctx context.Context) (messageConn, error) {
+		conn, err := dialIPC(ctx, path, c.maxMessageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		return conn, nil
+	}
+
+	return &IPCClient{streamClient: c}, nil
+}
+
+// ipcConn is a connection to an IPC endpoint. Messages are JSON values written one after the other on the
+// stream, which is how geth and erigon frame them.
+type ipcConn struct {
+	conn       net.Conn
+	limit      *messageLimitReader
+	decoder    *json.Decoder
+	writeMutex sync.Mutex
+}
+
+func dialIPC(ctx context.Context, path string, maxMessageSize int64) (*ipcConn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial: %w", err)
+	}
+
+	return newIPCConn(conn, maxMessageSize), nil
+}
+
+func newIPCConn(conn net.Conn, maxMessageSize int64) *ipcConn {
+	limit := &messageLimitReader{reader: conn, max: maxMessageSize}
+
+	return &ipcConn{conn: conn, limit: limit, decoder: json.NewDecoder(limit)}
+}
+
+func (c *ipcConn) readMessage() ([]byte, error) {
+	c.limit.reset()
+
+	var message json.RawMessage
+	if err := c.decoder.Decode(&message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (c *ipcConn) writeMessage(ctx context.Context, message []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = c.conn.SetWriteDeadline(time.Time{})
+		}()
+	}
+
+	_, err := c.conn.Write(append(message, '\n'))
+
+	return err
+}
+
+func (c *ipcConn) close() error {
+	return c.conn.Close()
+}
+
+func (c *ipcConn) abort() error {
+	return c.conn.Close()
+}
+
+// messageLimitReader fails with errMessageTooLarge when more than max bytes are read since the last reset.
+// The decoder reads ahead, so the limit is approximate: it may include the beginning of the next message.
+type messageLimitReader struct {
+	reader io.Reader
+	max    int64
+	read   int64
+}
+
+func (r *messageLimitReader) reset() {
+	r.read = 0
+}
+
+func (r *messageLimitReader) Read(p []byte) (int, error) {
+	if r.read >= r.max {
+		return 0, errMessageTooLarge
+	}
+
+	if remaining := r.max - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	return n, err
+}
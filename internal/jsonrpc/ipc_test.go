@@ -0,0 +1,254 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+)
+
+// ipcTestServer is a JSON-RPC server over a Unix socket standing in for the IPC endpoint of a node.
+// Every request is answered concurrently: test_sleep replies after the milliseconds of its first param,
+// so that responses can be sent out of order, and eth_subscribe sends a notification right after its response.
+type ipcTestServer struct {
+	path     string
+	listener net.Listener
+	conns    []*ipcConn
+	mutex    sync.Mutex
+}
+
+func newIPCTestServer(t *testing.T) *ipcTestServer {
+	path := filepath.Join(t.TempDir(), "geth.ipc")
+
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	s := &ipcTestServer{path: path, listener: listener}
+	t.Cleanup(func() {
+		_ = listener.Close()
+		s.dropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			ipc := newIPCConn(conn, defaultMaxMessageSize)
+
+			s.mutex.Lock()
+			s.conns = append(s.conns, ipc)
+			s.mutex.Unlock()
+
+			go s.serve(ipc)
+		}
+	}()
+
+	return s
+}
+
+func (s *ipcTestServer) serve(conn *ipcConn) {
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			return
+		}
+
+		if message[0] == '[' {
+			var requests []Request
+			if err := json.Unmarshal(message, &requests); err != nil {
+				return
+			}
+
+			responses := make([]Response, len(requests))
+			for i, req := range requests {
+				responses[i] = Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"0x10"`)}
+			}
+
+			writeIPC(conn, responses)
+
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(message, &req); err != nil {
+			return
+		}
+
+		go s.handle(conn, req)
+	}
+}
+
+func (s *ipcTestServer) handle(conn *ipcConn, req Request) {
+	switch req.Method {
+	case "test_sleep":
+		params, _ := req.Params.([]interface{})
+		delay, _ := params[0].(float64)
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+
+		writeIPC(conn, Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(fmt.Sprintf("%v", delay))})
+	case "eth_subscribe":
+		writeIPC(conn, Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"0xs1"`)})
+		writeIPC(conn, json.RawMessage(
+			`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xs1","result":{"number":"0x1"}}}`))
+	case "test_large":
+		writeIPC(conn, Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"` + strings.Repeat("a", 1024) + `"`)})
+	default:
+		writeIPC(conn, Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"0x10"`)})
+	}
+}
+
+func writeIPC(conn *ipcConn, v interface{}) {
+	message, _ := json.Marshal(v)
+	_ = conn.writeMessage(context.TODO(), message)
+}
+
+// dropConnections closes all the connections abruptly.
+func (s *ipcTestServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.abort()
+	}
+
+	s.conns = nil
+}
+
+func newTestIPCClient(t *testing.T, path string, opts ...StreamOption) *IPCClient {
+	opts = append([]StreamOption{
+		WithStreamLogger(&mock.Logger{}),
+		WithReconnectBackoff(10*time.Millisecond, 10*time.Millisecond),
+	}, opts...)
+
+	c, err := NewIPCClient(path, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+func TestNewIPCClient(t *testing.T) {
+	_, err := NewIPCClient("")
+	require.Error(t, err)
+
+	c, err := NewIPCClient("/tmp/geth.ipc")
+	require.NoError(t, err)
+	require.NotNil(t, c.log)
+	require.NotNil(t, c.retryPolicy)
+}
+
+func TestIPCClient_Call(t *testing.T) {
+	server := newIPCTestServer(t)
+	c := newTestIPCClient(t, server.path)
+
+	t.Run("should return the result of the call", func(t *testing.T) {
+		resp, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `"0x10"`, string(resp))
+	})
+
+	t.Run("should match the responses sent out of order", func(t *testing.T) {
+		delays := []int{200, 100, 0}
+		results := make([]string, len(delays))
+
+		wg := sync.WaitGroup{}
+		for i, delay := range delays {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				resp, err := c.Call(context.TODO(), "test_sleep", []interface{}{delay})
+				require.NoError(t, err)
+
+				results[i] = string(resp)
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, []string{"200", "100", "0"}, results)
+
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		require.Len(t, server.conns, 1, "calls should share the connection")
+	})
+
+	t.Run("should connect again after the connection is lost", func(t *testing.T) {
+		server.dropConnections()
+
+		require.Eventually(t, func() bool {
+			_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should fail after close", func(t *testing.T) {
+		require.NoError(t, c.Close())
+
+		_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+		require.ErrorIs(t, err, ErrClientClosed)
+	})
+}
+
+func TestIPCClient_CallBatch(t *testing.T) {
+	server := newIPCTestServer(t)
+	c := newTestIPCClient(t, server.path)
+
+	batch := []BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_blockNumber"}}
+	require.NoError(t, c.CallBatch(context.TODO(), batch))
+
+	for _, elem := range batch {
+		require.NoError(t, elem.Error)
+		require.JSONEq(t, `"0x10"`, string(elem.Result))
+	}
+}
+
+func TestIPCClient_Subscribe(t *testing.T) {
+	server := newIPCTestServer(t)
+	c := newTestIPCClient(t, server.path)
+
+	sub, err := c.Subscribe(context.TODO(), "newHeads")
+	require.NoError(t, err)
+
+	select {
+	case notification := <-sub.Notifications():
+		require.JSONEq(t, `{"number":"0x1"}`, string(notification))
+	case <-time.After(time.Second):
+		t.Fatal("notification not received")
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestIPCClient_maxMessageSize(t *testing.T) {
+	server := newIPCTestServer(t)
+	c := newTestIPCClient(t, server.path, WithMaxMessageSize(512), WithStreamRetryPolicy(noRetries{}))
+
+	_, err := c.Call(context.TODO(), "test_large", nil)
+	require.ErrorIs(t, err, errMessageTooLarge)
+
+	_, err = c.Call(context.TODO(), "eth_blockNumber", nil)
+	require.NoError(t, err, "should connect again")
+}
+
+func TestIPCClient_dial(t *testing.T) {
+	c := newTestIPCClient(t, filepath.Join(t.TempDir(), "missing.ipc"), WithStreamRetryPolicy(noRetries{}))
+
+	_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+	require.Error(t, err)
+	require.True(t, IsRetryable(err))
+}
@@ -0,0 +1,141 @@
+package jsonrpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LimiterStats reports how long the HTTP requests of a Client waited for the rate limit (see WithRateLimit)
+// and for the max in flight requests (see WithMaxInFlight) before being sent.
+type LimiterStats struct {
+	Requests  uint64        // HTTP requests sent
+	Delayed   uint64        // HTTP requests that had to wait
+	TotalWait time.Duration // time spent waiting by all the requests
+	MaxWait   time.Duration // longest wait of a request
+}
+
+// limiter paces the HTTP requests of a Client. It is shared by all the copies of the Client, so the limits
+// apply to all of its callers.
+type limiter struct {
+	bucket    *tokenBucket  // nil when there is no rate limit
+	inFlight  chan struct{} // nil when there is no max in flight requests
+	requests  atomic.Uint64
+	delayed   atomic.Uint64
+	totalWait atomic.Int64
+	maxWait   atomic.Int64
+}
+
+// acquire waits until a request made of n JSON-RPC calls can be sent: each call takes a token of the
+// rate limit, while the whole request takes a single in flight slot. The returned function releases the slot
+// once the response is read.
+func (l *limiter) acquire(ctx context.Context, n int) (func(), error) {
+	start := time.Now()
+	delayed := false
+
+	if l.bucket != nil {
+		if delay := l.bucket.reserve(n, start); delay > 0 {
+			delayed = true
+
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				l.bucket.cancel(n)
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	release := func() {}
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		default:
+			delayed = true
+
+			select {
+			case l.inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		release = func() {
+			<-l.inFlight
+		}
+	}
+
+	l.requests.Add(1)
+
+	if delayed {
+		l.record(time.Since(start))
+	}
+
+	return release, nil
+}
+
+func (l *limiter) record(wait time.Duration) {
+	l.delayed.Add(1)
+	l.totalWait.Add(int64(wait))
+
+	for {
+		maxWait := l.maxWait.Load()
+		if int64(wait) <= maxWait || l.maxWait.CompareAndSwap(maxWait, int64(wait)) {
+			return
+		}
+	}
+}
+
+func (l *limiter) stats() LimiterStats {
+	return LimiterStats{
+		Requests:  l.requests.Load(),
+		Delayed:   l.delayed.Load(),
+		TotalWait: time.Duration(l.totalWait.Load()),
+		MaxWait:   time.Duration(l.maxWait.Load()),
+	}
+}
+
+// tokenBucket is a token bucket refilled at rate tokens per second, holding up to burst tokens.
+// Tokens are reserved in advance: the bucket goes into debt, so that the callers are served in order.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mutex  sync.Mutex
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// reserve takes n tokens and returns how long to wait before they are available.
+func (b *tokenBucket) reserve(n int, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back the tokens of a reservation that is not used.
+func (b *tokenBucket) cancel(n int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+float64(n))
+}
@@ -0,0 +1,156 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should allow bursts and then pace the reservations", func(t *testing.T) {
+		b := newTokenBucket(10, 2, now)
+
+		require.Zero(t, b.reserve(1, now))
+		require.Zero(t, b.reserve(1, now))
+		require.Equal(t, 100*time.Millisecond, b.reserve(1, now))
+		require.Equal(t, 200*time.Millisecond, b.reserve(1, now), "callers should be served in order")
+	})
+
+	t.Run("should refill the tokens over time up to the burst", func(t *testing.T) {
+		b := newTokenBucket(10, 2, now)
+
+		require.Zero(t, b.reserve(2, now))
+		require.Zero(t, b.reserve(1, now.Add(100*time.Millisecond)))
+		require.Zero(t, b.reserve(2, now.Add(time.Hour)))
+		require.Equal(t, 100*time.Millisecond, b.reserve(1, now.Add(time.Hour)))
+	})
+
+	t.Run("should reserve more tokens than the burst", func(t *testing.T) {
+		b := newTokenBucket(10, 1, now)
+
+		require.Equal(t, 500*time.Millisecond, b.reserve(6, now))
+	})
+
+	t.Run("should give back the tokens of a canceled reservation", func(t *testing.T) {
+		b := newTokenBucket(10, 1, now)
+
+		require.Zero(t, b.reserve(1, now))
+		require.Equal(t, 100*time.Millisecond, b.reserve(1, now))
+
+		b.cancel(1)
+		require.Equal(t, 100*time.Millisecond, b.reserve(1, now))
+	})
+}
+
+func TestLimiter_acquire(t *testing.T) {
+	t.Run("should not wait without limits", func(t *testing.T) {
+		l := &limiter{}
+
+		release, err := l.acquire(context.TODO(), 10)
+		require.NoError(t, err)
+		release()
+
+		require.Equal(t, LimiterStats{Requests: 1}, l.stats())
+	})
+
+	t.Run("should wait for the rate limit", func(t *testing.T) {
+		l := &limiter{bucket: newTokenBucket(20, 1, time.Now())}
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			release, err := l.acquire(context.TODO(), 1)
+			require.NoError(t, err)
+			release()
+		}
+
+		require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+		stats := l.stats()
+		require.Equal(t, uint64(3), stats.Requests)
+		require.Equal(t, uint64(2), stats.Delayed)
+		require.Positive(t, stats.TotalWait)
+		require.GreaterOrEqual(t, stats.TotalWait, stats.MaxWait)
+	})
+
+	t.Run("should wait for a free in flight slot", func(t *testing.T) {
+		l := &limiter{inFlight: make(chan struct{}, 1)}
+
+		release, err := l.acquire(context.TODO(), 1)
+		require.NoError(t, err)
+
+		time.AfterFunc(50*time.Millisecond, release)
+
+		release, err = l.acquire(context.TODO(), 1)
+		require.NoError(t, err)
+		release()
+
+		require.GreaterOrEqual(t, l.stats().MaxWait, 40*time.Millisecond)
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		l := &limiter{bucket: newTokenBucket(1, 1, time.Now()), inFlight: make(chan struct{}, 1)}
+
+		release, err := l.acquire(context.TODO(), 1)
+		require.NoError(t, err)
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err = l.acquire(ctx, 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, uint64(1), l.stats().Requests)
+	})
+}
+
+func TestClient_maxInFlight(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x10"}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL, WithLogger(&mock.Logger{}), WithMaxInFlight(2))
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(2), maxInFlight.Load())
+
+	stats := c.LimiterStats()
+	require.Equal(t, uint64(10), stats.Requests)
+	require.Positive(t, stats.Delayed)
+}
@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -50,10 +51,103 @@ func TestWithHTTPRequestBuilder(t *testing.T) {
 	})
 
 	t.Run("with defined http request builder", func(t *testing.T) {
-		mockedBuilder := mock.HTTPRequestBuilder{}
+		mockedBuilder := headerRequestBuilder{}
 
 		c, err := NewClient(endpoint, WithHTTPRequestBuilder(&mockedBuilder))
 		require.NoError(t, err)
 		require.Equal(t, &mockedBuilder, c.httpRequestBuilder)
 	})
 }
+
+func TestWithMaxBatchSize(t *testing.T) {
+	t.Run("with invalid max batch size - should use default", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithMaxBatchSize(0))
+		require.NoError(t, err)
+		require.Equal(t, defaultMaxBatchSize, c.maxBatchSize)
+	})
+
+	t.Run("with defined max batch size", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithMaxBatchSize(5))
+		require.NoError(t, err)
+		require.Equal(t, 5, c.maxBatchSize)
+	})
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	t.Run("with nil retry policy - should use default", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRetryPolicy(nil))
+		require.NoError(t, err)
+		require.Equal(t, DefaultRetryPolicy(), c.retryPolicy)
+	})
+
+	t.Run("with defined retry policy", func(t *testing.T) {
+		policy := ExponentialBackoff{MaxRetries: 1}
+
+		c, err := NewClient(endpoint, WithRetryPolicy(policy))
+		require.NoError(t, err)
+		require.Equal(t, policy, c.retryPolicy)
+	})
+
+	t.Run("without retries", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithoutRetries())
+		require.NoError(t, err)
+		require.Equal(t, noRetries{}, c.retryPolicy)
+	})
+}
+
+func TestStreamOptions(t *testing.T) {
+	t.Run("set websocket opts", func(t *testing.T) {
+		log := &mock.Logger{}
+
+		c, err := NewWebSocketClient(
+			"ws://localhost:8546",
+			WithStreamLogger(log),
+			WithStreamRetryPolicy(noRetries{}),
+			WithDialTimeout(time.Second),
+			WithReconnectBackoff(time.Second, time.Minute),
+			WithMaxMessageSize(1024),
+		)
+		require.NoError(t, err)
+		require.Equal(t, log, c.log)
+		require.Equal(t, noRetries{}, c.retryPolicy)
+		require.Equal(t, time.Second, c.dialTimeout)
+		require.Equal(t, time.Second, c.reconnectBackoff)
+		require.Equal(t, time.Minute, c.maxReconnectBackoff)
+		require.Equal(t, int64(1024), c.maxMessageSize)
+	})
+
+	t.Run("should ignore invalid max message size", func(t *testing.T) {
+		c, err := NewWebSocketClient("ws://localhost:8546", WithMaxMessageSize(0))
+		require.NoError(t, err)
+		require.Equal(t, int64(defaultMaxMessageSize), c.maxMessageSize)
+	})
+}
+
+func TestWithRateLimit(t *testing.T) {
+	t.Run("without rate limit", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRateLimit(0, 10))
+		require.NoError(t, err)
+		require.Nil(t, c.limiter.bucket)
+	})
+
+	t.Run("with rate limit", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithRateLimit(25, 0))
+		require.NoError(t, err)
+		require.Equal(t, float64(25), c.limiter.bucket.rate)
+		require.Equal(t, float64(1), c.limiter.bucket.burst, "burst should be at least one")
+	})
+}
+
+func TestWithMaxInFlight(t *testing.T) {
+	t.Run("without max in flight", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithMaxInFlight(0))
+		require.NoError(t, err)
+		require.Nil(t, c.limiter.inFlight)
+	})
+
+	t.Run("with max in flight", func(t *testing.T) {
+		c, err := NewClient(endpoint, WithMaxInFlight(4))
+		require.NoError(t, err)
+		require.Equal(t, 4, cap(c.limiter.inFlight))
+	})
+}
@@ -0,0 +1,112 @@
+package jsonrpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = 200 * time.Millisecond
+	defaultMaxBackoff     = 5 * time.Second
+	defaultJitter         = 0.2
+)
+
+// RetryPolicy decides whether a failed call is retried and how long to wait before retrying it.
+type RetryPolicy interface {
+	// Backoff returns the delay before retrying a call that failed with the error after the given
+	// number of attempts, and false if the call must not be retried.
+	Backoff(attempts int, err error) (time.Duration, bool)
+}
+
+// ExponentialBackoff retries the retryable errors (see IsRetryable) up to MaxRetries times. The delay doubles
+// after every attempt, from InitialBackoff up to MaxBackoff, and is randomized by up to Jitter (0.2 = ±20%) so
+// that clients rate limited at the same time do not retry all at once. The delay requested by the server
+// with the Retry-After header takes precedence when longer.
+type ExponentialBackoff struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is set with WithRetryPolicy.
+func DefaultRetryPolicy() ExponentialBackoff {
+	return ExponentialBackoff{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Jitter:         defaultJitter,
+	}
+}
+
+func (b ExponentialBackoff) Backoff(attempts int, err error) (time.Duration, bool) {
+	if attempts > b.MaxRetries || !IsRetryable(err) {
+		return 0, false
+	}
+
+	backoff := b.InitialBackoff
+	for i := 1; i < attempts && backoff < b.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > b.MaxBackoff {
+		backoff = b.MaxBackoff
+	}
+
+	if b.Jitter > 0 {
+		backoff += time.Duration(float64(backoff) * b.Jitter * (2*rand.Float64() - 1))
+	}
+
+	if retryAfter, ok := RetryAfter(err); ok && retryAfter > backoff {
+		backoff = retryAfter
+	}
+
+	return backoff, true
+}
+
+// noRetries is the retry policy that never retries.
+type noRetries struct{}
+
+func (noRetries) Backoff(int, error) (time.Duration, bool) {
+	return 0, false
+}
+
+// withRetries executes the call until it succeeds or the retry policy gives up. It does not wait
+// for a retry that would happen after the deadline of the context.
+func withRetries(
+	ctx context.Context,
+	retryPolicy RetryPolicy,
+	log types.Logger,
+	method string,
+	call func() error,
+) error {
+	for attempts := 1; ; attempts++ {
+		err := call()
+		if err == nil {
+			return nil
+		}
+
+		backoff, retry := retryPolicy.Backoff(attempts, err)
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		log.Info("retrying rpc call", "method", method, "attempts", attempts, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
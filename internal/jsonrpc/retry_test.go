@@ -0,0 +1,59 @@
+package jsonrpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialBackoff_Backoff(t *testing.T) {
+	retryable := &Error{Code: CodeLimitExceeded, Message: "rate limited"}
+
+	t.Run("should double the backoff up to the max backoff", func(t *testing.T) {
+		policy := ExponentialBackoff{MaxRetries: 5, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}
+
+		expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second, 3 * time.Second}
+		for i, backoff := range expected {
+			got, retry := policy.Backoff(i+1, retryable)
+			require.True(t, retry)
+			require.Equal(t, backoff, got, "attempt %d", i+1)
+		}
+
+		_, retry := policy.Backoff(6, retryable)
+		require.False(t, retry, "should give up after max retries")
+	})
+
+	t.Run("should randomize the backoff with jitter", func(t *testing.T) {
+		policy := ExponentialBackoff{MaxRetries: 1, InitialBackoff: time.Second, MaxBackoff: time.Second, Jitter: 0.5}
+
+		for i := 0; i < 100; i++ {
+			got, retry := policy.Backoff(1, retryable)
+			require.True(t, retry)
+			require.GreaterOrEqual(t, got, 500*time.Millisecond)
+			require.LessOrEqual(t, got, 1500*time.Millisecond)
+		}
+	})
+
+	t.Run("should honor the delay requested by the server", func(t *testing.T) {
+		policy := DefaultRetryPolicy()
+
+		got, retry := policy.Backoff(1, &HTTPError{StatusCode: 429, RetryAfter: time.Minute})
+		require.True(t, retry)
+		require.Equal(t, time.Minute, got)
+	})
+
+	t.Run("should not retry non retryable errors", func(t *testing.T) {
+		_, retry := DefaultRetryPolicy().Backoff(1, errors.New("test error"))
+		require.False(t, retry)
+
+		_, retry = DefaultRetryPolicy().Backoff(1, &Error{Code: -32602, Message: "invalid params"})
+		require.False(t, retry)
+	})
+
+	t.Run("no retries policy should never retry", func(t *testing.T) {
+		_, retry := noRetries{}.Backoff(1, retryable)
+		require.False(t, retry)
+	})
+}
@@ -0,0 +1,738 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	defaultDialTimeout         = 10 * time.Second
+	defaultReconnectBackoff    = time.Second
+	defaultMaxReconnectBackoff = 30 * time.Second
+	defaultMaxMessageSize      = 64 << 20 // blocks with full transactions can be several megabytes
+	subscriptionBufferSize     = 100
+	unsubscribeTimeout         = 5 * time.Second
+)
+
+// ErrClientClosed is returned by the calls of a WebSocketClient or an IPCClient after Close.
+var ErrClientClosed = errors.New("rpc client closed")
+
+var errMessageTooLarge = errors.New("message too large")
+
+// messageConn is a connection exchanging JSON-RPC messages.
+type messageConn interface {
+	// readMessage returns the next message sent by the server.
+	readMessage() ([]byte, error)
+	// writeMessage sends a message, abandoning the write when the deadline of the context is exceeded.
+	writeMessage(ctx context.Context, message []byte) error
+	// close closes the connection gracefully.
+	close() error
+	// abort closes the connection immediately.
+	abort() error
+}
+
+// streamClient is a JSON-RPC client keeping a connection to the server, on which requests are multiplexed by
+// their IDs. On top of the calls of Client, it supports the subscriptions of eth_subscribe (see Subscribe).
+// The connection is established on the first call. When it drops, the calls in flight fail with a retryable
+// error (see IsRetryable) and the next call connects again, while the active subscriptions are renewed in
+// background on a new connection.
+type streamClient struct {
+	dial                func(ctx context.Context) (messageConn, error)
+	log                 types.Logger
+	retryPolicy         RetryPolicy
+	dialTimeout         time.Duration
+	reconnectBackoff    time.Duration
+	maxReconnectBackoff time.Duration
+	maxMessageSize      int64
+	session             *streamSession
+	subscriptions       map[*Subscription]struct{}
+	subscriptionIDs     map[string]*Subscription // server subscription ID -> subscription
+	reconnecting        bool
+	closed              chan struct{}
+	closeOnce           sync.Once
+	dialMutex           sync.Mutex
+	mutex               sync.Mutex
+}
+
+func newStreamClient(opts ...StreamOption) *streamClient {
+	c := &streamClient{
+		dialTimeout:         defaultDialTimeout,
+		reconnectBackoff:    defaultReconnectBackoff,
+		maxReconnectBackoff: defaultMaxReconnectBackoff,
+		maxMessageSize:      defaultMaxMessageSize,
+		subscriptions:       make(map[*Subscription]struct{}),
+		subscriptionIDs:     make(map[string]*Subscription),
+		closed:              make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.log == nil {
+		// use default logger when not provided
+		c.log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+
+	if c.retryPolicy == nil {
+		// retry the retryable errors when no policy is provided
+		c.retryPolicy = DefaultRetryPolicy()
+	}
+
+	return c
+}
+
+// Call sends an RPC request to the server and returns the result.
+// Failed calls are retried according to the retry policy (see WithStreamRetryPolicy).
+func (c *streamClient) Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	var rpcResult json.RawMessage
+
+	err := withRetries(ctx, c.retryPolicy, c.log, method, func() error {
+		var err error
+		rpcResult, err = c.call(ctx, method, params, nil)
+
+		return err
+	})
+
+	return rpcResult, err
+}
+
+// CallBatch sends the requests of the batch to the server in a single message and sets the result or the
+// error of every element. When the batch fails as a whole, it is retried according to the retry policy.
+// If it still fails, the error is set to all the elements and returned as well.
+func (c *streamClient) CallBatch(ctx context.Context, batch []BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := withRetries(ctx, c.retryPolicy, c.log, "batch", func() error {
+		return c.callBatch(ctx, batch)
+	})
+	if err != nil {
+		for i := range batch {
+			batch[i].Error = err
+		}
+	}
+
+	return err
+}
+
+// Subscribe creates a subscription with eth_subscribe. The namespace is the kind of notifications,
+// e.g. "newHeads" or "logs", and the args are its parameters, e.g. the filter of the logs.
+// The subscription is renewed automatically when the connection is lost, notifications sent by the
+// server while disconnected are lost.
+func (c *streamClient) Subscribe(
+	ctx context.Context,
+	namespace string,
+	args ...interface{},
+) (types.RPCSubscription, error) {
+	sub := &Subscription{
+		client:        c,
+		namespace:     namespace,
+		params:        append([]interface{}{namespace}, args...),
+		notifications: make(chan json.RawMessage, subscriptionBufferSize),
+	}
+
+	c.mutex.Lock()
+	c.subscriptions[sub] = struct{}{}
+	c.mutex.Unlock()
+
+	err := withRetries(ctx, c.retryPolicy, c.log, "eth_subscribe", func() error {
+		_, err := c.call(ctx, "eth_subscribe", sub.params, sub)
+		return err
+	})
+	if err != nil {
+		c.removeSubscription(sub)
+		return nil, fmt.Errorf("could not subscribe to %s: %w", namespace, err)
+	}
+
+	return sub, nil
+}
+
+// Close closes the connection and the subscriptions. Calls fail with ErrClientClosed afterward.
+func (c *streamClient) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		c.mutex.Lock()
+		session := c.session
+		for sub := range c.subscriptions {
+			delete(c.subscriptions, sub)
+			close(sub.notifications)
+		}
+		clear(c.subscriptionIDs)
+		c.mutex.Unlock()
+
+		if session != nil {
+			session.close()
+		}
+	})
+
+	return nil
+}
+
+func (c *streamClient) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *streamClient) call(
+	ctx context.Context,
+	method string,
+	params interface{},
+	subscription *Subscription,
+) (json.RawMessage, error) {
+	if method == "" {
+		return nil, fmt.Errorf("method is required")
+	}
+
+	session, err := c.getSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := nextRequestID()
+
+	message, err := json.Marshal(Request{JsonRPC: "2.0", Method: method, Params: params, ID: id})
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	responses, err := session.roundTrip(ctx, message, []int64{id}, subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcResponse, ok := responses[id]
+	if !ok {
+		return nil, errMissingResponse
+	}
+
+	if rpcResponse.Error != nil {
+		c.log.Error("rpc error", "code", rpcResponse.Error.Code, "message", rpcResponse.Error.Message)
+		return nil, rpcResponse.Error
+	}
+
+	return rpcResponse.Result, nil
+}
+
+func (c *streamClient) callBatch(ctx context.Context, batch []BatchElem) error {
+	requests := make([]Request, len(batch))
+	ids := make([]int64, len(batch))
+
+	for i, elem := range batch {
+		if elem.Method == "" {
+			return fmt.Errorf("method is required")
+		}
+
+		ids[i] = nextRequestID()
+		requests[i] = Request{JsonRPC: "2.0", Method: elem.Method, Params: elem.Params, ID: ids[i]}
+	}
+
+	message, err := json.Marshal(requests)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	session, err := c.getSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	responses, err := session.roundTrip(ctx, message, ids, nil)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		rpcResponse, ok := responses[id]
+		switch {
+		case !ok:
+			batch[i].Error = errMissingResponse
+		case rpcResponse.Error != nil:
+			batch[i].Error = rpcResponse.Error
+		default:
+			batch[i].Result = rpcResponse.Result
+			batch[i].Error = nil
+		}
+	}
+
+	return nil
+}
+
+// getSession returns the current session, connecting to the server when there is none.
+func (c *streamClient) getSession(ctx context.Context) (*streamSession, error) {
+	c.dialMutex.Lock()
+	defer c.dialMutex.Unlock()
+
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+
+	c.mutex.Lock()
+	session := c.session
+	c.mutex.Unlock()
+
+	if session != nil && !session.isDone() {
+		return session, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.dialTimeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect: %w", err)
+	}
+
+	session = newSession(conn)
+
+	c.mutex.Lock()
+	c.session = session
+	c.mutex.Unlock()
+
+	go c.readMessages(session)
+
+	if c.isClosed() {
+		session.close()
+		return nil, ErrClientClosed
+	}
+
+	return session, nil
+}
+
+// readMessages dispatches the messages of the session until its connection is closed.
+func (c *streamClient) readMessages(session *streamSession) {
+	for {
+		message, err := session.conn.readMessage()
+		if err != nil {
+			session.fail(fmt.Errorf("connection lost: %w", err))
+			c.onDisconnect(session)
+
+			return
+		}
+
+		message = bytes.TrimSpace(message)
+		if len(message) > 0 && message[0] == '[' {
+			c.dispatchBatch(session, message)
+			continue
+		}
+
+		c.dispatch(session, message)
+	}
+}
+
+// streamMessage is a response or a notification sent by the server.
+type streamMessage struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+func (m streamMessage) response() Response {
+	return Response{JsonRPC: "2.0", Result: m.Result, Error: m.Error, ID: m.ID}
+}
+
+func (c *streamClient) dispatch(session *streamSession, data []byte) {
+	var msg streamMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.log.Error("could not decode rpc message", "error", err)
+		return
+	}
+
+	// Request IDs start from one, notifications have no ID.
+	if msg.ID == 0 {
+		if msg.Method == "eth_subscription" {
+			c.notify(msg.Params)
+		}
+
+		return
+	}
+
+	call, ok := session.take(msg.ID)
+	if !ok {
+		c.log.Error("unexpected rpc response id", "id", msg.ID)
+		return
+	}
+
+	if call.subscription != nil && msg.Error == nil {
+		// The subscription is registered before reading the next message, which can be its first notification.
+		var id string
+		if err := json.Unmarshal(msg.Result, &id); err == nil {
+			c.setSubscriptionID(call.subscription, id)
+		}
+	}
+
+	call.deliver(map[int64]Response{msg.ID: msg.response()})
+}
+
+func (c *streamClient) dispatchBatch(session *streamSession, data []byte) {
+	var msgs []streamMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		c.log.Error("could not decode rpc message", "error", err)
+		return
+	}
+
+	batches := make(map[chan map[int64]Response]map[int64]Response)
+	calls := make(map[chan map[int64]Response]pendingCall)
+
+	for _, msg := range msgs {
+		call, ok := session.take(msg.ID)
+		if !ok {
+			c.log.Error("unexpected rpc response id", "id", msg.ID)
+			continue
+		}
+
+		if batches[call.responses] == nil {
+			batches[call.responses] = make(map[int64]Response)
+			calls[call.responses] = call
+		}
+
+		batches[call.responses][msg.ID] = msg.response()
+	}
+
+	for responsesChan, responses := range batches {
+		calls[responsesChan].deliver(responses)
+	}
+}
+
+func (c *streamClient) notify(params json.RawMessage) {
+	var notification struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(params, &notification); err != nil {
+		c.log.Error("could not decode subscription notification", "error", err)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	sub, ok := c.subscriptionIDs[notification.Subscription]
+	if !ok {
+		return
+	}
+
+	select {
+	case sub.notifications <- notification.Result:
+	default:
+		c.log.Error("subscription buffer full, notification dropped", "namespace", sub.namespace)
+	}
+}
+
+func (c *streamClient) setSubscriptionID(sub *Subscription, id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.subscriptions[sub]; !ok {
+		// unsubscribed in the meantime
+		return
+	}
+
+	delete(c.subscriptionIDs, sub.id)
+	sub.id = id
+	c.subscriptionIDs[id] = sub
+}
+
+// removeSubscription removes the subscription and closes its channel. It returns the ID of the subscription
+// on the server, and false if it was already removed.
+func (c *streamClient) removeSubscription(sub *Subscription) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.subscriptions[sub]; !ok {
+		return "", false
+	}
+
+	delete(c.subscriptions, sub)
+	delete(c.subscriptionIDs, sub.id)
+	close(sub.notifications)
+
+	return sub.id, true
+}
+
+func (c *streamClient) isConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.session != nil && !c.session.isDone()
+}
+
+// onDisconnect starts renewing the subscriptions in background when the connection of the session is lost.
+func (c *streamClient) onDisconnect(session *streamSession) {
+	if c.isClosed() {
+		return
+	}
+
+	c.log.Error("rpc connection lost", "error", session.getErr())
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.session == session {
+		c.session = nil
+	}
+
+	// The subscription IDs are only valid for the connection that created them.
+	clear(c.subscriptionIDs)
+
+	if len(c.subscriptions) == 0 || c.reconnecting {
+		return
+	}
+
+	c.reconnecting = true
+
+	go c.reconnect()
+}
+
+// reconnect connects again and renews the subscriptions, with an exponential backoff between the attempts.
+func (c *streamClient) reconnect() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := c.reconnectBackoff
+
+	for attempts := 1; ; attempts++ {
+		err := c.resubscribe(ctx)
+		if err == nil && c.reconnected() {
+			c.log.Info("rpc connection reestablished", "attempts", attempts)
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.log.Error("could not reconnect", "attempts", attempts, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		backoff = min(2*backoff, c.maxReconnectBackoff)
+	}
+}
+
+// reconnected stops the reconnection if the connection is still up after renewing the subscriptions.
+// Otherwise, the connection dropped again while reconnecting and the reconnection continues.
+func (c *streamClient) reconnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.session == nil || c.session.isDone() {
+		return false
+	}
+
+	c.reconnecting = false
+
+	return true
+}
+
+func (c *streamClient) resubscribe(ctx context.Context) error {
+	if _, err := c.getSession(ctx); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	subs := make([]*Subscription, 0, len(c.subscriptions))
+	for sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mutex.Unlock()
+
+	for _, sub := range subs {
+		subscribeCtx, cancel := context.WithTimeout(ctx, c.dialTimeout)
+		_, err := c.call(subscribeCtx, "eth_subscribe", sub.params, sub)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("could not renew subscription to %s: %w", sub.namespace, err)
+		}
+	}
+
+	return nil
+}
+
+// Subscription receives the notifications of a subscription created with the Subscribe method
+// of WebSocketClient or IPCClient.
+// It implements types.RPCSubscription.
+type Subscription struct {
+	client        *streamClient
+	namespace     string
+	params        []interface{}
+	id            string // ID of the subscription on the server, guarded by the mutex of the client
+	notifications chan json.RawMessage
+}
+
+// Notifications returns the channel receiving the result of every notification. It is closed by Unsubscribe
+// and by the Close method of the client. Notifications are dropped while the buffer of the channel is full.
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifications
+}
+
+// Unsubscribe cancels the subscription and closes its channel.
+func (s *Subscription) Unsubscribe() {
+	id, ok := s.client.removeSubscription(s)
+	if !ok || id == "" || !s.client.isConnected() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+
+	if _, err := s.client.call(ctx, "eth_unsubscribe", []interface{}{id}, nil); err != nil {
+		s.client.log.Error("could not unsubscribe", "namespace", s.namespace, "error", err)
+	}
+}
+
+// streamSession is a connection and the requests waiting for a response on it.
+type streamSession struct {
+	conn    messageConn
+	pending map[int64]pendingCall
+	done    chan struct{}
+	err     error
+	mutex   sync.Mutex
+}
+
+// pendingCall is a request, or a batch of requests sharing the same channel, waiting for the responses.
+type pendingCall struct {
+	responses    chan map[int64]Response
+	subscription *Subscription // set for eth_subscribe requests
+}
+
+// deliver hands the responses to the caller. The read loop is never blocked, even by a server splitting the
+// responses of a batch in several messages: only the first part of the batch is delivered in that case.
+func (p pendingCall) deliver(responses map[int64]Response) {
+	select {
+	case p.responses <- responses:
+	default:
+	}
+}
+
+func newSession(conn messageConn) *streamSession {
+	return &streamSession{
+		conn:    conn,
+		pending: make(map[int64]pendingCall),
+		done:    make(chan struct{}),
+	}
+}
+
+// roundTrip sends the message and waits for the responses of the requests with the given IDs.
+func (s *streamSession) roundTrip(
+	ctx context.Context,
+	message []byte,
+	ids []int64,
+	subscription *Subscription,
+) (map[int64]Response, error) {
+	call := pendingCall{responses: make(chan map[int64]Response, 1), subscription: subscription}
+
+	s.mutex.Lock()
+	if s.err != nil {
+		s.mutex.Unlock()
+		return nil, s.err
+	}
+
+	for _, id := range ids {
+		s.pending[id] = call
+	}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		for _, id := range ids {
+			delete(s.pending, id)
+		}
+		s.mutex.Unlock()
+	}()
+
+	if err := s.conn.writeMessage(ctx, message); err != nil {
+		err = fmt.Errorf("could not send request: %w", err)
+		s.fail(err)
+
+		return nil, err
+	}
+
+	select {
+	case responses := <-call.responses:
+		return responses, nil
+	case <-s.done:
+		return nil, s.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take removes the pending call of a request when its response is received.
+func (s *streamSession) take(id int64) (pendingCall, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	call, ok := s.pending[id]
+	delete(s.pending, id)
+
+	return call, ok
+}
+
+func (s *streamSession) getErr() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+func (s *streamSession) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// fail closes the connection, the pending calls fail with the error.
+func (s *streamSession) fail(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	s.err = err
+	close(s.done)
+
+	_ = s.conn.abort()
+}
+
+func (s *streamSession) close() {
+	_ = s.conn.close()
+	s.fail(ErrClientClosed)
+}
@@ -7,8 +7,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync/atomic"
+
+	"github.com/ilkamo/ethparser-go/types"
 )
 
+// requestIDs generates the IDs of the requests, unique across all the clients of the process.
+var requestIDs atomic.Int64
+
+func nextRequestID() int64 {
+	return requestIDs.Add(1)
+}
+
 type Request struct {
 	JsonRPC string      `json:"jsonrpc"`
 	Method  string      `json:"method"`
@@ -23,11 +33,8 @@ type Response struct {
 	ID      int64           `json:"id,omitempty"`
 }
 
-type Error struct {
-	Code    int    `json:"code"`
-	Data    string `json:"data"`
-	Message string `json:"message"`
-}
+// BatchElem is a request of a batch call, see types.BatchElem.
+type BatchElem = types.BatchElem
 
 func newRequestBody(method string, params interface{}) (io.Reader, error) {
 	if method == "" {
@@ -40,7 +47,7 @@ func newRequestBody(method string, params interface{}) (io.Reader, error) {
 		JsonRPC: "2.0",
 		Method:  method,
 		Params:  params,
-		ID:      1, // TODO: generate unique ID
+		ID:      nextRequestID(),
 	}); err != nil {
 		return nil, err
 	}
@@ -74,3 +81,28 @@ func (s simpleRequestBuilder) Build(
 
 	return req, nil
 }
+
+func (s simpleRequestBuilder) BuildBatch(
+	ctx context.Context,
+	endpoint string,
+	requests []Request,
+) (*http.Request, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+
+	buff := new(bytes.Buffer)
+
+	if err := json.NewEncoder(buff).Encode(requests); err != nil {
+		return nil, fmt.Errorf("could not create request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, buff)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"testing"
 
@@ -24,7 +25,7 @@ func Test_simpleRequestBuilder_Build(t *testing.T) {
 	})
 
 	t.Run("builder with endpoint and rpc method - with params", func(t *testing.T) {
-		expectedBody := `{"jsonrpc":"2.0","id":1,"method":"test_method", "params":["param1","param2"]}`
+		expectedBody := `{"jsonrpc":"2.0","method":"test_method", "params":["param1","param2"]}`
 
 		builder := simpleRequestBuilder{}
 		req, err := builder.Build(ctx, "https://test.com", "test_method", []interface{}{"param1", "param2"})
@@ -36,7 +37,7 @@ func Test_simpleRequestBuilder_Build(t *testing.T) {
 		requestBytes, err := io.ReadAll(req.Body)
 		require.NoError(t, err)
 
-		require.JSONEq(t, expectedBody, string(requestBytes))
+		requireRequest(t, expectedBody, requestBytes)
 	})
 
 	t.Run("builder with invalid context", func(t *testing.T) {
@@ -47,3 +48,62 @@ func Test_simpleRequestBuilder_Build(t *testing.T) {
 		require.ErrorContains(t, err, "could not create request: net/http: nil Context")
 	})
 }
+
+func Test_simpleRequestBuilder_BuildBatch(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("builder without endpoint", func(t *testing.T) {
+		_, err := simpleRequestBuilder{}.BuildBatch(ctx, "", nil)
+		require.ErrorContains(t, err, "endpoint is required")
+	})
+
+	t.Run("builder with endpoint and requests", func(t *testing.T) {
+		requests := []Request{{JsonRPC: "2.0", Method: "a", ID: 1}, {JsonRPC: "2.0", Method: "b", ID: 2}}
+
+		req, err := simpleRequestBuilder{}.BuildBatch(ctx, "https://test.com", requests)
+		require.NoError(t, err)
+
+		require.Equal(t, "https://test.com", req.URL.String())
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		requestBytes, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"jsonrpc":"2.0","method":"a","id":1},{"jsonrpc":"2.0","method":"b","id":2}]`,
+			string(requestBytes))
+	})
+}
+
+func Test_newRequestBody(t *testing.T) {
+	t.Run("requests should have unique ids", func(t *testing.T) {
+		ids := make(map[int64]bool)
+
+		for i := 0; i < 10; i++ {
+			body, err := newRequestBody("test_method", nil)
+			require.NoError(t, err)
+
+			var request Request
+			require.NoError(t, json.NewDecoder(body).Decode(&request))
+			require.False(t, ids[request.ID], "id %d is not unique", request.ID)
+
+			ids[request.ID] = true
+		}
+	})
+}
+
+// requireRequest asserts that the request body matches the expected request, ignoring its unique id.
+func requireRequest(t *testing.T, expected string, requestBytes []byte) {
+	t.Helper()
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(requestBytes, &request))
+
+	id, ok := request["id"].(float64)
+	require.True(t, ok, "request should have a numeric id")
+	require.Positive(t, id)
+
+	delete(request, "id")
+
+	requestWithoutID, err := json.Marshal(request)
+	require.NoError(t, err)
+	require.JSONEq(t, expected, string(requestWithoutID))
+}
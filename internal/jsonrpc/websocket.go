@@ -0,0 +1,34 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// WebSocketClient is a JSON-RPC client over a WebSocket connection. On top of the calls of Client, it supports
+// the subscriptions of eth_subscribe (see Subscribe). The connection is established on the first call. When it
+// drops, the calls in flight fail with a retryable error (see IsRetryable) and the next call connects again,
+// while the active subscriptions are renewed in background on a new connection.
+type WebSocketClient struct {
+	*streamClient
+}
+
+func NewWebSocketClient(rpcEndpoint string, opts ...StreamOption) (*WebSocketClient, error) {
+	u, err := url.Parse(rpcEndpoint)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return nil, fmt.Errorf("websocket endpoint must be a ws:// or wss:// url")
+	}
+
+	c := newStreamClient(opts...)
+	c.dial = func(ctx context.Context) (messageConn, error) {
+		conn, err := dialWebSocket(ctx, rpcEndpoint, c.maxMessageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		return conn, nil
+	}
+
+	return &WebSocketClient{streamClient: c}, nil
+}
@@ -0,0 +1,365 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // required by the WebSocket handshake, see RFC 6455 section 4.2.2
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket opcodes, see RFC 6455 section 5.2.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+const (
+	webSocketGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxControlPayloadSize = 125
+	closeNormalClosure    = 1000
+	closeTimeout          = time.Second
+)
+
+var errWebSocketProtocol = errors.New("websocket protocol error")
+
+// wsConn is a WebSocket connection (RFC 6455) exchanging JSON-RPC messages. Only what JSON-RPC needs is
+// supported: text and binary messages, fragmentation and control frames, without extensions or subprotocols.
+type wsConn struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	masked         bool // frames sent by a client are masked, the ones sent by a server are not
+	maxMessageSize int64
+	writeMutex     sync.Mutex
+}
+
+// dialWebSocket connects to a ws:// or wss:// endpoint and performs the opening handshake. A server refusing
+// the upgrade with a non 101 status code results in an *HTTPError, so that rate limits can be retried.
+func dialWebSocket(ctx context.Context, endpoint string, maxMessageSize int64) (*wsConn, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse endpoint: %w", err)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), defaultPort(u.Scheme))
+	}
+
+	var conn net.Conn
+
+	switch u.Scheme {
+	case "ws":
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	case "wss":
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", address)
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not dial: %w", err)
+	}
+
+	ws, err := handshake(ctx, conn, u, maxMessageSize)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+func handshake(ctx context.Context, conn net.Conn, u *url.URL, maxMessageSize int64) (*wsConn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("could not set handshake deadline: %w", err)
+		}
+	}
+
+	key, err := newWebSocketKey()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":               {"websocket"},
+			"Connection":            {"Upgrade"},
+			"Sec-WebSocket-Key":     {key},
+			"Sec-WebSocket-Version": {"13"},
+		},
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("could not send handshake: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read handshake response: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, newHTTPError(resp)
+	}
+
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, fmt.Errorf("%w: invalid handshake response", errWebSocketProtocol)
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("could not reset handshake deadline: %w", err)
+	}
+
+	return &wsConn{conn: conn, reader: reader, masked: true, maxMessageSize: maxMessageSize}, nil
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "wss" {
+		return "443"
+	}
+
+	return "80"
+}
+
+func newWebSocketKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("could not generate websocket key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// acceptKey returns the value of the Sec-WebSocket-Accept header expected for the key of the handshake.
+func acceptKey(key string) string {
+	h := sha1.New() //nolint:gosec // see the import
+	h.Write([]byte(key + webSocketGUID))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// readMessage returns the payload of the next text or binary message, joining its fragments.
+// Pings are answered while reading, and a close frame from the peer is answered and reported as io.EOF.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+
+	started := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+
+			continue
+		case opPong:
+			continue
+		case opClose:
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+
+			_ = c.writeFrame(opClose, payload)
+
+			return nil, fmt.Errorf("websocket closed by peer: %w", io.EOF)
+		case opText, opBinary:
+			if started {
+				return nil, fmt.Errorf("%w: unexpected new message", errWebSocketProtocol)
+			}
+
+			started = true
+		case opContinuation:
+			if !started {
+				return nil, fmt.Errorf("%w: unexpected continuation frame", errWebSocketProtocol)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown opcode %d", errWebSocketProtocol, opcode)
+		}
+
+		if int64(len(message)+len(payload)) > c.maxMessageSize {
+			return nil, errMessageTooLarge
+		}
+
+		message = append(message, payload...)
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", errWebSocketProtocol)
+	}
+
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if opcode >= opClose && (length > maxControlPayloadSize || !fin) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", errWebSocketProtocol)
+	}
+
+	if length > uint64(c.maxMessageSize) {
+		return false, 0, nil, errMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeMessage sends the payload as a single text frame. The write is abandoned when the deadline of the
+// context is exceeded.
+func (c *wsConn) writeMessage(ctx context.Context, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = c.conn.SetWriteDeadline(time.Time{})
+		}()
+	}
+
+	return c.writeFrameLocked(opText, payload)
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return c.writeFrameLocked(opcode, payload)
+}
+
+func (c *wsConn) writeFrameLocked(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+
+	var maskBit byte
+	if c.masked {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	if !c.masked {
+		frame = append(frame, payload...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return fmt.Errorf("could not generate mask: %w", err)
+		}
+
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	}
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+func (c *wsConn) abort() error {
+	return c.conn.Close()
+}
+
+// close sends a close frame and closes the connection without waiting for the answer of the peer.
+func (c *wsConn) close() error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(closeTimeout))
+	_ = c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, closeNormalClosure))
+
+	return c.conn.Close()
+}
@@ -0,0 +1,107 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConnPair() (*wsConn, *wsConn) {
+	clientConn, serverConn := net.Pipe()
+
+	client := &wsConn{conn: clientConn, reader: bufio.NewReader(clientConn), masked: true, maxMessageSize: 1 << 20}
+	server := &wsConn{conn: serverConn, reader: bufio.NewReader(serverConn), maxMessageSize: 1 << 20}
+
+	return client, server
+}
+
+func TestWSConn_messages(t *testing.T) {
+	t.Run("should exchange messages of any length in both directions", func(t *testing.T) {
+		client, server := newTestConnPair()
+		defer client.conn.Close()
+		defer server.conn.Close()
+
+		for _, size := range []int{0, 125, 126, 0xffff, 0x10000} {
+			payload := bytes.Repeat([]byte{'a'}, size)
+
+			go func() {
+				_ = client.writeMessage(context.TODO(), payload)
+			}()
+
+			got, err := server.readMessage()
+			require.NoError(t, err)
+			require.Equal(t, string(payload), string(got), "client to server, size %d", size)
+
+			go func() {
+				_ = server.writeMessage(context.TODO(), payload)
+			}()
+
+			got, err = client.readMessage()
+			require.NoError(t, err)
+			require.Equal(t, string(payload), string(got), "server to client, size %d", size)
+		}
+	})
+
+	t.Run("should join fragments and answer pings", func(t *testing.T) {
+		client, server := newTestConnPair()
+		defer client.conn.Close()
+		defer server.conn.Close()
+
+		go func() {
+			// first fragment without the fin bit, then a ping, then the last fragment
+			_, _ = server.conn.Write([]byte{opText, 3, 'a', 'b', 'c'})
+			_ = server.writeFrame(opPing, []byte("ping"))
+			_ = server.writeFrame(opContinuation, []byte("def"))
+		}()
+
+		pongs := make(chan []byte, 1)
+		go func() {
+			_, opcode, payload, err := server.readFrame()
+			if err == nil && opcode == opPong {
+				pongs <- payload
+			}
+		}()
+
+		got, err := client.readMessage()
+		require.NoError(t, err)
+		require.Equal(t, "abcdef", string(got))
+		require.Equal(t, "ping", string(<-pongs))
+	})
+
+	t.Run("should report a close frame as EOF", func(t *testing.T) {
+		client, server := newTestConnPair()
+		defer client.conn.Close()
+
+		go func() {
+			_ = server.close()
+		}()
+
+		_, err := client.readMessage()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("should reject messages larger than the max size", func(t *testing.T) {
+		client, server := newTestConnPair()
+		defer client.conn.Close()
+		defer server.conn.Close()
+
+		client.maxMessageSize = 10
+
+		go func() {
+			_ = server.writeMessage(context.TODO(), bytes.Repeat([]byte{'a'}, 11))
+		}()
+
+		_, err := client.readMessage()
+		require.ErrorIs(t, err, errMessageTooLarge)
+	})
+}
+
+func Test_acceptKey(t *testing.T) {
+	// example of RFC 6455 section 1.3
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
@@ -0,0 +1,344 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+)
+
+// wsTestServer is a JSON-RPC server over WebSocket standing in for an Ethereum node. It answers eth_blockNumber,
+// eth_subscribe and eth_unsubscribe, and fails every other method with an RPC error.
+type wsTestServer struct {
+	*httptest.Server
+	conns         []*wsConn
+	subscriptions map[string]*wsConn // subscription ID -> connection
+	subscribes    int
+	unsubscribes  int
+	mutex         sync.Mutex
+}
+
+func newWSTestServer(t *testing.T) *wsTestServer {
+	s := &wsTestServer{subscriptions: make(map[string]*wsConn)}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := acceptWebSocket(w, r)
+		if err != nil {
+			t.Errorf("could not accept websocket: %v", err)
+			return
+		}
+
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+
+		s.serve(conn)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// acceptWebSocket performs the server side of the opening handshake.
+func acceptWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("not a websocket request")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", acceptKey(r.Header.Get("Sec-WebSocket-Key")))
+
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, reader: bufio.NewReader(rw), maxMessageSize: defaultMaxMessageSize}, nil
+}
+
+func (s *wsTestServer) serve(conn *wsConn) {
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			return
+		}
+
+		if message[0] == '[' {
+			var requests []Request
+			if err := json.Unmarshal(message, &requests); err != nil {
+				return
+			}
+
+			// answer in reverse order, responses are matched by ID
+			responses := make([]Response, len(requests))
+			for i, req := range requests {
+				responses[len(requests)-1-i] = s.handle(conn, req)
+			}
+
+			s.write(conn, responses)
+
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(message, &req); err != nil {
+			return
+		}
+
+		s.write(conn, s.handle(conn, req))
+	}
+}
+
+func (s *wsTestServer) handle(conn *wsConn, req Request) Response {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch req.Method {
+	case "eth_blockNumber":
+		return Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`"0x10"`)}
+	case "eth_subscribe":
+		s.subscribes++
+		id := fmt.Sprintf("0xs%d", s.subscribes)
+		s.subscriptions[id] = conn
+
+		return Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(fmt.Sprintf("%q", id))}
+	case "eth_unsubscribe":
+		s.unsubscribes++
+
+		return Response{JsonRPC: "2.0", ID: req.ID, Result: json.RawMessage(`true`)}
+	default:
+		return Response{JsonRPC: "2.0", ID: req.ID, Error: &Error{Code: -32601, Message: "method not found"}}
+	}
+}
+
+func (s *wsTestServer) write(conn *wsConn, v interface{}) {
+	message, _ := json.Marshal(v)
+	_ = conn.writeMessage(context.TODO(), message)
+}
+
+// notify sends a notification to the subscriptions of the connections that are still open.
+func (s *wsTestServer) notify(result string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, conn := range s.subscriptions {
+		_ = conn.writeMessage(context.TODO(), []byte(fmt.Sprintf(
+			`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":%q,"result":%s}}`, id, result)))
+	}
+}
+
+// dropConnections closes all the connections abruptly.
+func (s *wsTestServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.conn.Close()
+	}
+
+	s.conns = nil
+	clear(s.subscriptions)
+}
+
+func (s *wsTestServer) counts() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.subscribes, s.unsubscribes
+}
+
+func (s *wsTestServer) endpoint() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func newTestWebSocketClient(t *testing.T, endpoint string, opts ...StreamOption) *WebSocketClient {
+	opts = append([]StreamOption{
+		WithStreamLogger(&mock.Logger{}),
+		WithReconnectBackoff(10*time.Millisecond, 10*time.Millisecond),
+	}, opts...)
+
+	c, err := NewWebSocketClient(endpoint, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	return c
+}
+
+func TestNewWebSocketClient(t *testing.T) {
+	_, err := NewWebSocketClient("http://localhost:8545")
+	require.Error(t, err)
+
+	_, err = NewWebSocketClient("ws://")
+	require.Error(t, err)
+
+	c, err := NewWebSocketClient("wss://localhost:8546")
+	require.NoError(t, err)
+	require.NotNil(t, c.log)
+	require.NotNil(t, c.retryPolicy)
+}
+
+func TestWebSocketClient_Call(t *testing.T) {
+	server := newWSTestServer(t)
+	c := newTestWebSocketClient(t, server.endpoint())
+
+	t.Run("should return the result of the call", func(t *testing.T) {
+		resp, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+		require.NoError(t, err)
+		require.JSONEq(t, `"0x10"`, string(resp))
+	})
+
+	t.Run("should return the rpc error", func(t *testing.T) {
+		_, err := c.Call(context.TODO(), "eth_unknown", nil)
+
+		var rpcErr *Error
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, -32601, rpcErr.Code)
+	})
+
+	t.Run("should connect again after the connection is lost", func(t *testing.T) {
+		server.dropConnections()
+
+		require.Eventually(t, func() bool {
+			_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+				require.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("should fail after close", func(t *testing.T) {
+		require.NoError(t, c.Close())
+
+		_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+		require.ErrorIs(t, err, ErrClientClosed)
+	})
+}
+
+func TestWebSocketClient_CallBatch(t *testing.T) {
+	server := newWSTestServer(t)
+	c := newTestWebSocketClient(t, server.endpoint())
+
+	batch := []BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_unknown"}, {Method: "eth_blockNumber"}}
+	require.NoError(t, c.CallBatch(context.TODO(), batch))
+
+	require.JSONEq(t, `"0x10"`, string(batch[0].Result))
+	require.JSONEq(t, `"0x10"`, string(batch[2].Result))
+
+	var rpcErr *Error
+	require.ErrorAs(t, batch[1].Error, &rpcErr)
+}
+
+func TestWebSocketClient_Subscribe(t *testing.T) {
+	server := newWSTestServer(t)
+	c := newTestWebSocketClient(t, server.endpoint())
+
+	sub, err := c.Subscribe(context.TODO(), "newHeads")
+	require.NoError(t, err)
+
+	t.Run("should receive the notifications", func(t *testing.T) {
+		server.notify(`{"number":"0x1"}`)
+
+		select {
+		case notification := <-sub.Notifications():
+			require.JSONEq(t, `{"number":"0x1"}`, string(notification))
+		case <-time.After(time.Second):
+			t.Fatal("notification not received")
+		}
+	})
+
+	t.Run("should renew the subscription when the connection is lost", func(t *testing.T) {
+		server.dropConnections()
+
+		require.Eventually(t, func() bool {
+			subscribes, _ := server.counts()
+			return subscribes == 2 && c.isConnected()
+		}, time.Second, 10*time.Millisecond)
+
+		// The routing of the renewed subscription is set before its response is delivered.
+		require.Eventually(t, func() bool {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			return c.subscriptionIDs["0xs2"] == sub
+		}, time.Second, 10*time.Millisecond)
+
+		server.notify(`{"number":"0x2"}`)
+
+		select {
+		case notification := <-sub.Notifications():
+			require.JSONEq(t, `{"number":"0x2"}`, string(notification))
+		case <-time.After(time.Second):
+			t.Fatal("notification not received after reconnection")
+		}
+	})
+
+	t.Run("should unsubscribe and close the channel", func(t *testing.T) {
+		sub.Unsubscribe()
+
+		_, ok := <-sub.Notifications()
+		require.False(t, ok)
+
+		_, unsubscribes := server.counts()
+		require.Equal(t, 1, unsubscribes)
+
+		sub.Unsubscribe() // no-op
+	})
+
+	t.Run("should close the subscriptions on close", func(t *testing.T) {
+		sub, err := c.Subscribe(context.TODO(), "logs", map[string]interface{}{"address": "0x1"})
+		require.NoError(t, err)
+
+		require.NoError(t, c.Close())
+
+		_, ok := <-sub.Notifications()
+		require.False(t, ok)
+	})
+}
+
+func TestWebSocketClient_handshake(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := newTestWebSocketClient(t, "ws"+strings.TrimPrefix(server.URL, "http"), WithStreamRetryPolicy(noRetries{}))
+
+	_, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	require.Equal(t, time.Second, httpErr.RetryAfter)
+	require.True(t, IsRetryable(err))
+}
@@ -2,6 +2,9 @@ package mock
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ilkamo/ethparser-go/types"
 )
@@ -9,7 +12,38 @@ import (
 type EthereumClient struct {
 	MostRecentBlock uint64
 	BlockByNumber   types.Block
-	WithError       error
+	// BlocksByNumber allows to return a specific block for a given number. When the number
+	// is not present, BlockByNumber is returned.
+	BlocksByNumber map[uint64]types.Block
+	// BlockErrors allows to return an error for a specific block number.
+	BlockErrors map[uint64]error
+	// Requests, when set, records the requested block numbers.
+	Requests  *BlockRequests
+	WithError error
+}
+
+type BlockRequests struct {
+	numbers map[uint64]int
+	sync.RWMutex
+}
+
+func (b *BlockRequests) add(blockNumber uint64) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.numbers == nil {
+		b.numbers = make(map[uint64]int)
+	}
+
+	b.numbers[blockNumber]++
+}
+
+// Count returns how many times a block was requested.
+func (b *BlockRequests) Count(blockNumber uint64) int {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.numbers[blockNumber]
 }
 
 func (e EthereumClient) GetMostRecentBlockNumber(_ context.Context) (uint64, error) {
@@ -20,10 +54,196 @@ func (e EthereumClient) GetMostRecentBlockNumber(_ context.Context) (uint64, err
 	return e.MostRecentBlock, nil
 }
 
-func (e EthereumClient) GetBlockByNumber(_ context.Context, _ uint64) (types.Block, error) {
+func (e EthereumClient) GetBlockByNumber(_ context.Context, blockNumber uint64) (types.Block, error) {
+	if e.Requests != nil {
+		e.Requests.add(blockNumber)
+	}
+
 	if e.WithError != nil {
 		return types.Block{}, e.WithError
 	}
 
+	if err, ok := e.BlockErrors[blockNumber]; ok {
+		return types.Block{}, err
+	}
+
+	if block, ok := e.BlocksByNumber[blockNumber]; ok {
+		return block, nil
+	}
+
 	return e.BlockByNumber, nil
 }
+
+// BatchEthereumClient is an EthereumClient that can also fetch several blocks in one call.
+type BatchEthereumClient struct {
+	EthereumClient
+	// Batches, when set, records the size of every batch call.
+	Batches *BatchRequests
+}
+
+type BatchRequests struct {
+	sizes []int
+	sync.RWMutex
+}
+
+// Sizes returns the size of every batch call, in order.
+func (b *BatchRequests) Sizes() []int {
+	b.RLock()
+	defer b.RUnlock()
+
+	return append([]int(nil), b.sizes...)
+}
+
+func (e BatchEthereumClient) GetBlocksByNumber(
+	ctx context.Context,
+	blockNumbers []uint64,
+) (map[uint64]types.Block, error) {
+	if e.Batches != nil {
+		e.Batches.Lock()
+		e.Batches.sizes = append(e.Batches.sizes, len(blockNumbers))
+		e.Batches.Unlock()
+	}
+
+	blocks := make(map[uint64]types.Block)
+
+	var errs []error
+	for _, blockNumber := range blockNumbers {
+		block, err := e.GetBlockByNumber(ctx, blockNumber)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		blocks[blockNumber] = block
+	}
+
+	return blocks, errors.Join(errs...)
+}
+
+// ReceiptsEthereumClient is an EthereumClient that can also fetch the receipts of transactions.
+// Transactions without a receipt in Receipts get a successful one.
+type ReceiptsEthereumClient struct {
+	EthereumClient
+	Receipts      map[string]types.Receipt
+	ReceiptsError error
+	// ReceiptRequests, when set, records the hashes of the transactions whose receipts are requested.
+	ReceiptRequests *ReceiptRequests
+}
+
+type ReceiptRequests struct {
+	hashes []string
+	sync.RWMutex
+}
+
+// Hashes returns the hashes of the transactions whose receipts were requested, in order.
+func (r *ReceiptRequests) Hashes() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	return append([]string(nil), r.hashes...)
+}
+
+func (e ReceiptsEthereumClient) GetTransactionReceipts(
+	_ context.Context,
+	_ string,
+	txHashes []string,
+) (map[string]types.Receipt, error) {
+	if e.ReceiptRequests != nil {
+		e.ReceiptRequests.Lock()
+		e.ReceiptRequests.hashes = append(e.ReceiptRequests.hashes, txHashes...)
+		e.ReceiptRequests.Unlock()
+	}
+
+	if e.ReceiptsError != nil {
+		return nil, e.ReceiptsError
+	}
+
+	receipts := make(map[string]types.Receipt, len(txHashes))
+	for _, txHash := range txHashes {
+		receipt, ok := e.Receipts[txHash]
+		if !ok {
+			receipt = types.Receipt{TransactionHash: txHash, Status: types.ReceiptStatusSuccessful}
+		}
+
+		receipts[txHash] = receipt
+	}
+
+	return receipts, nil
+}
+
+// TokenTransfersEthereumClient is an EthereumClient that can also fetch the token transfers of a block.
+type TokenTransfersEthereumClient struct {
+	EthereumClient
+	// TokenTransfers are the token transfers by block hash.
+	TokenTransfers      map[string][]types.TokenTransfer
+	TokenTransfersError error
+}
+
+func (e TokenTransfersEthereumClient) GetTokenTransfers(
+	_ context.Context,
+	blockHash string,
+) ([]types.TokenTransfer, error) {
+	if e.TokenTransfersError != nil {
+		return nil, e.TokenTransfersError
+	}
+
+	return e.TokenTransfers[blockHash], nil
+}
+
+// TracesEthereumClient is an EthereumClient that can also trace the internal transfers of a block.
+type TracesEthereumClient struct {
+	EthereumClient
+	// InternalTransfers are the internal transfers by block hash.
+	InternalTransfers map[string][]types.Transaction
+	TracesError       error
+}
+
+func (e TracesEthereumClient) GetInternalTransfers(_ context.Context, block types.Block) ([]types.Transaction, error) {
+	if e.TracesError != nil {
+		return nil, e.TracesError
+	}
+
+	return e.InternalTransfers[block.Hash], nil
+}
+
+// HeadsEthereumClient is an EthereumClient notifying the heads sent by the tests on Heads.
+// The most recent block is the last head sent.
+type HeadsEthereumClient struct {
+	EthereumClient
+	Heads          chan uint64
+	Head           *atomic.Uint64
+	SubscribeError error
+}
+
+func (e HeadsEthereumClient) GetMostRecentBlockNumber(_ context.Context) (uint64, error) {
+	return e.Head.Load(), nil
+}
+
+func (e HeadsEthereumClient) SubscribeNewHeads(ctx context.Context) (<-chan uint64, error) {
+	if e.SubscribeError != nil {
+		return nil, e.SubscribeError
+	}
+
+	heads := make(chan uint64)
+
+	go func() {
+		defer close(heads)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case head := <-e.Heads:
+				e.Head.Store(head)
+
+				select {
+				case heads <- head:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return heads, nil
+}
@@ -2,7 +2,6 @@ package mock
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -28,20 +27,3 @@ func (h *HTTPClient) Do(req *http.Request) (*http.Response, error) {
 		StatusCode: 200,
 	}, nil
 }
-
-type HTTPRequestBuilder struct {
-	ShouldError bool
-}
-
-func (h *HTTPRequestBuilder) Build(
-	_ context.Context,
-	_ string,
-	_ string,
-	_ interface{},
-) (*http.Request, error) {
-	if h.ShouldError {
-		return nil, errors.New("test error")
-	}
-
-	return &http.Request{}, nil
-}
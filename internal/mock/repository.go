@@ -7,8 +7,9 @@ import (
 )
 
 type TransactionsRepository struct {
-	GetError  error
-	SaveError error
+	GetError    error
+	SaveError   error
+	RemoveError error
 }
 
 func (t TransactionsRepository) GetTransactions(_ context.Context, _ string) ([]types.Transaction, error) {
@@ -43,11 +44,35 @@ func (t TransactionsRepository) SaveLastProcessedBlock(_ context.Context, _ uint
 	return nil
 }
 
+func (t TransactionsRepository) RemoveTransactionsByBlockHash(_ context.Context, _ string) error {
+	if t.RemoveError != nil {
+		return t.RemoveError
+	}
+
+	return nil
+}
+
+func (t TransactionsRepository) RemoveTransactionsByAddress(_ context.Context, _ string) error {
+	if t.RemoveError != nil {
+		return t.RemoveError
+	}
+
+	return nil
+}
+
 type AddressesRepository struct {
 	WantError error
 }
 
-func (o AddressesRepository) ObserveAddress(_ context.Context, _ string) error {
+func (o AddressesRepository) ObserveAddress(_ context.Context, _ types.Subscription) error {
+	if o.WantError != nil {
+		return o.WantError
+	}
+
+	return nil
+}
+
+func (o AddressesRepository) UnobserveAddress(_ context.Context, _ string) error {
 	if o.WantError != nil {
 		return o.WantError
 	}
@@ -62,3 +87,19 @@ func (o AddressesRepository) IsAddressObserved(_ context.Context, _ string) (boo
 
 	return false, nil
 }
+
+func (o AddressesRepository) GetObservedAddress(_ context.Context, address string) (types.Subscription, error) {
+	if o.WantError != nil {
+		return types.Subscription{}, o.WantError
+	}
+
+	return types.Subscription{Address: address}, nil
+}
+
+func (o AddressesRepository) ListObservedAddresses(_ context.Context, _, _ int) ([]types.Subscription, error) {
+	if o.WantError != nil {
+		return nil, o.WantError
+	}
+
+	return nil, nil
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+
+	"github.com/ilkamo/ethparser-go/types"
 )
 
 type RPCClient struct {
 	ShouldError bool
 	Response    json.RawMessage
+	// BatchResults are set, in order, to the elements of a batch call.
+	BatchResults []types.BatchElem
 }
 
 func (r RPCClient) Call(_ context.Context, _ string, _ interface{}) (json.RawMessage, error) {
@@ -18,3 +22,54 @@ func (r RPCClient) Call(_ context.Context, _ string, _ interface{}) (json.RawMes
 
 	return r.Response, nil
 }
+
+func (r RPCClient) CallBatch(_ context.Context, batch []types.BatchElem) error {
+	if r.ShouldError {
+		return errors.New("test error")
+	}
+
+	for i := range batch {
+		if i < len(r.BatchResults) {
+			batch[i].Result = r.BatchResults[i].Result
+			batch[i].Error = r.BatchResults[i].Error
+		}
+	}
+
+	return nil
+}
+
+// SubscriptionRPCClient is an RPCClient supporting subscriptions. All the subscriptions receive the
+// notifications sent by the tests on Notifications.
+type SubscriptionRPCClient struct {
+	RPCClient
+	Notifications  chan json.RawMessage
+	SubscribeError error
+	// Namespaces, when set, records the namespace of every subscription.
+	Namespaces *[]string
+}
+
+func (r SubscriptionRPCClient) Subscribe(
+	_ context.Context,
+	namespace string,
+	_ ...interface{},
+) (types.RPCSubscription, error) {
+	if r.SubscribeError != nil {
+		return nil, r.SubscribeError
+	}
+
+	if r.Namespaces != nil {
+		*r.Namespaces = append(*r.Namespaces, namespace)
+	}
+
+	return Subscription{notifications: r.Notifications}, nil
+}
+
+type Subscription struct {
+	notifications chan json.RawMessage
+}
+
+func (s Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifications
+}
+
+func (s Subscription) Unsubscribe() {}
@@ -0,0 +1,52 @@
+package rpcpool
+
+import "time"
+
+// circuitBreaker stops sending calls to an endpoint after too many consecutive failures. Once the cooldown
+// is over, a single trial call is let through: the breaker closes if it succeeds, opens again otherwise.
+type circuitBreaker struct {
+	threshold     int
+	cooldown      time.Duration
+	failures      int
+	openUntil     time.Time
+	trialInFlight bool
+}
+
+func (b *circuitBreaker) isOpen() bool {
+	return b.failures >= b.threshold
+}
+
+// allow tells if a call can be sent to the endpoint, reserving the trial call of an open breaker.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	if !b.isOpen() {
+		return true
+	}
+
+	if now.Before(b.openUntil) || b.trialInFlight {
+		return false
+	}
+
+	b.trialInFlight = true
+
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.failures = 0
+	b.trialInFlight = false
+}
+
+// cancelTrial releases the trial call of an open breaker without counting it: the caller gave up, so the call
+// tells nothing about the endpoint and the next one can be the trial.
+func (b *circuitBreaker) cancelTrial() {
+	b.trialInFlight = false
+}
+
+func (b *circuitBreaker) failure(now time.Time) {
+	b.failures++
+	b.trialInFlight = false
+
+	if b.isOpen() {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
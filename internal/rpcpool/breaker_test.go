@@ -0,0 +1,49 @@
+package rpcpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should open after threshold consecutive failures", func(t *testing.T) {
+		b := circuitBreaker{threshold: 2, cooldown: time.Minute}
+
+		b.failure(now)
+		require.False(t, b.isOpen())
+		require.True(t, b.allow(now))
+
+		b.success()
+		b.failure(now)
+		require.False(t, b.isOpen(), "a success should reset the failures")
+
+		b.failure(now)
+		require.True(t, b.isOpen())
+		require.False(t, b.allow(now))
+	})
+
+	t.Run("should let a single trial call through after the cooldown", func(t *testing.T) {
+		b := circuitBreaker{threshold: 1, cooldown: time.Minute}
+		b.failure(now)
+
+		require.False(t, b.allow(now.Add(time.Second)))
+		require.True(t, b.allow(now.Add(time.Minute)))
+		require.False(t, b.allow(now.Add(time.Minute)), "only one trial call should be in flight")
+
+		b.failure(now.Add(time.Minute))
+		require.True(t, b.isOpen())
+		require.False(t, b.allow(now.Add(time.Minute+time.Second)), "a failed trial should restart the cooldown")
+
+		require.True(t, b.allow(now.Add(2*time.Minute)))
+		b.cancelTrial()
+		require.True(t, b.isOpen(), "a canceled trial should not be counted")
+		require.True(t, b.allow(now.Add(2*time.Minute)), "a canceled trial should release the trial call")
+		b.success()
+		require.False(t, b.isOpen())
+		require.True(t, b.allow(now.Add(2*time.Minute)))
+	})
+}
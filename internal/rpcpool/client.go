@@ -0,0 +1,436 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	defaultStrategy            = StrategyPrimaryFallback
+	defaultFailureThreshold    = 3
+	defaultCooldown            = 30 * time.Second
+	defaultMaxBlockLag         = 5
+	defaultHealthCheckInterval = 15 * time.Second
+	healthCheckTimeout         = 5 * time.Second
+	latencySmoothing           = 0.2 // weight of a new sample in the moving average of latencies
+)
+
+// ErrNoEndpointAvailable is returned when the circuit breakers of all the endpoints are open.
+var ErrNoEndpointAvailable = errors.New("no rpc endpoint available")
+
+// Strategy decides the order in which the endpoints are tried.
+type Strategy string
+
+const (
+	// StrategyPrimaryFallback always tries the endpoints in the configured order.
+	StrategyPrimaryFallback Strategy = "primary-fallback"
+	// StrategyRoundRobin spreads the calls over the endpoints in turn.
+	StrategyRoundRobin Strategy = "round-robin"
+	// StrategyLowestLatency tries the endpoint with the lowest average latency first.
+	StrategyLowestLatency Strategy = "lowest-latency"
+)
+
+// ParseStrategy returns the strategy with the given name.
+func ParseStrategy(name string) (Strategy, error) {
+	switch strategy := Strategy(name); strategy {
+	case StrategyPrimaryFallback, StrategyRoundRobin, StrategyLowestLatency:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown rpc strategy %q", name)
+	}
+}
+
+// RPCClient is the client of a single endpoint, implemented by jsonrpc.Client.
+type RPCClient interface {
+	Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+	CallBatch(ctx context.Context, batch []types.BatchElem) error
+}
+
+// Endpoint is a named RPC endpoint of the pool.
+type Endpoint struct {
+	Name   string
+	Client RPCClient
+}
+
+// EndpointStatus is the health of an endpoint as seen by the pool.
+type EndpointStatus struct {
+	Name string
+	// Available is false while the circuit breaker of the endpoint is open.
+	Available bool
+	// Lagging is true when the endpoint is behind the others by more than the max block lag.
+	Lagging bool
+	// Head is the most recent block reported by the last health check.
+	Head uint64
+	// Latency is the moving average of the latencies of the calls.
+	Latency time.Duration
+}
+
+type endpoint struct {
+	Endpoint
+	breaker circuitBreaker
+	head    uint64
+	lagging bool
+	latency time.Duration
+}
+
+// Client is an RPC client spreading the calls over several endpoints according to a strategy. Calls that fail
+// because of the endpoint (network errors, rate limits, server errors, see jsonrpc.IsRetryable) are sent to the
+// next endpoint, while the other errors are returned as they are. Endpoints failing repeatedly are skipped by
+// their circuit breaker, and endpoints lagging behind the others are only used when no other is available.
+// It satisfies ethereum.RPCClient, so it can be used with ethereum.WithRPCClient.
+type Client struct {
+	endpoints           []*endpoint
+	strategy            Strategy
+	log                 types.Logger
+	failureThreshold    int
+	cooldown            time.Duration
+	maxBlockLag         uint64
+	healthCheckInterval time.Duration
+	next                atomic.Uint64 // next endpoint of the round-robin strategy
+	lastHealthCheck     time.Time
+	checkingHealth      atomic.Bool
+	mutex               sync.Mutex
+}
+
+func New(endpoints []Endpoint, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("at least one endpoint is required")
+	}
+
+	c := &Client{
+		strategy:            defaultStrategy,
+		failureThreshold:    defaultFailureThreshold,
+		cooldown:            defaultCooldown,
+		maxBlockLag:         defaultMaxBlockLag,
+		healthCheckInterval: defaultHealthCheckInterval,
+		lastHealthCheck:     time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if _, err := ParseStrategy(string(c.strategy)); err != nil {
+		return nil, err
+	}
+
+	if c.failureThreshold <= 0 {
+		c.failureThreshold = defaultFailureThreshold
+	}
+
+	if c.log == nil {
+		// use default logger when not provided
+		c.log = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+
+	for _, e := range endpoints {
+		c.endpoints = append(c.endpoints, &endpoint{
+			Endpoint: e,
+			breaker:  circuitBreaker{threshold: c.failureThreshold, cooldown: c.cooldown},
+		})
+	}
+
+	return c, nil
+}
+
+// NewFromURLs creates a pool with an RPC client per URL, over the transport of the URL: WebSocket for ws:// and
+// wss:// urls, IPC for filesystem paths like /data/geth.ipc, HTTP otherwise. The jsonrpc options only apply to
+// the HTTP clients, which do not retry failed calls by default, since the pool sends them to the next endpoint
+// instead. The endpoints are named after their redacted URLs (see jsonrpc.RedactURL), as the names are logged,
+// and after their paths for IPC.
+func NewFromURLs(urls []string, jsonrpcOpts []jsonrpc.Option, opts ...Option) (*Client, error) {
+	endpoints := make([]Endpoint, 0, len(urls))
+
+	for _, url := range urls {
+		rpcClient, err := newRPCClient(url, jsonrpcOpts)
+		if err != nil {
+			return nil, fmt.Errorf("could not create rpc client: %w", err)
+		}
+
+		name := jsonrpc.RedactURL(url)
+		if isIPCEndpoint(url) {
+			name = url
+		}
+
+		endpoints = append(endpoints, Endpoint{Name: name, Client: rpcClient})
+	}
+
+	return New(endpoints, opts...)
+}
+
+// newRPCClient creates the RPC client of the transport of the url (see NewFromURLs).
+func newRPCClient(url string, jsonrpcOpts []jsonrpc.Option) (RPCClient, error) {
+	switch {
+	case strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://"):
+		return jsonrpc.NewWebSocketClient(url)
+	case isIPCEndpoint(url):
+		return jsonrpc.NewIPCClient(url)
+	default:
+		return jsonrpc.NewClient(url, append([]jsonrpc.Option{jsonrpc.WithoutRetries()}, jsonrpcOpts...)...)
+	}
+}
+
+// isIPCEndpoint reports whether the endpoint is a filesystem path rather than a url.
+func isIPCEndpoint(endpoint string) bool {
+	return endpoint != "" && !strings.Contains(endpoint, "://")
+}
+
+// Call sends an RPC request to the endpoints, in the order of the strategy, until one of them answers.
+func (c *Client) Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	var result json.RawMessage
+
+	err := c.do(ctx, func(e *endpoint) error {
+		var err error
+		result, err = e.Client.Call(ctx, method, params)
+
+		return err
+	})
+
+	return result, err
+}
+
+// CallBatch sends a batch to the endpoints, in the order of the strategy, until one of them answers.
+// Errors of single elements of the batch do not cause a failover.
+func (c *Client) CallBatch(ctx context.Context, batch []types.BatchElem) error {
+	return c.do(ctx, func(e *endpoint) error {
+		return e.Client.CallBatch(ctx, batch)
+	})
+}
+
+// Status returns the health of the endpoints, in the configured order.
+func (c *Client) Status() []EndpointStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	status := make([]EndpointStatus, len(c.endpoints))
+	for i, e := range c.endpoints {
+		status[i] = EndpointStatus{
+			Name:      e.Name,
+			Available: !e.breaker.isOpen(),
+			Lagging:   e.lagging,
+			Head:      e.head,
+			Latency:   e.latency,
+		}
+	}
+
+	return status
+}
+
+func (c *Client) do(ctx context.Context, call func(e *endpoint) error) error {
+	c.scheduleHealthCheck()
+
+	var errs []error
+
+	for _, e := range c.candidates() {
+		if !c.allow(e) {
+			continue
+		}
+
+		start := time.Now()
+		err := call(e)
+		latency := time.Since(start)
+
+		if errors.Is(err, context.Canceled) {
+			// The caller gave up: it tells nothing about the health of the endpoint.
+			c.recordCanceled(e)
+			return err
+		}
+
+		// An endpoint that does not answer before the deadline is failing, even though the error is not retryable.
+		if err == nil || (!jsonrpc.IsRetryable(err) && !errors.Is(err, context.DeadlineExceeded)) {
+			// The endpoint answered: errors that are not caused by the endpoint are not sent to the others.
+			c.recordSuccess(e, latency)
+			return err
+		}
+
+		c.recordFailure(e, err)
+		errs = append(errs, fmt.Errorf("endpoint %s: %w", e.Name, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return ErrNoEndpointAvailable
+	}
+
+	return errors.Join(errs...)
+}
+
+// candidates returns the endpoints in the order they should be tried: the ones that are not lagging
+// ordered by the strategy, then the lagging ones as a last resort.
+func (c *Client) candidates() []*endpoint {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ordered := make([]*endpoint, len(c.endpoints))
+	copy(ordered, c.endpoints)
+
+	switch c.strategy {
+	case StrategyRoundRobin:
+		shift := int(c.next.Add(1)-1) % len(ordered)
+		ordered = append(ordered[shift:], ordered[:shift]...)
+	case StrategyLowestLatency:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].latency < ordered[j].latency
+		})
+	case StrategyPrimaryFallback:
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !ordered[i].lagging && ordered[j].lagging
+	})
+
+	return ordered
+}
+
+func (c *Client) allow(e *endpoint) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return e.breaker.allow(time.Now())
+}
+
+func (c *Client) recordSuccess(e *endpoint, latency time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e.breaker.success()
+
+	if e.latency == 0 {
+		e.latency = latency
+		return
+	}
+
+	e.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(e.latency))
+}
+
+func (c *Client) recordCanceled(e *endpoint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e.breaker.cancelTrial()
+}
+
+func (c *Client) recordFailure(e *endpoint, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e.breaker.failure(time.Now())
+
+	if e.breaker.isOpen() {
+		c.log.Error("rpc endpoint unavailable", "endpoint", e.Name, "error", err)
+	}
+}
+
+// scheduleHealthCheck starts a health check in background when the interval since the last one is over.
+func (c *Client) scheduleHealthCheck() {
+	if c.maxBlockLag == 0 || len(c.endpoints) < 2 {
+		return
+	}
+
+	c.mutex.Lock()
+	due := time.Since(c.lastHealthCheck) >= c.healthCheckInterval
+	c.mutex.Unlock()
+
+	if !due || !c.checkingHealth.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer c.checkingHealth.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+
+		c.checkHealth(ctx)
+	}()
+}
+
+// checkHealth gets the most recent block of every endpoint and marks as lagging the endpoints that are
+// behind the most recent one by more than the max block lag.
+func (c *Client) checkHealth(ctx context.Context) {
+	heads := make([]uint64, len(c.endpoints))
+	errs := make([]error, len(c.endpoints))
+	latencies := make([]time.Duration, len(c.endpoints))
+
+	wg := sync.WaitGroup{}
+	for i, e := range c.endpoints {
+		wg.Add(1)
+
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+
+			start := time.Now()
+			heads[i], errs[i] = getHead(ctx, e.Client)
+			latencies[i] = time.Since(start)
+		}(i, e)
+	}
+	wg.Wait()
+
+	for i, e := range c.endpoints {
+		if errs[i] != nil {
+			c.recordFailure(e, errs[i])
+			continue
+		}
+
+		c.recordSuccess(e, latencies[i])
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lastHealthCheck = time.Now()
+
+	var mostRecentBlock uint64
+	for i, e := range c.endpoints {
+		if errs[i] == nil {
+			e.head = heads[i]
+		}
+
+		mostRecentBlock = max(mostRecentBlock, e.head)
+	}
+
+	for _, e := range c.endpoints {
+		lagging := mostRecentBlock-e.head > c.maxBlockLag
+		if lagging != e.lagging {
+			c.log.Info("rpc endpoint lag changed",
+				"endpoint", e.Name, "lagging", lagging, "head", e.head, "mostRecentBlock", mostRecentBlock)
+		}
+
+		e.lagging = lagging
+	}
+}
+
+func getHead(ctx context.Context, rpcClient RPCClient) (uint64, error) {
+	resp, err := rpcClient.Call(ctx, "eth_blockNumber", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var blockNumber string
+	if err := json.Unmarshal(resp, &blockNumber); err != nil {
+		return 0, fmt.Errorf("could not unmarshal block number: %w", err)
+	}
+
+	head, err := strconv.ParseUint(strings.TrimPrefix(blockNumber, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse block number: %w", err)
+	}
+
+	return head, nil
+}
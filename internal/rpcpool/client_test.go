@@ -0,0 +1,332 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/jsonrpc"
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+var errUnavailable = &jsonrpc.HTTPError{StatusCode: http.StatusServiceUnavailable}
+
+// fakeEndpoint answers every call with its name, or with its error when set.
+type fakeEndpoint struct {
+	name  string
+	head  uint64
+	err   error
+	calls int
+	mutex sync.Mutex
+}
+
+func (f *fakeEndpoint) Call(_ context.Context, method string, _ interface{}) (json.RawMessage, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.calls++
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if method == "eth_blockNumber" {
+		return json.RawMessage(fmt.Sprintf(`"0x%x"`, f.head)), nil
+	}
+
+	return json.RawMessage(fmt.Sprintf(`%q`, f.name)), nil
+}
+
+func (f *fakeEndpoint) CallBatch(_ context.Context, batch []types.BatchElem) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.calls++
+
+	if f.err != nil {
+		return f.err
+	}
+
+	for i := range batch {
+		batch[i].Result = json.RawMessage(fmt.Sprintf(`%q`, f.name))
+	}
+
+	return nil
+}
+
+func (f *fakeEndpoint) setError(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.err = err
+}
+
+func (f *fakeEndpoint) getCalls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.calls
+}
+
+func newTestPool(t *testing.T, fakes []*fakeEndpoint, opts ...Option) *Client {
+	endpoints := make([]Endpoint, len(fakes))
+	for i, f := range fakes {
+		endpoints[i] = Endpoint{Name: f.name, Client: f}
+	}
+
+	opts = append([]Option{WithLogger(&mock.Logger{}), WithHealthCheckInterval(time.Hour)}, opts...)
+
+	c, err := New(endpoints, opts...)
+	require.NoError(t, err)
+
+	return c
+}
+
+func callName(t *testing.T, c *Client) string {
+	resp, err := c.Call(context.TODO(), "eth_chainId", nil)
+	require.NoError(t, err)
+
+	var name string
+	require.NoError(t, json.Unmarshal(resp, &name))
+
+	return name
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	require.Error(t, err)
+
+	_, err = NewFromURLs([]string{"http://a:8545", ""}, nil)
+	require.Error(t, err)
+
+	c, err := NewFromURLs([]string{"http://a:8545", "http://b:8545"}, nil, WithLogger(&mock.Logger{}))
+	require.NoError(t, err)
+	require.Len(t, c.Status(), 2)
+	require.Equal(t, "http://a:8545", c.Status()[0].Name)
+
+	c, err = NewFromURLs([]string{"https://user:pass@a/v2/key"}, nil, WithLogger(&mock.Logger{}))
+	require.NoError(t, err)
+	require.Equal(t, "https://a/[redacted]", c.Status()[0].Name, "secrets should not be part of the name")
+
+	c, err = NewFromURLs([]string{"http://a:8545", "wss://b/ws", "/data/geth.ipc"}, nil, WithLogger(&mock.Logger{}))
+	require.NoError(t, err)
+	require.IsType(t, jsonrpc.Client{}, c.endpoints[0].Client)
+	require.IsType(t, &jsonrpc.WebSocketClient{}, c.endpoints[1].Client)
+	require.IsType(t, &jsonrpc.IPCClient{}, c.endpoints[2].Client)
+	require.Equal(t, "/data/geth.ipc", c.Status()[2].Name)
+}
+
+func TestClient_Call(t *testing.T) {
+	t.Run("primary fallback should use the first endpoint while it works", func(t *testing.T) {
+		primary, fallback := &fakeEndpoint{name: "primary"}, &fakeEndpoint{name: "fallback"}
+		c := newTestPool(t, []*fakeEndpoint{primary, fallback})
+
+		require.Equal(t, "primary", callName(t, c))
+		require.Equal(t, "primary", callName(t, c))
+		require.Equal(t, 0, fallback.getCalls())
+
+		primary.setError(errUnavailable)
+		require.Equal(t, "fallback", callName(t, c))
+
+		primary.setError(nil)
+		require.Equal(t, "primary", callName(t, c))
+	})
+
+	t.Run("round robin should spread the calls", func(t *testing.T) {
+		a, b, cc := &fakeEndpoint{name: "a"}, &fakeEndpoint{name: "b"}, &fakeEndpoint{name: "c"}
+		c := newTestPool(t, []*fakeEndpoint{a, b, cc}, WithStrategy(StrategyRoundRobin))
+
+		var names []string
+		for i := 0; i < 6; i++ {
+			names = append(names, callName(t, c))
+		}
+
+		require.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, names)
+	})
+
+	t.Run("lowest latency should prefer the fastest endpoint", func(t *testing.T) {
+		slow, fast := &fakeEndpoint{name: "slow"}, &fakeEndpoint{name: "fast"}
+		c := newTestPool(t, []*fakeEndpoint{slow, fast}, WithStrategy(StrategyLowestLatency))
+
+		c.recordSuccess(c.endpoints[0], 100*time.Millisecond)
+		c.recordSuccess(c.endpoints[1], 10*time.Millisecond)
+
+		require.Equal(t, "fast", callName(t, c))
+	})
+
+	t.Run("should not fail over on errors not caused by the endpoint", func(t *testing.T) {
+		invalidParams := &jsonrpc.Error{Code: -32602, Message: "invalid params"}
+		primary, fallback := &fakeEndpoint{name: "primary", err: invalidParams}, &fakeEndpoint{name: "fallback"}
+		c := newTestPool(t, []*fakeEndpoint{primary, fallback})
+
+		_, err := c.Call(context.TODO(), "eth_getBlockByNumber", nil)
+		require.ErrorIs(t, err, invalidParams)
+		require.Equal(t, 0, fallback.getCalls())
+		require.True(t, c.Status()[0].Available)
+	})
+
+	t.Run("should not record anything when the call is canceled", func(t *testing.T) {
+		primary, fallback := &fakeEndpoint{name: "primary", err: context.Canceled}, &fakeEndpoint{name: "fallback"}
+		c := newTestPool(t, []*fakeEndpoint{primary, fallback}, WithCircuitBreaker(1, time.Minute))
+
+		_, err := c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 0, fallback.getCalls())
+		require.True(t, c.Status()[0].Available)
+		require.Zero(t, c.Status()[0].Latency)
+	})
+
+	t.Run("should release the trial call when it is canceled", func(t *testing.T) {
+		primary := &fakeEndpoint{name: "primary", err: errUnavailable}
+		c := newTestPool(t, []*fakeEndpoint{primary}, WithCircuitBreaker(1, time.Millisecond))
+
+		_, err := c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, errUnavailable)
+		require.False(t, c.Status()[0].Available)
+
+		time.Sleep(2 * time.Millisecond)
+		primary.setError(context.Canceled)
+
+		_, err = c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, context.Canceled)
+
+		primary.setError(nil)
+		require.Equal(t, "primary", callName(t, c), "the next call should be the trial")
+		require.True(t, c.Status()[0].Available)
+	})
+
+	t.Run("should count a deadline exceeded as a failure of the endpoint", func(t *testing.T) {
+		primary, fallback := &fakeEndpoint{name: "primary", err: context.DeadlineExceeded}, &fakeEndpoint{name: "fallback"}
+		c := newTestPool(t, []*fakeEndpoint{primary, fallback}, WithCircuitBreaker(1, time.Minute))
+
+		require.Equal(t, "fallback", callName(t, c))
+		require.False(t, c.Status()[0].Available)
+		require.Zero(t, c.Status()[0].Latency)
+	})
+
+	t.Run("should return the errors of all the endpoints", func(t *testing.T) {
+		a, b := &fakeEndpoint{name: "a", err: errUnavailable}, &fakeEndpoint{name: "b", err: errUnavailable}
+		c := newTestPool(t, []*fakeEndpoint{a, b})
+
+		_, err := c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, errUnavailable)
+		require.ErrorContains(t, err, "endpoint a")
+		require.ErrorContains(t, err, "endpoint b")
+	})
+}
+
+func TestClient_CallBatch(t *testing.T) {
+	primary, fallback := &fakeEndpoint{name: "primary", err: errUnavailable}, &fakeEndpoint{name: "fallback"}
+	c := newTestPool(t, []*fakeEndpoint{primary, fallback})
+
+	batch := []types.BatchElem{{Method: "eth_getBlockByNumber"}, {Method: "eth_getBlockByNumber"}}
+	require.NoError(t, c.CallBatch(context.TODO(), batch))
+
+	for _, elem := range batch {
+		require.JSONEq(t, `"fallback"`, string(elem.Result))
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	primary, fallback := &fakeEndpoint{name: "primary", err: errUnavailable}, &fakeEndpoint{name: "fallback"}
+	c := newTestPool(t, []*fakeEndpoint{primary, fallback}, WithCircuitBreaker(2, 50*time.Millisecond))
+
+	require.Equal(t, "fallback", callName(t, c))
+	require.Equal(t, "fallback", callName(t, c))
+	require.False(t, c.Status()[0].Available)
+
+	// The primary is skipped while its breaker is open.
+	require.Equal(t, "fallback", callName(t, c))
+	require.Equal(t, 2, primary.getCalls())
+
+	// After the cooldown a trial call closes the breaker.
+	primary.setError(nil)
+	time.Sleep(60 * time.Millisecond)
+
+	require.Equal(t, "primary", callName(t, c))
+	require.True(t, c.Status()[0].Available)
+
+	t.Run("should return error when all the breakers are open", func(t *testing.T) {
+		only := &fakeEndpoint{name: "only", err: errUnavailable}
+		c := newTestPool(t, []*fakeEndpoint{only}, WithCircuitBreaker(1, time.Minute))
+
+		_, err := c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, errUnavailable)
+
+		_, err = c.Call(context.TODO(), "eth_chainId", nil)
+		require.ErrorIs(t, err, ErrNoEndpointAvailable)
+	})
+}
+
+func TestClient_checkHealth(t *testing.T) {
+	primary, fallback := &fakeEndpoint{name: "primary", head: 100}, &fakeEndpoint{name: "fallback", head: 110}
+	c := newTestPool(t, []*fakeEndpoint{primary, fallback}, WithMaxBlockLag(5))
+
+	c.checkHealth(context.TODO())
+
+	status := c.Status()
+	require.True(t, status[0].Lagging)
+	require.Equal(t, uint64(100), status[0].Head)
+	require.False(t, status[1].Lagging)
+	require.Equal(t, uint64(110), status[1].Head)
+
+	// The lagging primary is only used when no other endpoint is available.
+	require.Equal(t, "fallback", callName(t, c))
+
+	fallback.setError(errUnavailable)
+	require.Equal(t, "primary", callName(t, c))
+
+	// The primary catches up.
+	fallback.setError(nil)
+	primary.head = 108
+	c.checkHealth(context.TODO())
+	require.False(t, c.Status()[0].Lagging)
+	require.Equal(t, "primary", callName(t, c))
+
+	t.Run("should check the health in background when the interval is over", func(t *testing.T) {
+		primary, fallback := &fakeEndpoint{name: "primary", head: 1}, &fakeEndpoint{name: "fallback", head: 10}
+		c := newTestPool(t, []*fakeEndpoint{primary, fallback}, WithHealthCheckInterval(time.Millisecond))
+
+		time.Sleep(2 * time.Millisecond)
+		callName(t, c)
+
+		require.Eventually(t, func() bool {
+			return c.Status()[0].Lagging
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestClient_withJSONRPCClients(t *testing.T) {
+	var downCalls atomic.Int64
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		downCalls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x10"}`, req.ID)
+	}))
+	defer up.Close()
+
+	c, err := NewFromURLs([]string{down.URL, up.URL}, []jsonrpc.Option{jsonrpc.WithLogger(&mock.Logger{})},
+		WithLogger(&mock.Logger{}))
+	require.NoError(t, err)
+
+	resp, err := c.Call(context.TODO(), "eth_blockNumber", nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `"0x10"`, string(resp))
+	require.Equal(t, int64(1), downCalls.Load(), "the pool should fail over instead of retrying the same endpoint")
+}
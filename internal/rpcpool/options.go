@@ -0,0 +1,46 @@
+package rpcpool
+
+import (
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+type Option func(c *Client)
+
+func WithStrategy(strategy Strategy) Option {
+	return func(c *Client) {
+		c.strategy = strategy
+	}
+}
+
+func WithLogger(logger types.Logger) Option {
+	return func(c *Client) {
+		c.log = logger
+	}
+}
+
+// WithCircuitBreaker sets after how many consecutive failures an endpoint stops receiving calls,
+// and for how long before a trial call is sent to it again. A threshold lower than one is ignored.
+func WithCircuitBreaker(failureThreshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.failureThreshold = failureThreshold
+		c.cooldown = cooldown
+	}
+}
+
+// WithMaxBlockLag sets how many blocks an endpoint can be behind the most recent block reported by the other
+// endpoints before being ejected. Lag detection is disabled when zero.
+func WithMaxBlockLag(blocks uint64) Option {
+	return func(c *Client) {
+		c.maxBlockLag = blocks
+	}
+}
+
+// WithHealthCheckInterval sets how often the most recent block of every endpoint is checked to detect lagging
+// endpoints and measure latencies.
+func WithHealthCheckInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.healthCheckInterval = interval
+	}
+}
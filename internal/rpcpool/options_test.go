@@ -0,0 +1,62 @@
+package rpcpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+)
+
+func TestWithStrategy(t *testing.T) {
+	c, err := New(testEndpoints(), WithStrategy(StrategyRoundRobin))
+	require.NoError(t, err)
+	require.Equal(t, StrategyRoundRobin, c.strategy)
+
+	_, err = New(testEndpoints(), WithStrategy("random"))
+	require.Error(t, err)
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("with nil logger - should use default", func(t *testing.T) {
+		c, err := New(testEndpoints(), WithLogger(nil))
+		require.NoError(t, err)
+		require.NotNil(t, c.log)
+	})
+
+	t.Run("with defined logger", func(t *testing.T) {
+		mockedLogger := mock.Logger{}
+
+		c, err := New(testEndpoints(), WithLogger(&mockedLogger))
+		require.NoError(t, err)
+		require.Equal(t, &mockedLogger, c.log)
+	})
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	c, err := New(testEndpoints(), WithCircuitBreaker(5, time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 5, c.endpoints[0].breaker.threshold)
+	require.Equal(t, time.Minute, c.endpoints[0].breaker.cooldown)
+
+	c, err = New(testEndpoints(), WithCircuitBreaker(0, time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, defaultFailureThreshold, c.endpoints[0].breaker.threshold)
+}
+
+func TestWithMaxBlockLag(t *testing.T) {
+	c, err := New(testEndpoints(), WithMaxBlockLag(10))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), c.maxBlockLag)
+}
+
+func TestWithHealthCheckInterval(t *testing.T) {
+	c, err := New(testEndpoints(), WithHealthCheckInterval(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, c.healthCheckInterval)
+}
+
+func testEndpoints() []Endpoint {
+	return []Endpoint{{Name: "test", Client: &fakeEndpoint{}}}
+}
@@ -2,30 +2,52 @@ package storage
 
 import (
 	"context"
-	"strings"
+	"fmt"
+	"sort"
 	"sync"
+
+	"github.com/ilkamo/ethparser-go/types"
 )
 
 // AddressesRepository is a repository for addresses.
 // This is an in memory implementation however in production it should be backed by a
-// fast cache storage like Redis or similar.
+// fast cache storage like Redis or similar. Addresses are expected in their canonical lowercase form.
 type AddressesRepository struct {
-	observedAddresses map[string]struct{}
+	observedAddresses map[string]types.Subscription
 	sync.RWMutex
 }
 
 // NewAddressesRepository creates a new AddressesRepository.
 func NewAddressesRepository() *AddressesRepository {
 	return &AddressesRepository{
-		observedAddresses: make(map[string]struct{}),
+		observedAddresses: make(map[string]types.Subscription),
 	}
 }
 
-func (o *AddressesRepository) ObserveAddress(_ context.Context, address string) error {
+// ObserveAddress adds the address of the subscription to the observed ones. Observing an already observed
+// address updates its label and owner but keeps the original creation time.
+func (o *AddressesRepository) ObserveAddress(_ context.Context, subscription types.Subscription) error {
 	o.Lock()
 	defer o.Unlock()
 
-	o.observedAddresses[strings.ToLower(address)] = struct{}{}
+	if existing, ok := o.observedAddresses[subscription.Address]; ok {
+		subscription.CreatedAt = existing.CreatedAt
+	}
+
+	o.observedAddresses[subscription.Address] = subscription
+
+	return nil
+}
+
+func (o *AddressesRepository) UnobserveAddress(_ context.Context, address string) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if _, ok := o.observedAddresses[address]; !ok {
+		return types.ErrAddressNotFound
+	}
+
+	delete(o.observedAddresses, address)
 
 	return nil
 }
@@ -34,7 +56,58 @@ func (o *AddressesRepository) IsAddressObserved(_ context.Context, address strin
 	o.RLock()
 	defer o.RUnlock()
 
-	_, ok := o.observedAddresses[strings.ToLower(address)]
+	_, ok := o.observedAddresses[address]
 
 	return ok, nil
 }
+
+// GetObservedAddress returns the subscription of an observed address, with its original creation time.
+func (o *AddressesRepository) GetObservedAddress(_ context.Context, address string) (types.Subscription, error) {
+	o.RLock()
+	defer o.RUnlock()
+
+	subscription, ok := o.observedAddresses[address]
+	if !ok {
+		return types.Subscription{}, types.ErrAddressNotFound
+	}
+
+	return subscription, nil
+}
+
+// ListObservedAddresses returns a page of the observed addresses ordered by creation time. Addresses
+// created at the same time are ordered alphabetically so that pages are stable.
+func (o *AddressesRepository) ListObservedAddresses(
+	_ context.Context,
+	offset, limit int,
+) ([]types.Subscription, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("%w: offset and limit must not be negative", types.ErrInvalidPagination)
+	}
+
+	o.RLock()
+	defer o.RUnlock()
+
+	subscriptions := make([]types.Subscription, 0, len(o.observedAddresses))
+	for _, subscription := range o.observedAddresses {
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].Address < subscriptions[j].Address
+		}
+
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+
+	if offset >= len(subscriptions) {
+		return []types.Subscription{}, nil
+	}
+
+	end := offset + limit
+	if end > len(subscriptions) {
+		end = len(subscriptions)
+	}
+
+	return subscriptions[offset:end], nil
+}
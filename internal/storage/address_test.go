@@ -4,8 +4,11 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/types"
 )
 
 func TestAddressesRepository(t *testing.T) {
@@ -23,7 +26,7 @@ func TestAddressesRepository(t *testing.T) {
 	t.Run("observe address", func(t *testing.T) {
 		repo := NewAddressesRepository()
 
-		err := repo.ObserveAddress(ctx, addresses[0])
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[0]})
 		require.NoError(t, err)
 
 		isObserved, err := repo.IsAddressObserved(ctx, addresses[0])
@@ -38,10 +41,10 @@ func TestAddressesRepository(t *testing.T) {
 	t.Run("observe more than one address", func(t *testing.T) {
 		repo := NewAddressesRepository()
 
-		err := repo.ObserveAddress(ctx, addresses[0])
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[0]})
 		require.NoError(t, err)
 
-		err = repo.ObserveAddress(ctx, addresses[1])
+		err = repo.ObserveAddress(ctx, types.Subscription{Address: addresses[1]})
 		require.NoError(t, err)
 
 		isObserved, err := repo.IsAddressObserved(ctx, addresses[0])
@@ -60,10 +63,10 @@ func TestAddressesRepository(t *testing.T) {
 	t.Run("repo should be idempotent", func(t *testing.T) {
 		repo := NewAddressesRepository()
 
-		err := repo.ObserveAddress(ctx, addresses[1])
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[1]})
 		require.NoError(t, err)
 
-		err = repo.ObserveAddress(ctx, addresses[1])
+		err = repo.ObserveAddress(ctx, types.Subscription{Address: addresses[1]})
 		require.NoError(t, err)
 
 		isObserved, err := repo.IsAddressObserved(ctx, addresses[1])
@@ -71,22 +74,118 @@ func TestAddressesRepository(t *testing.T) {
 		require.True(t, isObserved)
 	})
 
-	t.Run("no difference for uppercase and lowercase addresses", func(t *testing.T) {
+	t.Run("addresses are not normalized by the repo", func(t *testing.T) {
 		repo := NewAddressesRepository()
 
-		err := repo.ObserveAddress(ctx, addresses[1])
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[1]})
 		require.NoError(t, err)
 
 		isObserved, err := repo.IsAddressObserved(ctx, strings.ToUpper(addresses[1]))
 		require.NoError(t, err)
-		require.True(t, isObserved)
+		require.False(t, isObserved, "the parser is responsible for the canonical form")
+	})
+}
+
+func TestAddressesRepository_lifecycle(t *testing.T) {
+	addresses := randomAddresses()
+	ctx := context.TODO()
+	now := time.Now()
+
+	t.Run("unobserve address", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[0]})
+		require.NoError(t, err)
+
+		err = repo.UnobserveAddress(ctx, addresses[0])
+		require.NoError(t, err)
+
+		isObserved, err := repo.IsAddressObserved(ctx, addresses[0])
+		require.NoError(t, err)
+		require.False(t, isObserved, "should not be observed")
+	})
+
+	t.Run("unobserve not observed address", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		err := repo.UnobserveAddress(ctx, addresses[0])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+	})
+
+	t.Run("observe address again keeps the creation time", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		err := repo.ObserveAddress(ctx, types.Subscription{Address: addresses[0], Label: "old", CreatedAt: now})
+		require.NoError(t, err)
+
+		err = repo.ObserveAddress(ctx, types.Subscription{
+			Address:   addresses[0],
+			Label:     "new",
+			Owner:     "owner",
+			CreatedAt: now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		subscriptions, err := repo.ListObservedAddresses(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []types.Subscription{{
+			Address:   addresses[0],
+			Label:     "new",
+			Owner:     "owner",
+			CreatedAt: now,
+		}}, subscriptions)
+
+		subscription, err := repo.GetObservedAddress(ctx, addresses[0])
+		require.NoError(t, err)
+		require.Equal(t, subscriptions[0], subscription)
+	})
+
+	t.Run("get not observed address", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		_, err := repo.GetObservedAddress(ctx, addresses[0])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+	})
+
+	t.Run("list observed addresses with pagination", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		for i, address := range addresses {
+			err := repo.ObserveAddress(ctx, types.Subscription{
+				Address:   address,
+				CreatedAt: now.Add(time.Duration(i) * time.Second),
+			})
+			require.NoError(t, err)
+		}
+
+		page, err := repo.ListObservedAddresses(ctx, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		require.Equal(t, addresses[0], page[0].Address)
+		require.Equal(t, addresses[1], page[1].Address)
+
+		page, err = repo.ListObservedAddresses(ctx, 2, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		require.Equal(t, addresses[2], page[0].Address)
+
+		page, err = repo.ListObservedAddresses(ctx, 3, 2)
+		require.NoError(t, err)
+		require.Empty(t, page)
+	})
+
+	t.Run("list observed addresses with invalid pagination", func(t *testing.T) {
+		repo := NewAddressesRepository()
+
+		_, err := repo.ListObservedAddresses(ctx, -1, 2)
+		require.ErrorIs(t, err, types.ErrInvalidPagination)
 	})
 }
 
 func randomAddresses() []string {
 	return []string{
-		"0x056Fc2ceC04BF827d2A3a6e0A9588a05d6f87B57",
-		"0x63feFeeD9eF48706B402A6b94Bf9F63747B3D5Da",
-		"0x4d52a27740DD522F7f02E269bDE3AdB189da84aC",
+		"0x056fc2cec04bf827d2a3a6e0a9588a05d6f87b57",
+		"0x63fefeed9ef48706b402a6b94bf9f63747b3d5da",
+		"0x4d52a27740dd522f7f02e269bde3adb189da84ac",
 	}
 }
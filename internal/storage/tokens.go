@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+type TokenTransfersRepository struct {
+	// map[address]map[transferKey]transfer, so that reprocessing a block does not duplicate its transfers.
+	// Addresses are expected in their canonical lowercase form.
+	transfersPerAddress map[string]map[string]types.TokenTransfer
+	sync.RWMutex
+}
+
+func NewTokenTransfersRepository() *TokenTransfersRepository {
+	return &TokenTransfersRepository{
+		transfersPerAddress: make(map[string]map[string]types.TokenTransfer),
+	}
+}
+
+// transferKey identifies a transfer: a log emits a single transfer, except TransferBatch events.
+func transferKey(transfer types.TokenTransfer) string {
+	return fmt.Sprintf("%s-%d-%d", strings.ToLower(transfer.TransactionHash), transfer.LogIndex, transfer.BatchIndex)
+}
+
+func (t *TokenTransfersRepository) GetTokenTransfers(
+	_ context.Context,
+	address string,
+) ([]types.TokenTransfer, error) {
+	t.RLock()
+	defer t.RUnlock()
+
+	transfers, ok := t.transfersPerAddress[address]
+	if !ok {
+		return nil, types.ErrAddressNotFound
+	}
+
+	result := make([]types.TokenTransfer, 0, len(transfers))
+	for _, transfer := range transfers {
+		result = append(result, transfer)
+	}
+
+	return result, nil
+}
+
+func (t *TokenTransfersRepository) SaveTokenTransfers(_ context.Context, transfers []types.TokenTransfer) error {
+	t.Lock()
+	defer t.Unlock()
+
+	for _, transfer := range transfers {
+		key := transferKey(transfer)
+
+		for _, address := range []string{transfer.From, transfer.To} {
+			transfersOfAddress, ok := t.transfersPerAddress[address]
+			if !ok {
+				transfersOfAddress = make(map[string]types.TokenTransfer)
+				t.transfersPerAddress[address] = transfersOfAddress
+			}
+
+			transfersOfAddress[key] = transfer
+		}
+	}
+
+	return nil
+}
+
+// RemoveTokenTransfersByBlockHash removes the transfers of the block with the given hash from the history of
+// every address. Addresses left without transfers are removed as well.
+func (t *TokenTransfersRepository) RemoveTokenTransfersByBlockHash(_ context.Context, blockHash string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	for address, transfers := range t.transfersPerAddress {
+		for key, transfer := range transfers {
+			if strings.EqualFold(transfer.BlockHash, blockHash) {
+				delete(transfers, key)
+			}
+		}
+
+		if len(transfers) == 0 {
+			delete(t.transfersPerAddress, address)
+		}
+	}
+
+	return nil
+}
+
+// RemoveTokenTransfersByAddress removes the transfers history of an address. Transfers are still part of the
+// history of their counterpart addresses.
+func (t *TokenTransfersRepository) RemoveTokenTransfersByAddress(_ context.Context, address string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.transfersPerAddress, address)
+
+	return nil
+}
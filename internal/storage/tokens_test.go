@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestTokenTransfersRepository(t *testing.T) {
+	addresses := randomAddresses()
+	ctx := context.TODO()
+
+	t.Run("repo should be empty", func(t *testing.T) {
+		repo := NewTokenTransfersRepository()
+
+		transfers, err := repo.GetTokenTransfers(ctx, addresses[0])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+		require.Empty(t, transfers)
+	})
+
+	t.Run("save transfers of a batch without duplicates", func(t *testing.T) {
+		repo := NewTokenTransfersRepository()
+
+		transfer0 := types.TokenTransfer{TransactionHash: "0x1", LogIndex: 2, From: addresses[0], To: addresses[1]}
+		transfer1 := transfer0
+		transfer1.BatchIndex = 1
+
+		err := repo.SaveTokenTransfers(ctx, []types.TokenTransfer{transfer0, transfer1})
+		require.NoError(t, err)
+
+		err = repo.SaveTokenTransfers(ctx, []types.TokenTransfer{transfer0})
+		require.NoError(t, err, "should save again the transfers of a reprocessed block")
+
+		for _, address := range addresses[:2] {
+			transfers, err := repo.GetTokenTransfers(ctx, address)
+			require.NoError(t, err)
+			require.Len(t, transfers, 2)
+			require.Contains(t, transfers, transfer0)
+			require.Contains(t, transfers, transfer1)
+		}
+	})
+
+	t.Run("remove transfers by block hash", func(t *testing.T) {
+		repo := NewTokenTransfersRepository()
+
+		transfer0 := types.TokenTransfer{TransactionHash: "0x1", BlockHash: "0xb1", From: addresses[0], To: addresses[1]}
+		transfer1 := types.TokenTransfer{TransactionHash: "0x2", BlockHash: "0xb2", From: addresses[0], To: addresses[2]}
+
+		err := repo.SaveTokenTransfers(ctx, []types.TokenTransfer{transfer0, transfer1})
+		require.NoError(t, err)
+
+		err = repo.RemoveTokenTransfersByBlockHash(ctx, "0xB2")
+		require.NoError(t, err)
+
+		transfers, err := repo.GetTokenTransfers(ctx, addresses[0])
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{transfer0}, transfers)
+
+		transfers, err = repo.GetTokenTransfers(ctx, addresses[2])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+		require.Empty(t, transfers)
+	})
+
+	t.Run("remove transfers by address", func(t *testing.T) {
+		repo := NewTokenTransfersRepository()
+
+		transfer0 := types.TokenTransfer{TransactionHash: "0x1", From: addresses[0], To: addresses[1]}
+
+		err := repo.SaveTokenTransfers(ctx, []types.TokenTransfer{transfer0})
+		require.NoError(t, err)
+
+		err = repo.RemoveTokenTransfersByAddress(ctx, addresses[0])
+		require.NoError(t, err)
+
+		_, err = repo.GetTokenTransfers(ctx, addresses[0])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+
+		transfers, err := repo.GetTokenTransfers(ctx, addresses[1])
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+	})
+}
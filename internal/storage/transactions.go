@@ -10,7 +10,8 @@ import (
 
 type TransactionsRepository struct {
 	latestBlock uint64
-	// A simple in-memory storage for transactions -> map[address]map[txHash]tx
+	// A simple in-memory storage for transactions -> map[address]map[txID]tx, where addresses are expected
+	// in their canonical lowercase form.
 	// I am using a map instead of a slice to avoid duplicates in the storage in case of reprocessing
 	// because of a failure.
 	transactionsPerAddress map[string]map[string]types.Transaction
@@ -38,7 +39,7 @@ func (t *TransactionsRepository) GetTransactions(_ context.Context, address stri
 	t.RLock()
 	defer t.RUnlock()
 
-	transactions, ok := t.transactionsPerAddress[strings.ToLower(address)]
+	transactions, ok := t.transactionsPerAddress[address]
 	if !ok {
 		return nil, types.ErrAddressNotFound
 	}
@@ -56,30 +57,60 @@ func (t *TransactionsRepository) SaveTransactions(_ context.Context, transaction
 	defer t.Unlock()
 
 	for _, tx := range transactions {
-		txFrom := strings.ToLower(tx.From)
-		txTo := strings.ToLower(tx.To)
-		txHash := strings.ToLower(tx.Hash)
-
-		transactionsFrom, ok := t.transactionsPerAddress[txFrom]
-		if !ok {
-			transactionsFrom = make(map[string]types.Transaction)
-			t.transactionsPerAddress[txFrom] = transactionsFrom
+		txHash := strings.ToLower(tx.ID())
+
+		// Withdrawals have no sender and contract creations no recipient: they are indexed under the created
+		// contract instead.
+		for _, address := range []string{tx.From, tx.To, tx.ContractAddress} {
+			if address == "" {
+				continue
+			}
+
+			transactions, ok := t.transactionsPerAddress[address]
+			if !ok {
+				transactions = make(map[string]types.Transaction)
+				t.transactionsPerAddress[address] = transactions
+			}
+
+			transactions[txHash] = tx
 		}
+	}
 
-		transactionsFrom[txHash] = tx
+	return nil
+}
 
-		transactionsTo, ok := t.transactionsPerAddress[txTo]
-		if !ok {
-			transactionsTo = make(map[string]types.Transaction)
-			t.transactionsPerAddress[txTo] = transactionsTo
+// RemoveTransactionsByBlockHash removes the transactions included in the block with the given hash
+// from the history of every address. Addresses left without transactions are removed as well.
+func (t *TransactionsRepository) RemoveTransactionsByBlockHash(_ context.Context, blockHash string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	for address, transactions := range t.transactionsPerAddress {
+		for txHash, tx := range transactions {
+			if strings.EqualFold(tx.BlockHash, blockHash) {
+				delete(transactions, txHash)
+			}
 		}
 
-		transactionsTo[txHash] = tx
+		if len(transactions) == 0 {
+			delete(t.transactionsPerAddress, address)
+		}
 	}
 
 	return nil
 }
 
+// RemoveTransactionsByAddress removes the transactions history of an address. Transactions are still
+// part of the history of their counterpart addresses.
+func (t *TransactionsRepository) RemoveTransactionsByAddress(_ context.Context, address string) error {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.transactionsPerAddress, address)
+
+	return nil
+}
+
 func (t *TransactionsRepository) SaveLastProcessedBlock(_ context.Context, blockNumber uint64) error {
 	t.Lock()
 	defer t.Unlock()
@@ -118,6 +118,87 @@ func TestTransactionsRepository(t *testing.T) {
 		require.Contains(t, transactions, tx2)
 	})
 
+	t.Run("never index transactions under an empty address", func(t *testing.T) {
+		repo := NewTransactionRepository()
+
+		creation := types.Transaction{Hash: "0x1", From: addresses[0], ContractAddress: addresses[1]}
+		withdrawal := types.Transaction{Kind: types.TransactionKindWithdrawal, To: addresses[2],
+			Withdrawal: &types.Withdrawal{Index: 1}}
+
+		err := repo.SaveTransactions(ctx, []types.Transaction{creation, withdrawal})
+		require.NoError(t, err)
+
+		transactions, err := repo.GetTransactions(ctx, addresses[1])
+		require.NoError(t, err)
+		require.Equal(t, []types.Transaction{creation}, transactions, "creation should be indexed under the contract")
+
+		_, err = repo.GetTransactions(ctx, "")
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+	})
+
+	t.Run("remove transactions by block hash", func(t *testing.T) {
+		repo := NewTransactionRepository()
+
+		tx0 := types.Transaction{Hash: "0x1", BlockHash: "0xb1", From: addresses[0], To: addresses[1]}
+		tx1 := types.Transaction{Hash: "0x2", BlockHash: "0xb2", From: addresses[0], To: addresses[2]}
+
+		err := repo.SaveTransactions(ctx, []types.Transaction{tx0, tx1})
+		require.NoError(t, err)
+
+		err = repo.RemoveTransactionsByBlockHash(ctx, "0xB2")
+		require.NoError(t, err)
+
+		transactions, err := repo.GetTransactions(ctx, addresses[0])
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		require.Contains(t, transactions, tx0)
+
+		transactions, err = repo.GetTransactions(ctx, addresses[1])
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		require.Contains(t, transactions, tx0)
+
+		transactions, err = repo.GetTransactions(ctx, addresses[2])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+		require.Empty(t, transactions)
+	})
+
+	t.Run("remove transactions of unknown block hash", func(t *testing.T) {
+		repo := NewTransactionRepository()
+
+		tx0 := types.Transaction{Hash: "0x1", BlockHash: "0xb1", From: addresses[0], To: addresses[1]}
+
+		err := repo.SaveTransactions(ctx, []types.Transaction{tx0})
+		require.NoError(t, err)
+
+		err = repo.RemoveTransactionsByBlockHash(ctx, "0xb3")
+		require.NoError(t, err)
+
+		transactions, err := repo.GetTransactions(ctx, addresses[0])
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+	})
+
+	t.Run("remove transactions by address", func(t *testing.T) {
+		repo := NewTransactionRepository()
+
+		tx0 := types.Transaction{Hash: "0x1", From: addresses[0], To: addresses[1]}
+
+		err := repo.SaveTransactions(ctx, []types.Transaction{tx0})
+		require.NoError(t, err)
+
+		err = repo.RemoveTransactionsByAddress(ctx, addresses[0])
+		require.NoError(t, err)
+
+		transactions, err := repo.GetTransactions(ctx, addresses[0])
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+		require.Empty(t, transactions)
+
+		transactions, err = repo.GetTransactions(ctx, addresses[1])
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+	})
+
 	t.Run("get last processed block from empty repository", func(t *testing.T) {
 		repo := NewTransactionRepository()
 
@@ -137,7 +218,7 @@ func TestTransactionsRepository(t *testing.T) {
 		require.Equal(t, uint64(100), blockNumber)
 	})
 
-	t.Run("no case sensitive addresses", func(t *testing.T) {
+	t.Run("addresses are not normalized by the repo", func(t *testing.T) {
 		repo := NewTransactionRepository()
 
 		tx0 := types.Transaction{Hash: "0x1", From: addresses[0], To: addresses[1]}
@@ -152,10 +233,7 @@ func TestTransactionsRepository(t *testing.T) {
 		require.Contains(t, transactions, tx0)
 		require.Contains(t, transactions, tx1)
 
-		transactions, err = repo.GetTransactions(ctx, strings.ToUpper(addresses[0]))
-		require.NoError(t, err)
-		require.Len(t, transactions, 2)
-		require.Contains(t, transactions, tx0)
-		require.Contains(t, transactions, tx1)
+		_, err = repo.GetTransactions(ctx, strings.ToUpper(addresses[0]))
+		require.ErrorIs(t, err, types.ErrAddressNotFound, "the parser is responsible for the canonical form")
 	})
 }
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// WebhooksRepository is an in memory repository for webhooks and their deliveries outbox.
+// Pending deliveries and dead letters do not survive a restart, so deliveries are at-least-once within
+// a single process only. In production, the outbox should be backed by a durable storage.
+type WebhooksRepository struct {
+	// map[address]map[url]webhook, where addresses are expected in their canonical lowercase form
+	webhooks map[string]map[string]types.Webhook
+	// map[deliveryID]delivery
+	deliveries map[string]types.WebhookDelivery
+	sync.RWMutex
+}
+
+func NewWebhooksRepository() *WebhooksRepository {
+	return &WebhooksRepository{
+		webhooks:   make(map[string]map[string]types.Webhook),
+		deliveries: make(map[string]types.WebhookDelivery),
+	}
+}
+
+// SaveWebhook saves a webhook, replacing the one of the same address with the same URL.
+func (w *WebhooksRepository) SaveWebhook(_ context.Context, webhook types.Webhook) error {
+	w.Lock()
+	defer w.Unlock()
+
+	addressWebhooks, ok := w.webhooks[webhook.Address]
+	if !ok {
+		addressWebhooks = make(map[string]types.Webhook)
+		w.webhooks[webhook.Address] = addressWebhooks
+	}
+
+	addressWebhooks[webhook.URL] = webhook
+
+	return nil
+}
+
+func (w *WebhooksRepository) RemoveWebhook(_ context.Context, address, url string) error {
+	w.Lock()
+	defer w.Unlock()
+
+	if _, ok := w.webhooks[address][url]; !ok {
+		return types.ErrWebhookNotFound
+	}
+
+	delete(w.webhooks[address], url)
+
+	if len(w.webhooks[address]) == 0 {
+		delete(w.webhooks, address)
+	}
+
+	return nil
+}
+
+func (w *WebhooksRepository) GetWebhooks(_ context.Context, address string) ([]types.Webhook, error) {
+	w.RLock()
+	defer w.RUnlock()
+
+	addressWebhooks := w.webhooks[address]
+
+	result := make([]types.Webhook, 0, len(addressWebhooks))
+	for _, webhook := range addressWebhooks {
+		result = append(result, webhook)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].URL < result[j].URL
+	})
+
+	return result, nil
+}
+
+// EnqueueDeliveries adds the deliveries to the outbox. Deliveries already in the outbox are ignored.
+func (w *WebhooksRepository) EnqueueDeliveries(_ context.Context, deliveries []types.WebhookDelivery) error {
+	w.Lock()
+	defer w.Unlock()
+
+	for _, delivery := range deliveries {
+		if _, ok := w.deliveries[delivery.ID]; ok {
+			continue
+		}
+
+		w.deliveries[delivery.ID] = delivery
+	}
+
+	return nil
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due, the oldest first.
+func (w *WebhooksRepository) GetDueDeliveries(
+	_ context.Context,
+	now time.Time,
+	limit int,
+) ([]types.WebhookDelivery, error) {
+	w.RLock()
+	defer w.RUnlock()
+
+	var due []types.WebhookDelivery
+	for _, delivery := range w.deliveries {
+		if delivery.Status == types.WebhookDeliveryStatusPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (w *WebhooksRepository) UpdateDelivery(_ context.Context, delivery types.WebhookDelivery) error {
+	w.Lock()
+	defer w.Unlock()
+
+	w.deliveries[delivery.ID] = delivery
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of an address, the oldest first.
+func (w *WebhooksRepository) GetDeliveries(_ context.Context, address string) ([]types.WebhookDelivery, error) {
+	w.RLock()
+	defer w.RUnlock()
+
+	var result []types.WebhookDelivery
+	for _, delivery := range w.deliveries {
+		if delivery.Address == address {
+			result = append(result, delivery)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestWebhooksRepository_webhooks(t *testing.T) {
+	ctx := context.TODO()
+	address := randomAddresses()[0]
+
+	t.Run("save, get and remove webhooks", func(t *testing.T) {
+		repo := NewWebhooksRepository()
+
+		webhooks, err := repo.GetWebhooks(ctx, address)
+		require.NoError(t, err)
+		require.Empty(t, webhooks)
+
+		require.NoError(t, repo.SaveWebhook(ctx, types.Webhook{Address: address, URL: "http://b", Secret: "s1"}))
+		require.NoError(t, repo.SaveWebhook(ctx, types.Webhook{Address: address, URL: "http://a", Secret: "s1"}))
+		require.NoError(t, repo.SaveWebhook(ctx, types.Webhook{Address: address, URL: "http://a", Secret: "s2"}))
+
+		webhooks, err = repo.GetWebhooks(ctx, address)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+		require.Equal(t, "http://a", webhooks[0].URL)
+		require.Equal(t, "s2", webhooks[0].Secret, "should replace the webhook with the same url")
+		require.Equal(t, "http://b", webhooks[1].URL)
+
+		require.NoError(t, repo.RemoveWebhook(ctx, address, "http://a"))
+		require.ErrorIs(t, repo.RemoveWebhook(ctx, address, "http://a"), types.ErrWebhookNotFound)
+
+		webhooks, err = repo.GetWebhooks(ctx, address)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+	})
+}
+
+func TestWebhooksRepository_deliveries(t *testing.T) {
+	ctx := context.TODO()
+	addresses := randomAddresses()
+	now := time.Now()
+
+	t.Run("enqueue, get due and update deliveries", func(t *testing.T) {
+		repo := NewWebhooksRepository()
+
+		d0 := types.WebhookDelivery{
+			ID:            "d0",
+			Address:       addresses[0],
+			Status:        types.WebhookDeliveryStatusPending,
+			NextAttemptAt: now.Add(-time.Second),
+			CreatedAt:     now,
+		}
+		d1 := types.WebhookDelivery{
+			ID:            "d1",
+			Address:       addresses[0],
+			Status:        types.WebhookDeliveryStatusPending,
+			NextAttemptAt: now.Add(time.Minute),
+			CreatedAt:     now.Add(time.Second),
+		}
+		d2 := types.WebhookDelivery{
+			ID:            "d2",
+			Address:       addresses[1],
+			Status:        types.WebhookDeliveryStatusPending,
+			NextAttemptAt: now.Add(-time.Minute),
+			CreatedAt:     now,
+		}
+
+		require.NoError(t, repo.EnqueueDeliveries(ctx, []types.WebhookDelivery{d0, d1, d2}))
+
+		due, err := repo.GetDueDeliveries(ctx, now, 10)
+		require.NoError(t, err)
+		require.Len(t, due, 2)
+		require.Equal(t, "d2", due[0].ID, "should return the oldest due delivery first")
+		require.Equal(t, "d0", due[1].ID)
+
+		due, err = repo.GetDueDeliveries(ctx, now, 1)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+
+		delivered := due[0]
+		delivered.Status = types.WebhookDeliveryStatusDelivered
+		delivered.Attempts = 1
+		require.NoError(t, repo.UpdateDelivery(ctx, delivered))
+
+		// Enqueuing a delivery again does not reset it.
+		require.NoError(t, repo.EnqueueDeliveries(ctx, []types.WebhookDelivery{d2}))
+
+		due, err = repo.GetDueDeliveries(ctx, now, 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, "d0", due[0].ID)
+
+		deliveries, err := repo.GetDeliveries(ctx, addresses[0])
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, "d0", deliveries[0].ID)
+		require.Equal(t, "d1", deliveries[1].ID)
+
+		deliveries, err = repo.GetDeliveries(ctx, addresses[1])
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, types.WebhookDeliveryStatusDelivered, deliveries[0].Status)
+	})
+}
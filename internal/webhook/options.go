@@ -0,0 +1,9 @@
+package webhook
+
+type Option func(s *Sender)
+
+func WithHTTPClient(httpClient HTTPClient) Option {
+	return func(s *Sender) {
+		s.httpClient = httpClient
+	}
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Payload transport layer data structure.
+type payload struct {
+	DeliveryID  string             `json:"deliveryId"`
+	Address     string             `json:"address"`
+	Transaction transactionPayload `json:"transaction"`
+}
+
+type transactionPayload struct {
+	Kind            string `json:"kind,omitempty"`
+	Hash            string `json:"hash,omitempty"` // withdrawals have no hash
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	ContractAddress string `json:"contractAddress,omitempty"` // only set for contract creations
+	Value           string `json:"value"`                     // decimal string, it does not fit in a JSON number
+	// WithdrawalIndex and ValidatorIndex are only set for withdrawals.
+	WithdrawalIndex *uint64 `json:"withdrawalIndex,omitempty"`
+	ValidatorIndex  *uint64 `json:"validatorIndex,omitempty"`
+}
+
+// NewPayload returns the JSON payload notifying a transaction of an observed address.
+func NewPayload(deliveryID, address string, tx types.Transaction) ([]byte, error) {
+	transaction := transactionPayload{
+		Kind:            string(tx.Kind),
+		Hash:            tx.Hash,
+		BlockHash:       tx.BlockHash,
+		BlockNumber:     tx.BlockNumber,
+		From:            tx.From,
+		To:              tx.To,
+		ContractAddress: tx.ContractAddress,
+		Value:           tx.Value.String(),
+	}
+
+	if tx.Withdrawal != nil {
+		transaction.WithdrawalIndex = &tx.Withdrawal.Index
+		transaction.ValidatorIndex = &tx.Withdrawal.ValidatorIndex
+	}
+
+	return json.Marshal(payload{
+		DeliveryID:  deliveryID,
+		Address:     address,
+		Transaction: transaction,
+	})
+}
+
+// DeliveryID returns a deterministic ID for the delivery of a transaction, identified by types.Transaction.ID,
+// to a webhook URL, so that the same transaction is never enqueued twice for the same webhook, even if its block
+// is processed again.
+func DeliveryID(address, url, txID string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(address) + "|" + url + "|" + strings.ToLower(txID)))
+
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestNewPayload(t *testing.T) {
+	t.Run("should marshal the transaction", func(t *testing.T) {
+		tx := types.Transaction{
+			BlockHash:   "0xb1",
+			BlockNumber: 1,
+			Hash:        "0xt1",
+			From:        "0xfrom",
+			To:          "0xto",
+			Value:       *big.NewInt(123),
+		}
+
+		payload, err := NewPayload("id", "0xfrom", tx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"deliveryId": "id",
+			"address": "0xfrom",
+			"transaction": {
+				"hash": "0xt1",
+				"blockHash": "0xb1",
+				"blockNumber": 1,
+				"from": "0xfrom",
+				"to": "0xto",
+				"value": "123"
+			}
+		}`, string(payload))
+	})
+
+	t.Run("should marshal the withdrawal", func(t *testing.T) {
+		tx := types.Transaction{
+			Kind:        types.TransactionKindWithdrawal,
+			BlockHash:   "0xb1",
+			BlockNumber: 1,
+			To:          "0xto",
+			Value:       *big.NewInt(2_000_000_000),
+			Withdrawal:  &types.Withdrawal{Index: 8, ValidatorIndex: 100, Address: "0xto", Amount: 2},
+		}
+
+		payload, err := NewPayload("id", "0xto", tx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"deliveryId": "id",
+			"address": "0xto",
+			"transaction": {
+				"kind": "withdrawal",
+				"blockHash": "0xb1",
+				"blockNumber": 1,
+				"from": "",
+				"to": "0xto",
+				"value": "2000000000",
+				"withdrawalIndex": 8,
+				"validatorIndex": 100
+			}
+		}`, string(payload))
+	})
+}
+
+func TestDeliveryID(t *testing.T) {
+	t.Run("should be deterministic and case insensitive", func(t *testing.T) {
+		id := DeliveryID("0xABC", "http://localhost", "0xT1")
+
+		require.Len(t, id, 32)
+		require.Equal(t, id, DeliveryID("0xabc", "http://localhost", "0xt1"))
+		require.NotEqual(t, id, DeliveryID("0xabc", "http://localhost/other", "0xt1"))
+		require.NotEqual(t, id, DeliveryID("0xabc", "http://localhost", "0xt2"))
+	})
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	defaultTimeout = time.Second * 10
+
+	// SignatureHeader contains the HMAC-SHA256 of the request body, computed with the webhook secret
+	// and hex encoded with the `sha256=` prefix.
+	SignatureHeader = "X-Ethparser-Signature"
+	// DeliveryHeader contains the unique ID of the delivery. Retries of a delivery keep the same ID,
+	// so receivers can use it to deduplicate.
+	DeliveryHeader = "X-Ethparser-Delivery"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Sender posts webhook payloads to their receivers.
+type Sender struct {
+	httpClient HTTPClient
+}
+
+func NewSender(opts ...Option) Sender {
+	s := &Sender{}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.httpClient == nil {
+		// use default http client when not provided
+		s.httpClient = &http.Client{
+			Timeout: defaultTimeout,
+		}
+	}
+
+	return *s
+}
+
+// Send posts the payload of the delivery to the webhook URL, signed with the webhook secret.
+// Any response status other than 2xx is considered a failure.
+func (s Sender) Send(ctx context.Context, webhook types.Webhook, delivery types.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.Payload))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+
+	defer func() {
+		// Drain the body to allow the connection to be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the signature of the payload computed with the secret, as sent in the SignatureHeader.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
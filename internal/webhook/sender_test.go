@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestSender_Send(t *testing.T) {
+	ctx := context.TODO()
+	delivery := types.WebhookDelivery{
+		ID:      "delivery-1",
+		Payload: []byte(`{"deliveryId":"delivery-1"}`),
+	}
+
+	t.Run("should post the signed payload", func(t *testing.T) {
+		var (
+			gotBody      []byte
+			gotSignature string
+			gotDelivery  string
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotBody, _ = io.ReadAll(r.Body)
+			gotSignature = r.Header.Get(SignatureHeader)
+			gotDelivery = r.Header.Get(DeliveryHeader)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := NewSender().Send(ctx, types.Webhook{URL: server.URL, Secret: "secret"}, delivery)
+		require.NoError(t, err)
+		require.Equal(t, delivery.Payload, gotBody)
+		require.Equal(t, Sign("secret", delivery.Payload), gotSignature)
+		require.Equal(t, delivery.ID, gotDelivery)
+	})
+
+	t.Run("should return error when the receiver does not accept the payload", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		err := NewSender().Send(ctx, types.Webhook{URL: server.URL, Secret: "secret"}, delivery)
+		require.ErrorContains(t, err, "unexpected response status code: 500")
+	})
+
+	t.Run("should return error when the request cannot be sent", func(t *testing.T) {
+		s := NewSender(WithHTTPClient(&mock.HTTPClient{ShouldError: true}))
+
+		err := s.Send(ctx, types.Webhook{URL: "http://localhost", Secret: "secret"}, delivery)
+		require.ErrorContains(t, err, "could not send request")
+	})
+}
+
+func TestSign(t *testing.T) {
+	t.Run("should compute the hmac sha256 of the payload", func(t *testing.T) {
+		// echo -n '{}' | openssl dgst -sha256 -hmac secret
+		expected := "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+
+		require.Equal(t, expected, Sign("secret", []byte(`{}`)))
+		require.NotEqual(t, Sign("secret", []byte(`{}`)), Sign("other", []byte(`{}`)))
+	})
+}
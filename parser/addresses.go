@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// parseAddress validates an address and returns its canonical lowercase form, which is the one used by
+// the repositories. Malformed addresses are rejected with ethutil.ErrInvalidAddress.
+func parseAddress(address string) (string, error) {
+	parsed, err := ethutil.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.String(), nil
+}
+
+// normalizeTransactionAddresses validates the addresses of a transaction received from the node and replaces
+// them with their canonical form. Missing addresses, like the recipient of a contract creation, are kept empty.
+func normalizeTransactionAddresses(tx *types.Transaction) error {
+	for _, address := range []*string{&tx.From, &tx.To, &tx.ContractAddress} {
+		if *address == "" {
+			continue
+		}
+
+		normalized, err := parseAddress(*address)
+		if err != nil {
+			return fmt.Errorf("could not parse address of transaction %s: %w", tx.ID(), err)
+		}
+
+		*address = normalized
+	}
+
+	return nil
+}
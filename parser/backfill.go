@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Backfill processes the historical blocks in the range [from, to] and saves the observed transactions
+// to the repository. It blocks until the whole range is processed or the context is canceled.
+// The backfill tracks its own progress: it never moves the last processed block of the live loop, so it
+// can run alongside Run (e.g. to index the history of an address while following the head of the chain).
+// Blocks that could not be fetched or processed are retried with the same backoff used by the live loop, and
+// the backfill gives up after the number of consecutive failures set with WithBackfillMaxFailures.
+// The range is expected to contain final blocks: chain reorganizations are not handled by the backfill.
+// Progress is logged and reported to the handler set with WithBackfillProgressHandler.
+func (p *Parser) Backfill(ctx context.Context, from, to uint64) error {
+	return p.backfill(ctx, from, to, p.addressesRepository.IsAddressObserved, p.backfillProgressHandler)
+}
+
+// backfill processes the blocks in the range [from, to] saving only the transactions accepted by the filter.
+// The progress handler, when not nil, is called every time the backfill makes progress.
+func (p *Parser) backfill(
+	ctx context.Context,
+	from, to uint64,
+	isObserved addressFilter,
+	progressHandler func(progress types.BackfillProgress),
+) error {
+	if from > to {
+		return fmt.Errorf("%w: from %d is greater than to %d", types.ErrInvalidRange, from, to)
+	}
+
+	tracker := newBlocksTracker(p.blockRetryBackoff, p.maxBlockRetryBackoff)
+	progress := types.BackfillProgress{From: from, To: to}
+	parentHash := ""
+	failures := 0
+
+	p.logger.Info("starting backfill", "from", from, "to", to)
+
+	for !progress.Done() {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("backfill stopped at block %d: %w", progress.LastProcessedBlock, err)
+		}
+
+		firstBlockNumber := from + progress.ProcessedBlocks
+		lastBlockNumber := firstBlockNumber + uint64(p.backfillMaxBlocksInParallel) - 1
+		if lastBlockNumber > to || lastBlockNumber < firstBlockNumber {
+			lastBlockNumber = to
+		}
+
+		processed, err := p.backfillBlocks(ctx, tracker, firstBlockNumber, lastBlockNumber, parentHash, isObserved)
+		if err != nil {
+			p.logger.Error("could not backfill blocks", "from", firstBlockNumber, "to", lastBlockNumber, "error", err)
+		}
+
+		if len(processed) == 0 {
+			// Without progress, either the first block failed or all the pending blocks are backing off.
+			if err != nil {
+				failures++
+			}
+
+			if failures >= p.backfillMaxFailures {
+				return fmt.Errorf("backfill failed %d consecutive times at block %d: %w", failures, firstBlockNumber, err)
+			}
+
+			if err := p.waitForRetry(ctx, tracker); err != nil {
+				return fmt.Errorf("backfill stopped at block %d: %w", progress.LastProcessedBlock, err)
+			}
+
+			continue
+		}
+
+		failures = 0
+		parentHash = processed[len(processed)-1].Hash
+		progress.ProcessedBlocks += uint64(len(processed))
+		progress.LastProcessedBlock = firstBlockNumber + uint64(len(processed)) - 1
+
+		p.logger.Info("backfill progress",
+			"from", from, "to", to, "lastProcessedBlock", progress.LastProcessedBlock)
+
+		if progressHandler != nil {
+			progressHandler(progress)
+		}
+	}
+
+	p.logger.Info("backfill completed", "from", from, "to", to)
+
+	return nil
+}
+
+// backfillBlocks fetches and processes the blocks in the range [from, to]. It returns the blocks of
+// the longest prefix of the range that was processed successfully.
+func (p *Parser) backfillBlocks(
+	ctx context.Context,
+	tracker *blocksTracker,
+	from, to uint64,
+	parentHash string,
+	isObserved addressFilter,
+) ([]types.Block, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.blocksProcessTimeout)
+	defer cancel()
+
+	fetchErr := p.fetchBlocks(ctx, tracker, from, to)
+
+	blocks := tracker.contiguousBlocks(from, to)
+	if len(blocks) == 0 {
+		return nil, fetchErr
+	}
+
+	// Blocks that break the sequence are fetched again after the backoff: the range is expected to be final,
+	// so the node is most likely serving inconsistent blocks.
+	if parentHash != "" && blocks[0].ParentHash != parentHash {
+		tracker.setFailed(from, time.Now())
+		return nil, errors.Join(fetchErr, fmt.Errorf("block %d is not a child of block %d", from, from-1))
+	}
+
+	var sequenceErr error
+	if linked := linkedBlocksCount(blocks); linked < len(blocks) {
+		brokenLink := from + uint64(linked)
+		sequenceErr = fmt.Errorf("block %d is not a child of block %d", brokenLink, brokenLink-1)
+
+		tracker.setFailed(brokenLink-1, time.Now())
+		tracker.setFailed(brokenLink, time.Now())
+		blocks = blocks[:linked-1]
+	}
+
+	processed, processErr := p.processSequence(ctx, blocks, isObserved, true)
+
+	for i := range blocks[:processed] {
+		tracker.forget(from + uint64(i))
+	}
+
+	// The first block that could not be processed is fetched and processed again after the backoff.
+	if processed < len(blocks) {
+		tracker.setFailed(from+uint64(processed), time.Now())
+	}
+
+	return blocks[:processed], errors.Join(fetchErr, sequenceErr, processErr)
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_Backfill(t *testing.T) {
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("backfill should error because of invalid range", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		err = p.Backfill(context.TODO(), 10, 9)
+		require.ErrorIs(t, err, types.ErrInvalidRange)
+	})
+
+	t.Run("backfill should process the range without moving the live sequence", func(t *testing.T) {
+		transactionsRepo := storage.NewTransactionRepository()
+		var progress []types.BackfillProgress
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(transactionsRepo),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 20,
+				BlocksByNumber:  chainOfBlocks(1, 20, "", "a", transactions),
+			}),
+			WithBackfillMaxBlocksToProcessInParallel(4),
+			WithBackfillProgressHandler(func(p types.BackfillProgress) {
+				progress = append(progress, p)
+			}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		err = p.Backfill(context.TODO(), 3, 12)
+		require.NoError(t, err)
+
+		observed := p.GetTransactions(observedAddress)
+		require.Len(t, observed, 10)
+		for _, tx := range observed {
+			require.GreaterOrEqual(t, tx.BlockNumber, uint64(3))
+			require.LessOrEqual(t, tx.BlockNumber, uint64(12))
+		}
+
+		require.Zero(t, p.GetCurrentBlock())
+		lastProcessedBlock, err := transactionsRepo.GetLastProcessedBlock(context.TODO())
+		require.NoError(t, err)
+		require.Zero(t, lastProcessedBlock)
+
+		require.Equal(t, []types.BackfillProgress{
+			{From: 3, To: 12, LastProcessedBlock: 6, ProcessedBlocks: 4},
+			{From: 3, To: 12, LastProcessedBlock: 10, ProcessedBlocks: 8},
+			{From: 3, To: 12, LastProcessedBlock: 12, ProcessedBlocks: 10},
+		}, progress)
+		require.True(t, progress[len(progress)-1].Done())
+	})
+
+	t.Run("backfill should stop when the context is canceled", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Hour),
+			WithEthereumClient(mock.EthereumClient{WithError: errors.New("node is down")}),
+			WithBlockRetryBackoff(time.Hour, time.Hour),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*50)
+		defer cancel()
+
+		err = p.Backfill(ctx, 1, 10)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("backfill should give up after consecutive failures", func(t *testing.T) {
+		requests := &mock.BlockRequests{}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithEthereumClient(mock.EthereumClient{WithError: errors.New("node is down"), Requests: requests}),
+			WithBlockRetryBackoff(time.Millisecond, time.Millisecond),
+			WithBackfillMaxFailures(3),
+		)
+		require.NoError(t, err)
+
+		err = p.Backfill(context.TODO(), 1, 10)
+		require.ErrorContains(t, err, "backfill failed 3 consecutive times at block 1")
+		require.ErrorContains(t, err, "node is down")
+		require.Equal(t, 3, requests.Count(1))
+	})
+
+	t.Run("backfill should back off when a block cannot be processed", func(t *testing.T) {
+		requests := &mock.BlockRequests{}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Hour),
+			WithTransactionsRepo(mock.TransactionsRepository{SaveError: errors.New("database is down")}),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 10,
+				BlocksByNumber:  chainOfBlocks(1, 10, "", "a", transactions),
+				Requests:        requests,
+			}),
+			WithBlockRetryBackoff(time.Millisecond*20, time.Millisecond*20),
+			WithBackfillMaxFailures(3),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		start := time.Now()
+		err = p.Backfill(context.TODO(), 1, 10)
+		require.ErrorContains(t, err, "database is down")
+		require.GreaterOrEqual(t, time.Since(start), time.Millisecond*40, "should wait for the backoff")
+		require.Equal(t, 3, requests.Count(1))
+	})
+
+	t.Run("backfill should run alongside the live parser", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 20,
+				BlocksByNumber:  chainOfBlocks(1, 20, "", "a", transactions),
+			}),
+			WithStartBlock(15),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			err := p.Run(ctx)
+			require.NoError(t, err)
+			wg.Done()
+		}()
+
+		require.NoError(t, p.Backfill(ctx, 1, 14))
+
+		require.Eventually(t, func() bool {
+			return p.GetCurrentBlock() == 20
+		}, time.Second*2, time.Millisecond*10)
+
+		require.Len(t, p.GetTransactions(observedAddress), 20)
+
+		cancel()
+		wg.Wait()
+	})
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"context"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Events returns a channel that receives the events published by the parser until the context is canceled,
+// then the channel is closed. Every call registers a new subscriber with its own buffer, whose size can be
+// set with WithEventsBufferSize.
+//
+// Back-pressure policy: the parser never waits for a subscriber. When the buffer of a subscriber is full,
+// new events are dropped for that subscriber and counted in DroppedEvents, so a slow consumer cannot stall
+// block processing. Blocks are processed in parallel, so events of different blocks can be received out of
+// order, and the events of a block are published again if the block is processed again after a failure.
+//
+// Backfills publish the transaction and token transfer events of the historical blocks they process, marked with
+// Event.Backfill, but no block processed event. The last blocks of an address backfill can overlap with the ones
+// processed by the live loop, so their events can be received twice: Transaction.ID identifies duplicates.
+func (p *Parser) Events(ctx context.Context) <-chan types.Event {
+	events := make(chan types.Event, p.eventsBufferSize)
+
+	p.eventsMutex.Lock()
+	p.eventSubscribers[events] = struct{}{}
+	p.eventsMutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		p.eventsMutex.Lock()
+		defer p.eventsMutex.Unlock()
+
+		delete(p.eventSubscribers, events)
+		close(events)
+	}()
+
+	return events
+}
+
+// DroppedEvents returns how many events were dropped because the buffer of a subscriber was full.
+func (p *Parser) DroppedEvents() uint64 {
+	return p.droppedEvents.Load()
+}
+
+// publishEvent sends an event to all the subscribers without blocking.
+func (p *Parser) publishEvent(event types.Event) {
+	p.eventsMutex.RLock()
+	defer p.eventsMutex.RUnlock()
+
+	for events := range p.eventSubscribers {
+		select {
+		case events <- event:
+		default:
+			p.droppedEvents.Add(1)
+			p.logger.Error("event dropped, subscriber is too slow", "type", event.Type, "block", event.BlockNumber)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_Events(t *testing.T) {
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975099",
+			From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975099",
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975099",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("subscriber should receive transactions and processed blocks", func(t *testing.T) {
+		chain := chainOfBlocks(1, 3, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		events := p.Events(ctx)
+
+		require.NoError(t, p.processBlocks(ctx))
+
+		var transactionEvents, blockEvents []types.Event
+		for i := 0; i < 6; i++ {
+			event := <-events
+
+			switch event.Type {
+			case types.EventTypeTransaction:
+				transactionEvents = append(transactionEvents, event)
+				require.Equal(t, chain[event.BlockNumber].Transactions[0].Hash, event.Transaction.Hash)
+				require.False(t, event.Backfill)
+			case types.EventTypeBlockProcessed:
+				blockEvents = append(blockEvents, event)
+				require.Equal(t, chain[event.BlockNumber].Hash, event.BlockHash)
+			default:
+				t.Fatalf("unexpected event type %s", event.Type)
+			}
+		}
+
+		require.Len(t, transactionEvents, 3)
+		require.Len(t, blockEvents, 3)
+		require.Zero(t, p.DroppedEvents())
+	})
+
+	t.Run("backfill should only publish transactions marked as backfilled", func(t *testing.T) {
+		chain := chainOfBlocks(1, 3, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		events := p.Events(ctx)
+
+		require.NoError(t, p.Backfill(ctx, 1, 3))
+
+		require.Len(t, events, 3)
+		for i := 0; i < 3; i++ {
+			event := <-events
+			require.Equal(t, types.EventTypeTransaction, event.Type)
+			require.True(t, event.Backfill)
+		}
+	})
+
+	t.Run("slow subscriber should not stall block processing", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 3,
+				BlocksByNumber:  chainOfBlocks(1, 3, "", "a", transactions),
+			}),
+			WithEventsBufferSize(1),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		events := p.Events(ctx)
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 3, p.GetCurrentBlock())
+		require.Equal(t, uint64(5), p.DroppedEvents())
+		require.Len(t, events, 1)
+	})
+
+	t.Run("subscriber should receive reorg events", func(t *testing.T) {
+		chain := chainOfBlocks(1, 5, "", "a", transactions)
+		ethMock := &mock.EthereumClient{MostRecentBlock: 5, BlocksByNumber: chain}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		require.NoError(t, p.processBlocks(ctx))
+
+		for n, block := range chainOfBlocks(4, 6, chain[3].Hash, "b", transactions) {
+			chain[n] = block
+		}
+		ethMock.MostRecentBlock = 6
+
+		events := p.Events(ctx)
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, types.Event{
+			Type:           types.EventTypeReorg,
+			BlockNumber:    3,
+			OrphanedBlocks: 2,
+		}, <-events)
+	})
+
+	t.Run("events channel should be closed when the context is canceled", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		events := p.Events(ctx)
+		cancel()
+
+		require.Eventually(t, func() bool {
+			_, ok := <-events
+			return !ok
+		}, time.Second, time.Millisecond*10)
+	})
+}
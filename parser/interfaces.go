@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"time"
 
 	"github.com/ilkamo/ethparser-go/types"
 )
@@ -18,14 +19,47 @@ type TransactionsRepository interface {
 
 	// SaveLastProcessedBlock saves the last processed block number.
 	SaveLastProcessedBlock(ctx context.Context, blockNumber uint64) error
+
+	// RemoveTransactionsByBlockHash removes all the transactions included in the block with the given hash.
+	// It is used to drop transactions of orphaned blocks after a chain reorganization.
+	RemoveTransactionsByBlockHash(ctx context.Context, blockHash string) error
+
+	// RemoveTransactionsByAddress removes the transactions history of an address.
+	RemoveTransactionsByAddress(ctx context.Context, address string) error
+}
+
+type TokenTransfersRepository interface {
+	// GetTokenTransfers returns the token transfers sent or received by an address.
+	GetTokenTransfers(ctx context.Context, address string) ([]types.TokenTransfer, error)
+
+	// SaveTokenTransfers saves token transfers to the repository.
+	SaveTokenTransfers(ctx context.Context, transfers []types.TokenTransfer) error
+
+	// RemoveTokenTransfersByBlockHash removes all the token transfers of the block with the given hash.
+	// It is used to drop transfers of orphaned blocks after a chain reorganization.
+	RemoveTokenTransfersByBlockHash(ctx context.Context, blockHash string) error
+
+	// RemoveTokenTransfersByAddress removes the token transfers history of an address.
+	RemoveTokenTransfersByAddress(ctx context.Context, address string) error
 }
 
 type AddressesRepository interface {
-	// ObserveAddress adds an address to the list of observed addresses.
-	ObserveAddress(ctx context.Context, address string) error
+	// ObserveAddress adds the address of the subscription to the list of observed addresses.
+	ObserveAddress(ctx context.Context, subscription types.Subscription) error
+
+	// UnobserveAddress removes an address from the list of observed addresses.
+	// It returns types.ErrAddressNotFound if the address is not observed.
+	UnobserveAddress(ctx context.Context, address string) error
 
 	// IsAddressObserved checks if an address is observed.
 	IsAddressObserved(ctx context.Context, address string) (bool, error)
+
+	// GetObservedAddress returns the subscription of an observed address.
+	// It returns types.ErrAddressNotFound if the address is not observed.
+	GetObservedAddress(ctx context.Context, address string) (types.Subscription, error)
+
+	// ListObservedAddresses returns a page of the observed addresses, ordered by subscription time.
+	ListObservedAddresses(ctx context.Context, offset, limit int) ([]types.Subscription, error)
 }
 
 type EthereumClient interface {
@@ -35,3 +69,71 @@ type EthereumClient interface {
 	// GetBlockByNumber returns a block by its number.
 	GetBlockByNumber(ctx context.Context, blockNumber uint64) (types.Block, error)
 }
+
+// EthereumBatchClient is optionally implemented by an EthereumClient able to fetch several blocks in one round
+// trip. When it is implemented, the parser fetches all the blocks of an iteration with a single call.
+type EthereumBatchClient interface {
+	// GetBlocksByNumber returns the blocks with the given numbers. Blocks that could not be fetched
+	// are missing from the result, and the returned error tells why.
+	GetBlocksByNumber(ctx context.Context, blockNumbers []uint64) (map[uint64]types.Block, error)
+}
+
+// EthereumReceiptsClient is optionally implemented by an EthereumClient able to fetch transaction receipts.
+// When it is implemented, the parser attaches the receipts to the transactions involving an observed address.
+type EthereumReceiptsClient interface {
+	// GetTransactionReceipts returns the receipts of the transactions with the given hashes, included in the
+	// block with the given hash, by transaction hash.
+	GetTransactionReceipts(ctx context.Context, blockHash string, txHashes []string) (map[string]types.Receipt, error)
+}
+
+// EthereumTokenTransfersClient is implemented by an EthereumClient able to decode the token transfers of a block
+// from its logs. It is required by WithTokenTransfers.
+type EthereumTokenTransfersClient interface {
+	// GetTokenTransfers returns the ERC-20, ERC-721 and ERC-1155 token transfers of the block with the given hash.
+	GetTokenTransfers(ctx context.Context, blockHash string) ([]types.TokenTransfer, error)
+}
+
+// EthereumTracesClient is implemented by an EthereumClient able to trace the internal calls of the transactions
+// of a block. It is required by WithInternalTransfers.
+type EthereumTracesClient interface {
+	// GetInternalTransfers returns the ETH transferred by the contracts while executing the transactions of
+	// the block, as transactions of kind types.TransactionKindInternal.
+	GetInternalTransfers(ctx context.Context, block types.Block) ([]types.Transaction, error)
+}
+
+// EthereumHeadsSubscriber is implemented by an EthereumClient able to notify the new heads of the chain.
+// It is required by WithNewHeadsSubscription.
+type EthereumHeadsSubscriber interface {
+	// SubscribeNewHeads returns a channel receiving the number of the new heads of the chain.
+	// The channel is closed when the context is canceled or the subscription ends.
+	SubscribeNewHeads(ctx context.Context) (<-chan uint64, error)
+}
+
+type WebhooksRepository interface {
+	// SaveWebhook saves a webhook for an address.
+	SaveWebhook(ctx context.Context, webhook types.Webhook) error
+
+	// RemoveWebhook removes the webhook of an address with the given URL.
+	// It returns types.ErrWebhookNotFound if the webhook does not exist.
+	RemoveWebhook(ctx context.Context, address, url string) error
+
+	// GetWebhooks returns the webhooks of an address.
+	GetWebhooks(ctx context.Context, address string) ([]types.Webhook, error)
+
+	// EnqueueDeliveries adds deliveries to the outbox. It must ignore deliveries that are already in the outbox.
+	EnqueueDeliveries(ctx context.Context, deliveries []types.WebhookDelivery) error
+
+	// GetDueDeliveries returns up to limit pending deliveries whose next attempt is due.
+	GetDueDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error)
+
+	// UpdateDelivery updates a delivery after an attempt.
+	UpdateDelivery(ctx context.Context, delivery types.WebhookDelivery) error
+
+	// GetDeliveries returns the delivery log of an address.
+	GetDeliveries(ctx context.Context, address string) ([]types.WebhookDelivery, error)
+}
+
+type WebhookSender interface {
+	// Send delivers the payload of a delivery to a webhook.
+	Send(ctx context.Context, webhook types.Webhook, delivery types.WebhookDelivery) error
+}
@@ -32,6 +32,12 @@ func WithTransactionsRepo(repo TransactionsRepository) Option {
 	}
 }
 
+func WithTokenTransfersRepo(repo TokenTransfersRepository) Option {
+	return func(p *Parser) {
+		p.tokenTransfersRepo = repo
+	}
+}
+
 func WithAddressesRepo(repo AddressesRepository) Option {
 	return func(p *Parser) {
 		p.addressesRepository = repo
@@ -44,8 +50,144 @@ func WithNoNewBlocksPause(duration time.Duration) Option {
 	}
 }
 
+// WithNewHeadsSubscription drives the parser with the new heads notified by the Ethereum client, which must
+// implement EthereumHeadsSubscriber: new blocks are processed as soon as they are notified instead of after
+// the no new blocks pause, which is kept as a fallback in case notifications are missed.
+func WithNewHeadsSubscription() Option {
+	return func(p *Parser) {
+		p.newHeadsSubscription = true
+	}
+}
+
 func WithMaxBlocksToProcessInParallel(maxBlocks int) Option {
 	return func(p *Parser) {
 		p.maxNumberOfBlocksToProcessInParallel = maxBlocks
 	}
 }
+
+// WithMaxReorgDepth sets how many recently processed block hashes the parser remembers to detect
+// chain reorganizations. Reorgs deeper than this are rolled back up to the oldest remembered block.
+// Depths that are not positive would disable the detection of reorgs, so they are ignored.
+func WithMaxReorgDepth(depth int) Option {
+	return func(p *Parser) {
+		if depth > 0 {
+			p.maxReorgDepth = depth
+		}
+	}
+}
+
+// WithConfirmations sets the number of blocks that must be mined on top of a block before the parser
+// considers it final and processes it. With zero confirmations the parser follows the head of the chain.
+func WithConfirmations(confirmations uint64) Option {
+	return func(p *Parser) {
+		p.confirmations = confirmations
+	}
+}
+
+// WithUnconfirmedTransactions enables tracking of the observed transactions included in blocks that
+// have not reached the configured number of confirmations yet. They are exposed by GetUnconfirmedTransactions.
+func WithUnconfirmedTransactions(enabled bool) Option {
+	return func(p *Parser) {
+		p.unconfirmedTransactionsEnabled = enabled
+	}
+}
+
+// WithTokenTransfers enables the detection of the ERC-20, ERC-721 and ERC-1155 token transfers sent or received
+// by the observed addresses, which are exposed by ListTokenTransfers. The Ethereum client must implement
+// EthereumTokenTransfersClient. The logs of every processed block are fetched, costing a call per block.
+func WithTokenTransfers(enabled bool) Option {
+	return func(p *Parser) {
+		p.tokenTransfersEnabled = enabled
+	}
+}
+
+// WithInternalTransfers enables the detection of the ETH sent or received by the observed addresses through
+// contract calls, e.g. the withdrawals of a multisig, by tracing the transactions of every processed block.
+// The internal transfers are part of the history of the addresses, with kind types.TransactionKindInternal.
+// The Ethereum client must implement EthereumTracesClient, and its node must support tracing.
+func WithInternalTransfers(enabled bool) Option {
+	return func(p *Parser) {
+		p.internalTransfersEnabled = enabled
+	}
+}
+
+// WithBlockRetryBackoff sets the backoff used to retry fetching a block that could not be fetched.
+// The delay doubles after each failed attempt, up to maxBackoff.
+func WithBlockRetryBackoff(backoff, maxBackoff time.Duration) Option {
+	return func(p *Parser) {
+		p.blockRetryBackoff = backoff
+		p.maxBlockRetryBackoff = maxBackoff
+	}
+}
+
+// WithStartBlock sets the first block processed by Run when the repository has not processed
+// any block after it yet. Older blocks can be processed with Backfill.
+func WithStartBlock(blockNumber uint64) Option {
+	return func(p *Parser) {
+		p.startBlock = blockNumber
+	}
+}
+
+// WithBackfillMaxBlocksToProcessInParallel sets how many blocks a backfill fetches and processes in parallel.
+// It is independent of the live loop setting, set with WithMaxBlocksToProcessInParallel.
+func WithBackfillMaxBlocksToProcessInParallel(maxBlocks int) Option {
+	return func(p *Parser) {
+		p.backfillMaxBlocksInParallel = maxBlocks
+	}
+}
+
+// WithBackfillMaxFailures sets how many consecutive times a backfill can fail to make progress before giving up.
+// Failed blocks are retried with the backoff set with WithBlockRetryBackoff.
+func WithBackfillMaxFailures(maxFailures int) Option {
+	return func(p *Parser) {
+		p.backfillMaxFailures = maxFailures
+	}
+}
+
+// WithBackfillProgressHandler sets a handler called every time a backfill makes progress.
+// The handler is called synchronously by the backfill, so it should return quickly.
+func WithBackfillProgressHandler(handler func(progress types.BackfillProgress)) Option {
+	return func(p *Parser) {
+		p.backfillProgressHandler = handler
+	}
+}
+
+// WithEventsBufferSize sets the size of the buffer of every events subscriber (see Events).
+// Events are dropped for a subscriber when its buffer is full.
+func WithEventsBufferSize(size int) Option {
+	return func(p *Parser) {
+		p.eventsBufferSize = size
+	}
+}
+
+// WithWebhooksRepo sets the repository of the webhooks and of their deliveries outbox. The default repository is
+// in memory, so pending deliveries and dead letters are lost on restart: deliveries are at-least-once across
+// restarts only with a repository backed by a persistent storage.
+func WithWebhooksRepo(repo WebhooksRepository) Option {
+	return func(p *Parser) {
+		p.webhooksRepo = repo
+	}
+}
+
+func WithWebhookSender(sender WebhookSender) Option {
+	return func(p *Parser) {
+		p.webhookSender = sender
+	}
+}
+
+// WithWebhookRetries sets how many times a webhook delivery is attempted before being dead-lettered and
+// the backoff between the attempts, which doubles after each failed attempt up to maxBackoff.
+func WithWebhookRetries(maxAttempts int, backoff, maxBackoff time.Duration) Option {
+	return func(p *Parser) {
+		p.webhookMaxAttempts = maxAttempts
+		p.webhookRetryBackoff = backoff
+		p.maxWebhookRetryBackoff = maxBackoff
+	}
+}
+
+// WithWebhookPollInterval sets how often the outbox is checked for due webhook deliveries.
+func WithWebhookPollInterval(interval time.Duration) Option {
+	return func(p *Parser) {
+		p.webhookPollInterval = interval
+	}
+}
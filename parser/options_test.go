@@ -7,6 +7,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/internal/webhook"
+	"github.com/ilkamo/ethparser-go/types"
 )
 
 func TestWithBlockProcessTimeout(t *testing.T) {
@@ -69,6 +72,19 @@ func TestWithNoNewBlocksPause(t *testing.T) {
 	})
 }
 
+func TestWithNewHeadsSubscription(t *testing.T) {
+	t.Run("set new heads subscription opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithNewHeadsSubscription())
+		require.NoError(t, err)
+		require.True(t, p.newHeadsSubscription)
+	})
+
+	t.Run("should error when the ethereum client cannot subscribe", func(t *testing.T) {
+		_, err := NewParser(endpoint, nil, WithNewHeadsSubscription(), WithEthereumClient(mock.EthereumClient{}))
+		require.Error(t, err)
+	})
+}
+
 func TestWithMaxNumberOfBlocksToProcessInParallel(t *testing.T) {
 	t.Run("set max blocks to process opt", func(t *testing.T) {
 		p, err := NewParser(endpoint, nil, WithMaxBlocksToProcessInParallel(22))
@@ -76,3 +92,126 @@ func TestWithMaxNumberOfBlocksToProcessInParallel(t *testing.T) {
 		require.Equal(t, 22, p.maxNumberOfBlocksToProcessInParallel)
 	})
 }
+
+func TestWithMaxReorgDepth(t *testing.T) {
+	t.Run("set max reorg depth opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithMaxReorgDepth(12))
+		require.NoError(t, err)
+		require.Equal(t, 12, p.maxReorgDepth)
+	})
+
+	t.Run("should keep the default max reorg depth when not positive", func(t *testing.T) {
+		for _, depth := range []int{0, -1} {
+			p, err := NewParser(endpoint, nil, WithMaxReorgDepth(depth))
+			require.NoError(t, err)
+			require.Equal(t, defaultMaxReorgDepth, p.maxReorgDepth, depth)
+
+			p.rememberBlockHash(10, "0xb10")
+			_, ok := p.getBlockHash(10)
+			require.True(t, ok, "should remember the hash of the last processed block")
+		}
+	})
+}
+
+func TestWithConfirmations(t *testing.T) {
+	t.Run("set confirmations opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithConfirmations(12))
+		require.NoError(t, err)
+		require.Equal(t, uint64(12), p.confirmations)
+	})
+}
+
+func TestWithUnconfirmedTransactions(t *testing.T) {
+	t.Run("set unconfirmed transactions opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithUnconfirmedTransactions(true))
+		require.NoError(t, err)
+		require.True(t, p.unconfirmedTransactionsEnabled)
+	})
+}
+
+func TestWithBlockRetryBackoff(t *testing.T) {
+	t.Run("set block retry backoff opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithBlockRetryBackoff(time.Second, time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, time.Second, p.blocksTracker.backoff)
+		require.Equal(t, time.Minute, p.blocksTracker.maxBackoff)
+	})
+}
+
+func TestWithStartBlock(t *testing.T) {
+	t.Run("set start block opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithStartBlock(100))
+		require.NoError(t, err)
+		require.Equal(t, uint64(100), p.startBlock)
+	})
+}
+
+func TestWithBackfillMaxBlocksToProcessInParallel(t *testing.T) {
+	t.Run("set backfill max blocks to process opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithBackfillMaxBlocksToProcessInParallel(3))
+		require.NoError(t, err)
+		require.Equal(t, 3, p.backfillMaxBlocksInParallel)
+	})
+}
+
+func TestWithBackfillMaxFailures(t *testing.T) {
+	t.Run("set backfill max failures opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithBackfillMaxFailures(3))
+		require.NoError(t, err)
+		require.Equal(t, 3, p.backfillMaxFailures)
+	})
+}
+
+func TestWithBackfillProgressHandler(t *testing.T) {
+	t.Run("set backfill progress handler opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithBackfillProgressHandler(func(types.BackfillProgress) {}))
+		require.NoError(t, err)
+		require.NotNil(t, p.backfillProgressHandler)
+	})
+}
+
+func TestWithEventsBufferSize(t *testing.T) {
+	t.Run("set events buffer size opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithEventsBufferSize(5))
+		require.NoError(t, err)
+		require.Equal(t, 5, p.eventsBufferSize)
+	})
+}
+
+func TestWithWebhooksRepo(t *testing.T) {
+	t.Run("set webhooks repo opt", func(t *testing.T) {
+		repo := storage.NewWebhooksRepository()
+
+		p, err := NewParser(endpoint, nil, WithWebhooksRepo(repo))
+		require.NoError(t, err)
+		require.Equal(t, repo, p.webhooksRepo)
+	})
+}
+
+func TestWithWebhookSender(t *testing.T) {
+	t.Run("set webhook sender opt", func(t *testing.T) {
+		sender := webhook.NewSender(webhook.WithHTTPClient(&mock.HTTPClient{}))
+
+		p, err := NewParser(endpoint, nil, WithWebhookSender(sender))
+		require.NoError(t, err)
+		require.Equal(t, sender, p.webhookSender)
+	})
+}
+
+func TestWithWebhookRetries(t *testing.T) {
+	t.Run("set webhook retries opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithWebhookRetries(3, time.Second, time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, 3, p.webhookMaxAttempts)
+		require.Equal(t, time.Second, p.webhookRetryBackoff)
+		require.Equal(t, time.Minute, p.maxWebhookRetryBackoff)
+	})
+}
+
+func TestWithWebhookPollInterval(t *testing.T) {
+	t.Run("set webhook poll interval opt", func(t *testing.T) {
+		p, err := NewParser(endpoint, nil, WithWebhookPollInterval(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, p.webhookPollInterval)
+	})
+}
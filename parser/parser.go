@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ilkamo/ethparser-go/internal/ethereum"
 	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/internal/webhook"
 	"github.com/ilkamo/ethparser-go/types"
 )
 
@@ -16,6 +19,17 @@ const (
 	defaultBlocksProcessTimeout       = 5 * time.Second
 	defaultNoNewBlocksPause           = 10 * time.Second // eth new block appears every ~12 seconds
 	defaultMaxNumberOfBlocksToProcess = 10
+	defaultMaxReorgDepth              = 64 // blocks older than two epochs are finalized
+	defaultBlockRetryBackoff          = time.Second
+	defaultMaxBlockRetryBackoff       = time.Minute
+	defaultAddressBackfillsQueueSize  = 100
+	defaultBackfillMaxFailures        = 10
+	defaultEventsBufferSize           = 100
+	defaultWebhookMaxAttempts         = 10
+	defaultWebhookRetryBackoff        = 5 * time.Second
+	defaultMaxWebhookRetryBackoff     = time.Hour
+	defaultWebhookPollInterval        = time.Second
+	defaultWebhookDeliveriesBatchSize = 100
 )
 
 type Parser struct {
@@ -24,12 +38,41 @@ type Parser struct {
 	lastProcessedBlock                   uint64
 	logger                               types.Logger
 	noNewBlocksPause                     time.Duration
+	newHeadsSubscription                 bool
+	newHeads                             <-chan uint64 // new heads notified while running, see WithNewHeadsSubscription
 	transactionsRepo                     TransactionsRepository
+	tokenTransfersRepo                   TokenTransfersRepository
+	tokenTransfersEnabled                bool
+	internalTransfersEnabled             bool
 	addressesRepository                  AddressesRepository
 	running                              bool
 	batchesWorker                        chan struct{}
 	maxNumberOfBlocksToProcessInParallel int
-	processingErrs                       []error
+	blocksTracker                        *blocksTracker
+	blockRetryBackoff                    time.Duration
+	maxBlockRetryBackoff                 time.Duration
+	startBlock                           uint64
+	backfillMaxBlocksInParallel          int
+	backfillMaxFailures                  int
+	backfillProgressHandler              func(progress types.BackfillProgress)
+	addressBackfills                     chan addressBackfillJob
+	addressBackfillStatuses              map[string]types.AddressBackfillStatus
+	eventsBufferSize                     int
+	eventSubscribers                     map[chan types.Event]struct{}
+	eventsMutex                          sync.RWMutex
+	droppedEvents                        atomic.Uint64
+	webhooksRepo                         WebhooksRepository
+	webhookSender                        WebhookSender
+	webhookMaxAttempts                   int
+	webhookRetryBackoff                  time.Duration
+	maxWebhookRetryBackoff               time.Duration
+	webhookPollInterval                  time.Duration
+	maxReorgDepth                        int
+	blockHashes                          map[uint64]string // recently processed block number -> block hash
+	confirmations                        uint64
+	unconfirmedTransactionsEnabled       bool
+	unconfirmedTransactions              *storage.TransactionsRepository
+	unconfirmedHead                      uint64 // most recent block used to build unconfirmedTransactions
 	mutex                                sync.RWMutex
 }
 
@@ -43,10 +86,27 @@ func NewParser(
 		logger:                               logger,
 		noNewBlocksPause:                     defaultNoNewBlocksPause,
 		transactionsRepo:                     storage.NewTransactionRepository(),
+		tokenTransfersRepo:                   storage.NewTokenTransfersRepository(),
 		addressesRepository:                  storage.NewAddressesRepository(),
 		batchesWorker:                        make(chan struct{}, 1),
 		maxNumberOfBlocksToProcessInParallel: defaultMaxNumberOfBlocksToProcess,
-		processingErrs:                       make([]error, 0),
+		blockRetryBackoff:                    defaultBlockRetryBackoff,
+		maxBlockRetryBackoff:                 defaultMaxBlockRetryBackoff,
+		backfillMaxBlocksInParallel:          defaultMaxNumberOfBlocksToProcess,
+		backfillMaxFailures:                  defaultBackfillMaxFailures,
+		addressBackfills:                     make(chan addressBackfillJob, defaultAddressBackfillsQueueSize),
+		addressBackfillStatuses:              make(map[string]types.AddressBackfillStatus),
+		eventsBufferSize:                     defaultEventsBufferSize,
+		eventSubscribers:                     make(map[chan types.Event]struct{}),
+		webhooksRepo:                         storage.NewWebhooksRepository(),
+		webhookSender:                        webhook.NewSender(),
+		webhookMaxAttempts:                   defaultWebhookMaxAttempts,
+		webhookRetryBackoff:                  defaultWebhookRetryBackoff,
+		maxWebhookRetryBackoff:               defaultMaxWebhookRetryBackoff,
+		webhookPollInterval:                  defaultWebhookPollInterval,
+		maxReorgDepth:                        defaultMaxReorgDepth,
+		blockHashes:                          make(map[uint64]string),
+		unconfirmedTransactions:              storage.NewTransactionRepository(),
 	}
 
 	for _, opt := range opts {
@@ -62,6 +122,20 @@ func NewParser(
 		p.ethClient = ethClient
 	}
 
+	if _, ok := p.ethClient.(EthereumHeadsSubscriber); p.newHeadsSubscription && !ok {
+		return nil, fmt.Errorf("ethereum client does not support new heads subscriptions")
+	}
+
+	if _, ok := p.ethClient.(EthereumTokenTransfersClient); p.tokenTransfersEnabled && !ok {
+		return nil, fmt.Errorf("ethereum client does not support token transfers")
+	}
+
+	if _, ok := p.ethClient.(EthereumTracesClient); p.internalTransfersEnabled && !ok {
+		return nil, fmt.Errorf("ethereum client does not support traces")
+	}
+
+	p.blocksTracker = newBlocksTracker(p.blockRetryBackoff, p.maxBlockRetryBackoff)
+
 	p.batchesWorker <- struct{}{}
 
 	return p, nil
@@ -76,27 +150,32 @@ func (p *Parser) GetCurrentBlock() int {
 	return int(p.lastProcessedBlock)
 }
 
-// Subscribe adds an address to the list of addresses to watch for transactions.
+// Subscribe adds an address to the list of addresses to watch for transactions. It returns false for the malformed
+// addresses (see SubscribeWithMetadata).
 // It is not clear to me what the returned bool means. I assumed it returns true if the address was successfully
 // added, false if it not. I would add an error to the return value to provide more information about the failure.
 // Additionally, I would add a context to the method signature.
 func (p *Parser) Subscribe(address string) bool {
-	err := p.addressesRepository.ObserveAddress(context.Background(), address)
+	err := p.SubscribeWithMetadata(context.Background(), types.Subscription{Address: address})
 	if err != nil {
-		p.logger.Error("could not observe address", err)
+		p.logger.Error("could not observe address", "error", err)
 		return false
 	}
 
-	p.logger.Info("started observing address", "address", address)
-
 	return true
 }
 
-// GetTransactions returns a list of transactions for an address.
+// GetTransactions returns a list of transactions for an address. It returns nil for the malformed addresses.
 // I cannot change the signature of the method as it is defined in the `Parser` interface.
 // However, IMO it would be better to return an error if something goes wrong.
 // In addition, I would add a context to the method signature to handle timeouts and cancellations.
 func (p *Parser) GetTransactions(address string) []types.Transaction {
+	address, err := parseAddress(address)
+	if err != nil {
+		p.logger.Error("could not get transactions", "error", err)
+		return nil
+	}
+
 	transactions, err := p.transactionsRepo.GetTransactions(context.Background(), address)
 	if err != nil {
 		if errors.Is(err, types.ErrAddressNotFound) {
@@ -110,28 +189,133 @@ func (p *Parser) GetTransactions(address string) []types.Transaction {
 	return transactions
 }
 
-// getNumberOfBlocksToProcess calculates the number of blocks that the parser should process in the next iteration.
-func (p *Parser) getNumberOfBlocksToProcess(ctx context.Context) (int, uint64, error) {
+// ListTransactions returns a page of the transactions of an address ordered by block number and position in
+// the block, followed by the internal transfers of each transaction and then by the withdrawals of the block,
+// as executed, so that pages are stable while new blocks are processed.
+// It returns types.ErrAddressNotFound if there are no transactions for the address, and ethutil.ErrInvalidAddress
+// if it is malformed.
+func (p *Parser) ListTransactions(
+	ctx context.Context,
+	address string,
+	offset, limit int,
+) ([]types.Transaction, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("%w: offset and limit must not be negative", types.ErrInvalidPagination)
+	}
+
+	address, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := p.transactionsRepo.GetTransactions(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transactions: %w", err)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].BlockNumber != transactions[j].BlockNumber {
+			return transactions[i].BlockNumber < transactions[j].BlockNumber
+		}
+
+		// Withdrawals are processed after the transactions of their block.
+		if isWithdrawal(transactions[i]) != isWithdrawal(transactions[j]) {
+			return !isWithdrawal(transactions[i])
+		}
+
+		if isWithdrawal(transactions[i]) {
+			return transactions[i].Withdrawal.Index < transactions[j].Withdrawal.Index
+		}
+
+		if transactions[i].TransactionIndex != transactions[j].TransactionIndex {
+			return transactions[i].TransactionIndex < transactions[j].TransactionIndex
+		}
+
+		if transactions[i].Hash != transactions[j].Hash {
+			return transactions[i].Hash < transactions[j].Hash
+		}
+
+		// A transaction comes before its internal transfers.
+		if isInternal(transactions[i]) != isInternal(transactions[j]) {
+			return !isInternal(transactions[i])
+		}
+
+		return transactions[i].TraceIndex < transactions[j].TraceIndex
+	})
+
+	if offset >= len(transactions) {
+		return []types.Transaction{}, nil
+	}
+
+	end := offset + limit
+	if end > len(transactions) {
+		end = len(transactions)
+	}
+
+	return transactions[offset:end], nil
+}
+
+// GetUnconfirmedTransactions returns the observed transactions of an address that are included in blocks
+// which have not reached the configured number of confirmations yet. They could still be reorged out,
+// this is why they are kept separated from the final ones returned by GetTransactions.
+// It always returns nil if the parser was not created with the WithUnconfirmedTransactions option, or if the
+// address is malformed.
+func (p *Parser) GetUnconfirmedTransactions(address string) []types.Transaction {
+	address, err := parseAddress(address)
+	if err != nil {
+		p.logger.Error("could not get unconfirmed transactions", "error", err)
+		return nil
+	}
+
 	p.mutex.RLock()
-	defer p.mutex.RUnlock()
+	unconfirmedTransactions := p.unconfirmedTransactions
+	p.mutex.RUnlock()
 
-	lastBlockNumber, err := p.ethClient.GetMostRecentBlockNumber(ctx)
+	transactions, err := unconfirmedTransactions.GetTransactions(context.Background(), address)
 	if err != nil {
-		return 0, 0, fmt.Errorf("could not get most recent block: %w", err)
+		if errors.Is(err, types.ErrAddressNotFound) {
+			return nil
+		}
+
+		p.logger.Error("could not get unconfirmed transactions", "error", err)
+		return nil
 	}
 
-	blocksToProcessCount := int(lastBlockNumber - uint64(p.GetCurrentBlock()))
+	return transactions
+}
+
+// getNumberOfBlocksToProcess calculates the number of blocks that the parser should process in the next iteration.
+// Only blocks with at least the configured number of confirmations are taken into account: the most recent
+// block that can be processed is `mostRecentBlock - confirmations`.
+func (p *Parser) getNumberOfBlocksToProcess(mostRecentBlock uint64) (int, uint64) {
+	lastProcessedBlock := uint64(p.GetCurrentBlock())
+	lastConfirmedBlock := p.lastConfirmedBlock(mostRecentBlock)
+
+	if lastConfirmedBlock <= lastProcessedBlock {
+		return 0, lastProcessedBlock
+	}
+
+	blocksToProcessCount := int(lastConfirmedBlock - lastProcessedBlock)
 
 	if blocksToProcessCount > p.maxNumberOfBlocksToProcessInParallel {
 		blocksToProcessCount = p.maxNumberOfBlocksToProcessInParallel
 	}
 
-	lastBlockOfTheSequence := p.GetCurrentBlock() + blocksToProcessCount
+	lastBlockOfTheSequence := lastProcessedBlock + uint64(blocksToProcessCount)
 
 	p.logger.Info("calculated blocks to process",
 		"blocks", blocksToProcessCount, "lastBlockOfTheSequence", lastBlockOfTheSequence)
 
-	return blocksToProcessCount, uint64(lastBlockOfTheSequence), nil
+	return blocksToProcessCount, lastBlockOfTheSequence
+}
+
+// lastConfirmedBlock returns the most recent block that has the configured number of confirmations.
+func (p *Parser) lastConfirmedBlock(mostRecentBlock uint64) uint64 {
+	if mostRecentBlock < p.confirmations {
+		return 0
+	}
+
+	return mostRecentBlock - p.confirmations
 }
 
 // Run starts the parser and listens for new blocks.
@@ -140,10 +324,14 @@ func (p *Parser) getNumberOfBlocksToProcess(ctx context.Context) (int, uint64, e
 // I also added a context to handle timeouts and cancellations.
 // When called, it starts processing blocks in a loop until the context is canceled.
 // The starting block is the last processed block from the repository so that the parser
-// can continue from where it left off after a restart.
+// can continue from where it left off after a restart. When a start block is set with WithStartBlock
+// and the repository is behind it, the parser starts from the start block instead.
+// Address backfills scheduled with SubscribeSince and webhook deliveries are executed in background
+// while the parser is running. With WithNewHeadsSubscription, the parser waits for the next new head
+// instead of pausing when there are no new blocks.
 func (p *Parser) Run(ctx context.Context) error {
-	if p.isRunning() {
-		return errors.New("parser is already running")
+	if p.IsRunning() {
+		return types.ErrAlreadyRunning
 	}
 
 	p.setIsRunning(true)
@@ -156,8 +344,34 @@ func (p *Parser) Run(ctx context.Context) error {
 		return err
 	}
 
+	if p.startBlock > 0 && latestProcessed < p.startBlock-1 {
+		latestProcessed = p.startBlock - 1
+	}
+
 	p.setLastProcessedBlock(latestProcessed)
 
+	if p.newHeadsSubscription {
+		heads, err := p.ethClient.(EthereumHeadsSubscriber).SubscribeNewHeads(ctx)
+		if err != nil {
+			return fmt.Errorf("could not subscribe to new heads: %w", err)
+		}
+
+		p.newHeads = heads
+	}
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.runAddressBackfills(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		p.runWebhookDeliveries(ctx)
+	}()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -173,7 +387,8 @@ func (p *Parser) Run(ctx context.Context) error {
 	}
 }
 
-func (p *Parser) isRunning() bool {
+// IsRunning returns true while Run is processing blocks.
+func (p *Parser) IsRunning() bool {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/ilkamo/ethparser-go/ethutil"
 	"github.com/ilkamo/ethparser-go/internal/mock"
 	"github.com/ilkamo/ethparser-go/internal/storage"
 	"github.com/ilkamo/ethparser-go/types"
@@ -21,12 +23,13 @@ func TestParser(t *testing.T) {
 	mostRecentBlockOnChain := uint64(19698125)
 	noNewBlockPauseDuration := time.Millisecond * 100
 
-	address0 := "0x115295d8C90Fe127932C6fE78daE6D5a4B975098"
+	address0 := "0x115295D8C90fe127932C6fE78dAE6d5A4B975098"
 	expectedTx := types.Transaction{
-		Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
-		From:  address0,
-		To:    "0x225295d8C90Fe127932C6fE78daE6D5a4B975098",
-		Value: *big.NewInt(123),
+		Hash:               "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+		From:               "0x115295d8c90fe127932c6fe78dae6d5a4b975098",
+		To:                 "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+		Value:              *big.NewInt(123),
+		ConfirmationStatus: types.ConfirmationStatusFinal,
 	}
 	expectedBlock := types.Block{
 		Number:     mostRecentBlockOnChain,
@@ -72,7 +75,7 @@ func TestParser(t *testing.T) {
 		}()
 
 		require.Eventually(t, func() bool {
-			return p.isRunning()
+			return p.IsRunning()
 		}, time.Second*2, time.Millisecond*100)
 
 		// Should observe the subscribed transaction.
@@ -153,7 +156,7 @@ func TestParser(t *testing.T) {
 		}()
 
 		require.Eventually(t, func() bool {
-			return p.isRunning() && len(log.GotErrors()) > 10
+			return p.IsRunning() && len(log.GotErrors()) > 10
 		}, time.Second*2, time.Millisecond*100)
 		require.Contains(t, log.GotErrors(), "could not process blocks")
 
@@ -206,13 +209,120 @@ func TestParser(t *testing.T) {
 		}()
 
 		require.Eventually(t, func() bool {
-			return p.isRunning()
+			return p.IsRunning()
 		}, time.Second*2, time.Millisecond*100)
 
 		err = p.Run(context.TODO())
-		require.ErrorAs(t, err, &types.ErrAlreadyRunning)
+		require.ErrorIs(t, err, types.ErrAlreadyRunning)
 
 		cancel()
 		wg.Wait()
 	})
 }
+
+func TestParser_ListTransactions(t *testing.T) {
+	ctx := context.TODO()
+	address := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	storedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	counterpart := "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+
+	repo := storage.NewTransactionRepository()
+	require.NoError(t, repo.SaveTransactions(ctx, []types.Transaction{
+		{BlockNumber: 2, TransactionIndex: 0, Hash: "0x2b", From: storedAddress, To: counterpart},
+		{BlockNumber: 1, TransactionIndex: 5, Hash: "0x1", From: counterpart, To: storedAddress},
+		{BlockNumber: 2, TransactionIndex: 1, Hash: "0x2a", From: storedAddress, To: counterpart},
+	}))
+
+	p, err := NewParser(endpoint, &mock.Logger{}, WithTransactionsRepo(repo))
+	require.NoError(t, err)
+
+	t.Run("should return the transactions ordered page by page", func(t *testing.T) {
+		page, err := p.ListTransactions(ctx, address, 0, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		require.Equal(t, "0x1", page[0].Hash)
+		require.Equal(t, "0x2b", page[1].Hash, "should follow the execution order in the block")
+
+		page, err = p.ListTransactions(ctx, address, 2, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		require.Equal(t, "0x2a", page[0].Hash)
+
+		page, err = p.ListTransactions(ctx, address, 3, 2)
+		require.NoError(t, err)
+		require.Empty(t, page)
+	})
+
+	t.Run("should return error for unknown addresses and invalid pagination", func(t *testing.T) {
+		_, err := p.ListTransactions(ctx, "0x115295D8C90fe127932C6fE78dAE6d5A4B975098", 0, 2)
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+
+		_, err = p.ListTransactions(ctx, "0xunknown", 0, 2)
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+
+		_, err = p.ListTransactions(ctx, address, -1, 2)
+		require.ErrorIs(t, err, types.ErrInvalidPagination)
+	})
+}
+
+func TestParser_newHeadsSubscription(t *testing.T) {
+	address := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	chain := chainOfBlocks(1, 3, "", "a", []types.Transaction{
+		{Hash: "0xtx", From: address, To: "0x225295d8c90fe127932c6fe78dae6d5a4b975098"},
+	})
+
+	t.Run("should process the new blocks as soon as they are notified", func(t *testing.T) {
+		head := &atomic.Uint64{}
+		head.Store(2)
+
+		ethClient := mock.HeadsEthereumClient{
+			EthereumClient: mock.EthereumClient{BlocksByNumber: chain},
+			Heads:          make(chan uint64),
+			Head:           head,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithEthereumClient(ethClient),
+			WithNewHeadsSubscription(),
+			WithNoNewBlocksPause(time.Hour),
+			WithStartBlock(1),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(address))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- p.Run(ctx)
+		}()
+
+		require.Eventually(t, func() bool {
+			return p.GetCurrentBlock() == 2
+		}, time.Second, time.Millisecond)
+
+		ethClient.Heads <- 3
+
+		require.Eventually(t, func() bool {
+			return p.GetCurrentBlock() == 3
+		}, time.Second, time.Millisecond, "should not wait for the no new blocks pause")
+		require.Len(t, p.GetTransactions(address), 3)
+
+		cancel()
+		require.NoError(t, <-errs)
+	})
+
+	t.Run("should return error when the subscription fails", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithEthereumClient(mock.HeadsEthereumClient{Head: &atomic.Uint64{}, SubscribeError: errors.New("test error")}),
+			WithNewHeadsSubscription(),
+		)
+		require.NoError(t, err)
+
+		require.ErrorContains(t, p.Run(context.TODO()), "could not subscribe to new heads: test error")
+	})
+}
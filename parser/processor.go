@@ -2,135 +2,494 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/internal/storage"
 	"github.com/ilkamo/ethparser-go/types"
 )
 
-// processBlocks processes the blocks in batches. It gets the number of blocks to process, then processes them in parallel.
-// It waits for processed blocks and updates the `last processed block indicator` only if all the blocks were processed successfully.
-// If an error occurs during processing, the batch will be retried again in the next iteration. This assumes that
-// parser repositories are idempotent. It is an all or nothing approach that works well if
-// the rpc client is reliable and the number of blocks to process is well tuned. This could be improved with a more sophisticated
-// approach that would allow for partial processing of the batch by tracking the processed blocks.
-func (p *Parser) processBlocks(ctx context.Context) error {
-	ctx, cancel := context.WithTimeout(ctx, defaultBlocksProcessTimeout)
+// processBlocks processes the blocks in batches. It gets the number of confirmed blocks to process, then fetches
+// in parallel the blocks of the batch that were not fetched in the previous iterations.
+// Progress is tracked per block: fetched blocks are kept by the blocks tracker until they can be processed, and
+// blocks that could not be fetched are retried with an exponential backoff, without fetching the whole batch again.
+// The contiguous sequence of fetched blocks following the last processed block is checked to be a continuation of
+// the last processed block. When it is not, a chain reorganization happened and the parser rolls back to the
+// common ancestor (see handleReorg). The sequence is then processed in parallel and the `last processed block
+// indicator` is moved forward to the last block of the longest prefix of the sequence that was processed
+// successfully. Blocks after a processing failure are processed again in the next iteration: this assumes
+// that parser repositories are idempotent.
+func (p *Parser) processBlocks(runCtx context.Context) error {
+	ctx, cancel := context.WithTimeout(runCtx, p.blocksProcessTimeout)
 	defer cancel()
 
-	blocksToProcessCount, lastBlockNumberOfTheSequence, err := p.getNumberOfBlocksToProcess(ctx)
+	mostRecentBlock, err := p.ethClient.GetMostRecentBlockNumber(ctx)
 	if err != nil {
-		return fmt.Errorf("could not get number of blocks to process: %w", err)
+		return fmt.Errorf("could not get most recent block: %w", err)
 	}
 
+	blocksToProcessCount, lastBlockNumberOfTheSequence := p.getNumberOfBlocksToProcess(mostRecentBlock)
+
 	if blocksToProcessCount == 0 {
+		if err := p.refreshUnconfirmedTransactions(ctx, mostRecentBlock); err != nil {
+			return fmt.Errorf("could not refresh unconfirmed transactions: %w", err)
+		}
+
 		p.logger.Info("no new blocks, sleeping to avoid spamming the node")
-		time.Sleep(p.noNewBlocksPause)
+		p.waitForNewBlocks(runCtx)
 		return nil
 	}
 
+	firstBlockNumber := uint64(p.GetCurrentBlock()) + 1
+
+	fetchErr := p.fetchBlocks(ctx, p.blocksTracker, firstBlockNumber, lastBlockNumberOfTheSequence)
+
+	blocks := p.blocksTracker.contiguousBlocks(firstBlockNumber, lastBlockNumberOfTheSequence)
+	if len(blocks) == 0 {
+		return errors.Join(fetchErr, p.waitForRetry(runCtx, p.blocksTracker))
+	}
+
+	linked, err := p.checkBlocksSequence(firstBlockNumber, blocks)
+	if err != nil {
+		p.logger.Info("chain reorganization detected", "block", firstBlockNumber)
+		p.blocksTracker.reset()
+
+		return p.handleReorg(ctx)
+	}
+
+	var sequenceErr error
+	if linked < len(blocks) {
+		// The chain changed while the blocks were being fetched: the blocks around the broken link
+		// are fetched again in the next iteration.
+		brokenLink := firstBlockNumber + uint64(linked)
+		sequenceErr = fmt.Errorf("block %d is not a child of block %d, chain changed during fetching",
+			brokenLink, brokenLink-1)
+
+		p.blocksTracker.forget(brokenLink - 1)
+		p.blocksTracker.forget(brokenLink)
+		blocks = blocks[:linked-1]
+	}
+
+	processed, processErr := p.processSequence(ctx, blocks, p.addressesRepository.IsAddressObserved, false)
+	if processed == 0 {
+		return errors.Join(fetchErr, sequenceErr, processErr)
+	}
+
+	lastProcessedBlock := firstBlockNumber + uint64(processed) - 1
+
+	// Save the last processed block of the sequence.
+	if err = p.transactionsRepo.SaveLastProcessedBlock(ctx, lastProcessedBlock); err != nil {
+		return fmt.Errorf("could not save last processed block of the sequence: %w", err)
+	}
+
+	// Remember the hashes of the processed blocks to be able to detect reorgs in the next iterations.
+	for i, block := range blocks[:processed] {
+		blockNumber := firstBlockNumber + uint64(i)
+
+		p.rememberBlockHash(blockNumber, block.Hash)
+		p.blocksTracker.forget(blockNumber)
+	}
+
+	// Move the sequence forward.
+	p.setLastProcessedBlock(lastProcessedBlock)
+
+	return errors.Join(fetchErr, sequenceErr, processErr)
+}
+
+// fetchBlocks fetches the blocks in the range [from, to] that the tracker still needs, with a single batch call
+// when the Ethereum client supports it (see EthereumBatchClient), in parallel otherwise.
+// Fetched blocks are handed to the tracker, failed ones are scheduled for a retry.
+func (p *Parser) fetchBlocks(ctx context.Context, tracker *blocksTracker, from, to uint64) error {
+	blockNumbers := tracker.blocksToFetch(from, to, time.Now())
+
+	if batchClient, ok := p.ethClient.(EthereumBatchClient); ok && len(blockNumbers) > 1 {
+		return p.fetchBlocksBatch(ctx, batchClient, tracker, blockNumbers)
+	}
+
+	errs := make([]error, len(blockNumbers))
+
 	wg := sync.WaitGroup{}
-	for i := 0; i < blocksToProcessCount; i++ {
+	for i, blockNumber := range blockNumbers {
 		wg.Add(1)
 
-		go func(blockNumber int) {
+		go func(i int, blockNumber uint64) {
 			defer wg.Done()
 
-			block, err := p.ethClient.GetBlockByNumber(ctx, uint64(blockNumber))
+			block, err := p.ethClient.GetBlockByNumber(ctx, blockNumber)
 			if err != nil {
 				p.logger.Error("could not get block by number", "block", blockNumber, "error", err)
-				p.setProcessingError(err)
+				tracker.setFailed(blockNumber, time.Now())
+				errs[i] = fmt.Errorf("could not get block %d: %w", blockNumber, err)
 				return
 			}
 
-			if err := p.processBlock(ctx, block); err != nil {
+			tracker.setFetched(blockNumber, block)
+		}(i, blockNumber)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// fetchBlocksBatch fetches the blocks with a single batch call.
+func (p *Parser) fetchBlocksBatch(
+	ctx context.Context,
+	batchClient EthereumBatchClient,
+	tracker *blocksTracker,
+	blockNumbers []uint64,
+) error {
+	blocks, err := batchClient.GetBlocksByNumber(ctx, blockNumbers)
+
+	for _, blockNumber := range blockNumbers {
+		block, ok := blocks[blockNumber]
+		if !ok {
+			tracker.setFailed(blockNumber, time.Now())
+			continue
+		}
+
+		tracker.setFetched(blockNumber, block)
+	}
+
+	if err != nil {
+		p.logger.Error("could not get blocks by number", "blocks", len(blockNumbers), "error", err)
+		return fmt.Errorf("could not get blocks: %w", err)
+	}
+
+	return nil
+}
+
+// processSequence processes a sequence of blocks in parallel and returns the length of the longest
+// prefix of the sequence that was processed successfully. Backfill tells if the blocks are processed by
+// a backfill rather than by the live loop (see processBlock).
+func (p *Parser) processSequence(
+	ctx context.Context,
+	blocks []types.Block,
+	isObserved addressFilter,
+	backfill bool,
+) (int, error) {
+	errs := make([]error, len(blocks))
+
+	wg := sync.WaitGroup{}
+	for i, block := range blocks {
+		wg.Add(1)
+
+		go func(i int, block types.Block) {
+			defer wg.Done()
+
+			if err := p.processBlock(ctx, block, isObserved, backfill); err != nil {
 				p.logger.Error("could not process block", "block", block.Number, "error", err)
-				p.setProcessingError(err)
+				errs[i] = err
 			}
-		}(p.GetCurrentBlock() + i + 1)
+		}(i, block)
 	}
 	wg.Wait()
 
-	if len(p.getProcessingErrors()) > 0 {
-		return fmt.Errorf("errors occurred during block processing: %v", p.getProcessingErrors())
+	for i, err := range errs {
+		if err != nil {
+			return i, fmt.Errorf("errors occurred during block processing: %w", errors.Join(errs[i:]...))
+		}
 	}
 
-	// Clear the processing errors for the next iteration.
-	p.clearProcessingErrors()
+	return len(blocks), nil
+}
 
-	// Save the last processed block of the sequence.
-	if err = p.transactionsRepo.SaveLastProcessedBlock(ctx, lastBlockNumberOfTheSequence); err != nil {
-		return fmt.Errorf("could not save last processed block of the sequence: %w", err)
+// waitForNewBlocks sleeps for the no new blocks pause, or until the parser is stopped. When the parser is driven
+// by the new heads subscription, it wakes up as soon as a new head is notified.
+func (p *Parser) waitForNewBlocks(ctx context.Context) {
+	timer := time.NewTimer(p.noNewBlocksPause)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	case _, ok := <-p.newHeads:
+		if !ok && ctx.Err() == nil {
+			// Receiving from a nil channel blocks: the parser polls from now on.
+			p.logger.Info("new heads subscription ended, polling for new blocks")
+			p.newHeads = nil
+		}
 	}
+}
 
-	// Move the sequence forward.
-	p.setLastProcessedBlock(lastBlockNumberOfTheSequence)
+// waitForRetry sleeps until the first failed block can be fetched again, but never more than
+// the no new blocks pause. It avoids spamming the node while all the pending blocks are backing off.
+// It returns the context error if the context is canceled while waiting.
+func (p *Parser) waitForRetry(ctx context.Context, tracker *blocksTracker) error {
+	wait, ok := tracker.nextRetryIn(time.Now())
+	if !ok || wait <= 0 {
+		return nil
+	}
 
-	return nil
+	if wait > p.noNewBlocksPause {
+		wait = p.noNewBlocksPause
+	}
+
+	p.logger.Info("waiting to retry failed blocks", "wait", wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // processBlock processes the block by filtering out observed transactions and saving them to the repository.
-func (p *Parser) processBlock(ctx context.Context, block types.Block) error {
+// The events of the blocks processed by a backfill are marked as such, and no block processed event is published
+// for them since they are behind the head followed by the live loop.
+func (p *Parser) processBlock(ctx context.Context, block types.Block, isObserved addressFilter, backfill bool) error {
 	p.logger.Info("processing block", "block", block.Number, "transactions", len(block.Transactions))
 
-	observedTx, err := p.processAndFilterObservedTransactions(ctx, block.Transactions)
+	observedTx, err := p.processAndFilterObservedTransactions(ctx, block.Transactions, isObserved)
 	if err != nil {
 		return fmt.Errorf("could not filter observed transactions: %w", err)
 	}
 
 	p.logger.Info("observed transactions", "transactions", len(observedTx))
 
+	if err := p.attachReceipts(ctx, block.Hash, observedTx); err != nil {
+		return fmt.Errorf("could not attach receipts: %w", err)
+	}
+
+	internalTx, err := p.processInternalTransfers(ctx, block, isObserved)
+	if err != nil {
+		return fmt.Errorf("could not process internal transfers: %w", err)
+	}
+
+	withdrawals, err := p.processWithdrawals(ctx, block, isObserved)
+	if err != nil {
+		return fmt.Errorf("could not process withdrawals: %w", err)
+	}
+
+	observedTx = append(observedTx, internalTx...)
+	observedTx = append(observedTx, withdrawals...)
+
+	for i := range observedTx {
+		observedTx[i].ConfirmationStatus = types.ConfirmationStatusFinal
+	}
+
 	if err := p.transactionsRepo.SaveTransactions(ctx, observedTx); err != nil {
 		return fmt.Errorf("could not save transactions: %w", err)
 	}
 
+	observedTransfers, err := p.processTokenTransfers(ctx, block.Hash, isObserved)
+	if err != nil {
+		return fmt.Errorf("could not process token transfers: %w", err)
+	}
+
+	if err := p.enqueueWebhookDeliveries(ctx, observedTx); err != nil {
+		return err
+	}
+
+	for _, tx := range observedTx {
+		p.publishEvent(types.Event{
+			Type:        types.EventTypeTransaction,
+			BlockNumber: block.Number,
+			BlockHash:   block.Hash,
+			Transaction: tx,
+			Backfill:    backfill,
+		})
+	}
+
+	for _, transfer := range observedTransfers {
+		p.publishEvent(types.Event{
+			Type:          types.EventTypeTokenTransfer,
+			BlockNumber:   block.Number,
+			BlockHash:     block.Hash,
+			TokenTransfer: transfer,
+			Backfill:      backfill,
+		})
+	}
+
+	if backfill {
+		return nil
+	}
+
+	p.publishEvent(types.Event{
+		Type:        types.EventTypeBlockProcessed,
+		BlockNumber: block.Number,
+		BlockHash:   block.Hash,
+	})
+
 	return nil
 }
 
-// processAndFilterObservedTransactions filters out transactions that involve observed addresses.
-func (p *Parser) processAndFilterObservedTransactions(
-	ctx context.Context,
-	transactions []types.Transaction,
-) ([]types.Transaction, error) {
-	var filtered []types.Transaction
+// refreshUnconfirmedTransactions rebuilds the unconfirmed transactions from the blocks that are more recent than
+// the last confirmed block. The whole set is replaced at once, so transactions of blocks that were reorged out
+// disappear on the next refresh. It only runs when enabled and when the head of the chain moved since the
+// previous refresh, to avoid fetching the same blocks again while waiting for new ones.
+func (p *Parser) refreshUnconfirmedTransactions(ctx context.Context, mostRecentBlock uint64) error {
+	if !p.unconfirmedTransactionsEnabled || p.confirmations == 0 || p.getUnconfirmedHead() == mostRecentBlock {
+		return nil
+	}
 
-	for _, tx := range transactions {
-		okFrom, err := p.addressesRepository.IsAddressObserved(ctx, tx.From)
+	blocks, err := p.getUnconfirmedBlocks(ctx, p.lastConfirmedBlock(mostRecentBlock)+1, mostRecentBlock)
+	if err != nil {
+		return fmt.Errorf("could not get unconfirmed blocks: %w", err)
+	}
+
+	unconfirmedTransactions := storage.NewTransactionRepository()
+
+	for _, block := range blocks {
+		observedTx, err := p.processAndFilterObservedTransactions(
+			ctx,
+			block.Transactions,
+			p.addressesRepository.IsAddressObserved,
+		)
 		if err != nil {
-			return nil, fmt.Errorf("could not check if address `from` is observed: %w", err)
+			return fmt.Errorf("could not filter observed transactions: %w", err)
 		}
 
-		okTo, err := p.addressesRepository.IsAddressObserved(ctx, tx.To)
+		withdrawals, err := p.processWithdrawals(ctx, block, p.addressesRepository.IsAddressObserved)
 		if err != nil {
-			return nil, fmt.Errorf("could not check if address `to` is observed: %w", err)
+			return fmt.Errorf("could not filter observed withdrawals: %w", err)
 		}
 
-		if okFrom || okTo {
-			filtered = append(filtered, tx)
+		observedTx = append(observedTx, withdrawals...)
+
+		for i := range observedTx {
+			observedTx[i].ConfirmationStatus = types.ConfirmationStatusPending
+		}
+
+		if err := unconfirmedTransactions.SaveTransactions(ctx, observedTx); err != nil {
+			return fmt.Errorf("could not save unconfirmed transactions: %w", err)
 		}
 	}
 
-	return filtered, nil
+	p.setUnconfirmedTransactions(unconfirmedTransactions, mostRecentBlock)
+
+	return nil
 }
 
-func (p *Parser) setProcessingError(err error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
+// getUnconfirmedBlocks fetches the blocks in the range [from, to] with a single batch call when the Ethereum
+// client supports it (see EthereumBatchClient). Otherwise, they are fetched in parallel, but never more than
+// the max number of blocks to process in parallel at a time, since the range grows with the confirmations.
+func (p *Parser) getUnconfirmedBlocks(ctx context.Context, from, to uint64) ([]types.Block, error) {
+	blocks := make([]types.Block, to-from+1)
+
+	if batchClient, ok := p.ethClient.(EthereumBatchClient); ok && len(blocks) > 1 {
+		blockNumbers := make([]uint64, len(blocks))
+		for i := range blockNumbers {
+			blockNumbers[i] = from + uint64(i)
+		}
+
+		fetched, err := batchClient.GetBlocksByNumber(ctx, blockNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, blockNumber := range blockNumbers {
+			block, ok := fetched[blockNumber]
+			if !ok {
+				return nil, fmt.Errorf("missing block %d", blockNumber)
+			}
+
+			blocks[i] = block
+		}
+
+		return blocks, nil
+	}
 
-	p.processingErrs = append(p.processingErrs, err)
+	errs := make([]error, len(blocks))
+	workers := make(chan struct{}, p.maxNumberOfBlocksToProcessInParallel)
+
+	wg := sync.WaitGroup{}
+	for i := range blocks {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func(i int) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			blocks[i], errs[i] = p.ethClient.GetBlockByNumber(ctx, from+uint64(i))
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
 }
 
-func (p *Parser) getProcessingErrors() []error {
+func (p *Parser) getUnconfirmedHead() uint64 {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
-	return p.processingErrs
+	return p.unconfirmedHead
 }
 
-func (p *Parser) clearProcessingErrors() {
+func (p *Parser) setUnconfirmedTransactions(transactions *storage.TransactionsRepository, head uint64) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	p.processingErrs = nil
+	p.unconfirmedTransactions = transactions
+	p.unconfirmedHead = head
+}
+
+// addressFilter tells if the transactions involving an address should be saved.
+type addressFilter func(ctx context.Context, address string) (bool, error)
+
+// processAndFilterObservedTransactions filters out transactions that involve observed addresses. The addresses
+// of the transactions are normalized first (see normalizeTransactionAddresses), and the address of the contract
+// deployed by a contract creation is resolved, so that the deployments of an observed address are kept as well.
+// Transactions with malformed addresses are logged and treated as unobserved: failing the block would stall the
+// parser at its height, since the node returns the same data on every retry.
+func (p *Parser) processAndFilterObservedTransactions(
+	ctx context.Context,
+	transactions []types.Transaction,
+	isObserved addressFilter,
+) ([]types.Transaction, error) {
+	var filtered []types.Transaction
+
+	for _, tx := range transactions {
+		if err := normalizeTransactionAddresses(&tx); err != nil {
+			p.logger.Error("skipping malformed transaction", "error", err)
+			continue
+		}
+
+		if tx.IsContractCreation() && tx.ContractAddress == "" {
+			sender, err := ethutil.ParseAddress(tx.From)
+			if err != nil {
+				p.logger.Error("skipping malformed transaction", "error",
+					fmt.Errorf("could not compute contract address of transaction %s: %w", tx.ID(), err))
+				continue
+			}
+
+			tx.ContractAddress = ethutil.CreateAddress(sender, tx.Nonce).String()
+		}
+
+		okFrom, err := isObserved(ctx, tx.From)
+		if err != nil {
+			return nil, fmt.Errorf("could not check if address `from` is observed: %w", err)
+		}
+
+		okTo, err := isObserved(ctx, tx.To)
+		if err != nil {
+			return nil, fmt.Errorf("could not check if address `to` is observed: %w", err)
+		}
+
+		var okContract bool
+		if tx.ContractAddress != "" {
+			if okContract, err = isObserved(ctx, tx.ContractAddress); err != nil {
+				return nil, fmt.Errorf("could not check if created contract is observed: %w", err)
+			}
+		}
+
+		if okFrom || okTo || okContract {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered, nil
 }
@@ -3,6 +3,7 @@ package parser
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 	"time"
@@ -35,12 +36,17 @@ func TestParser_processBlock(t *testing.T) {
 
 	tx := types.Transaction{
 		Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
-		From:  "0x995295d8C90Fe127932C6fE78daE6D5a4B975098",
-		To:    "0x225295d8C90Fe127932C6fE78daE6D5a4B975098",
+		From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975098",
+		To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
 		Value: *big.NewInt(123),
 	}
 
-	err = p.processBlock(context.Background(), types.Block{Transactions: []types.Transaction{tx}})
+	err = p.processBlock(
+		context.Background(),
+		types.Block{Transactions: []types.Transaction{tx}},
+		p.addressesRepository.IsAddressObserved,
+		false,
+	)
 	require.Error(t, err)
 }
 
@@ -48,32 +54,29 @@ func TestParser_processBlock(t *testing.T) {
 func TestParser_processBlocks(t *testing.T) {
 	log := &mock.Logger{}
 	mostRecentBlockOnChain := uint64(14)
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975098",
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975099",
+			From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975099",
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975099",
+			Value: *big.NewInt(123),
+		},
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975100",
+			From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975100",
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975100",
+			Value: *big.NewInt(123),
+		},
+	}
 	ethMock := mock.EthereumClient{
 		MostRecentBlock: mostRecentBlockOnChain,
-		BlockByNumber: types.Block{
-			Number: mostRecentBlockOnChain,
-			Hash:   "0xasd295d8C90Fe127932C6fE78daE6D5a4B975gs1",
-			Transactions: []types.Transaction{
-				{
-					Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
-					From:  "0x995295d8C90Fe127932C6fE78daE6D5a4B975098",
-					To:    "0x225295d8C90Fe127932C6fE78daE6D5a4B975098",
-					Value: *big.NewInt(123),
-				},
-				{
-					Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975099",
-					From:  "0x995295d8C90Fe127932C6fE78daE6D5a4B975099",
-					To:    "0x225295d8C90Fe127932C6fE78daE6D5a4B975099",
-					Value: *big.NewInt(123),
-				},
-				{
-					Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975100",
-					From:  "0x995295d8C90Fe127932C6fE78daE6D5a4B975100",
-					To:    "0x225295d8C90Fe127932C6fE78daE6D5a4B975100",
-					Value: *big.NewInt(123),
-				},
-			},
-		},
+		BlocksByNumber:  chainOfBlocks(1, mostRecentBlockOnChain, "", "a", transactions),
 	}
 
 	maxBlocksToProcessInParallelCount := 10
@@ -123,3 +126,436 @@ func TestParser_processBlocks(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestParser_processBlocksPartialProgress(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("parser should only retry the failed blocks", func(t *testing.T) {
+		requests := &mock.BlockRequests{}
+		ethMock := &mock.EthereumClient{
+			MostRecentBlock: 10,
+			BlocksByNumber:  chainOfBlocks(1, 10, "", "a", transactions),
+			BlockErrors:     map[uint64]error{4: errors.New("flaky node")},
+			Requests:        requests,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithBlockRetryBackoff(time.Millisecond*50, time.Second),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		err = p.processBlocks(ctx)
+		require.ErrorContains(t, err, "could not get block 4: flaky node")
+
+		// The contiguous prefix of processed blocks moves the sequence forward.
+		require.Equal(t, 3, p.GetCurrentBlock())
+		require.Len(t, p.GetTransactions(observedAddress), 3)
+
+		ethMock.BlockErrors = nil
+
+		// The failed block is still backing off: nothing is fetched.
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 3, p.GetCurrentBlock())
+		require.Equal(t, 1, requests.Count(4))
+
+		require.Eventually(t, func() bool {
+			require.NoError(t, p.processBlocks(ctx))
+			return p.GetCurrentBlock() == 10
+		}, time.Second, time.Millisecond*10)
+
+		require.Len(t, p.GetTransactions(observedAddress), 10)
+		require.Equal(t, 2, requests.Count(4))
+
+		for blockNumber := uint64(1); blockNumber <= 10; blockNumber++ {
+			if blockNumber != 4 {
+				require.Equal(t, 1, requests.Count(blockNumber), "block %d should be fetched once", blockNumber)
+			}
+		}
+	})
+
+	t.Run("parser should stop waiting for the retry when the context is canceled", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Hour),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 10,
+				BlockErrors:     map[uint64]error{1: errors.New("flaky node")},
+			}),
+			WithBlockRetryBackoff(time.Hour, time.Hour),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+		defer cancel()
+
+		start := time.Now()
+		err = p.processBlocks(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("parser should fetch the blocks of an iteration with a single batch call", func(t *testing.T) {
+		requests := &mock.BlockRequests{}
+		batches := &mock.BatchRequests{}
+		ethMock := &mock.BatchEthereumClient{
+			EthereumClient: mock.EthereumClient{
+				MostRecentBlock: 10,
+				BlocksByNumber:  chainOfBlocks(1, 10, "", "a", transactions),
+				BlockErrors:     map[uint64]error{4: errors.New("flaky node")},
+				Requests:        requests,
+			},
+			Batches: batches,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithBlockRetryBackoff(time.Millisecond, time.Millisecond),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		err = p.processBlocks(ctx)
+		require.ErrorContains(t, err, "could not get blocks: flaky node")
+		require.Equal(t, 3, p.GetCurrentBlock())
+		require.Equal(t, []int{10}, batches.Sizes())
+
+		ethMock.BlockErrors = nil
+		time.Sleep(time.Millisecond * 2)
+
+		// Only the failed block is fetched again.
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 10, p.GetCurrentBlock())
+		require.Len(t, p.GetTransactions(observedAddress), 10)
+		require.Equal(t, []int{10}, batches.Sizes())
+		require.Equal(t, 2, requests.Count(4))
+	})
+
+	t.Run("parser should not fetch again blocks that could not be processed", func(t *testing.T) {
+		requests := &mock.BlockRequests{}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(mock.TransactionsRepository{SaveError: errors.New("save error")}),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 2,
+				BlocksByNumber:  chainOfBlocks(1, 2, "", "a", transactions),
+				Requests:        requests,
+			}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.ErrorContains(t, p.processBlocks(ctx), "save error")
+		require.ErrorContains(t, p.processBlocks(ctx), "save error")
+		require.Zero(t, p.GetCurrentBlock())
+		require.Equal(t, 1, requests.Count(1))
+		require.Equal(t, 1, requests.Count(2))
+	})
+}
+
+func TestParser_confirmations(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("parser should only process confirmed blocks", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 14,
+				BlocksByNumber:  chainOfBlocks(1, 14, "", "a", transactions),
+			}),
+			WithMaxBlocksToProcessInParallel(20),
+			WithConfirmations(4),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 10, p.GetCurrentBlock())
+
+		observed := p.GetTransactions(observedAddress)
+		require.Len(t, observed, 10)
+		for _, tx := range observed {
+			require.Equal(t, types.ConfirmationStatusFinal, tx.ConfirmationStatus)
+		}
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 10, p.GetCurrentBlock())
+		require.Empty(t, p.GetUnconfirmedTransactions(observedAddress), "unconfirmed transactions are disabled")
+	})
+
+	t.Run("parser should not process anything when the chain is shorter than confirmations", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 3}),
+			WithConfirmations(4),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Zero(t, p.GetCurrentBlock())
+	})
+
+	t.Run("parser should expose unconfirmed transactions separately", func(t *testing.T) {
+		chain := chainOfBlocks(1, 14, "", "a", transactions)
+		ethMock := &mock.EthereumClient{
+			MostRecentBlock: 14,
+			BlocksByNumber:  chain,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithMaxBlocksToProcessInParallel(20),
+			WithConfirmations(4),
+			WithUnconfirmedTransactions(true),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.NoError(t, p.processBlocks(ctx))
+
+		unconfirmed := p.GetUnconfirmedTransactions(observedAddress)
+		require.Len(t, unconfirmed, 4)
+		for _, tx := range unconfirmed {
+			require.Greater(t, tx.BlockNumber, uint64(10))
+			require.Equal(t, types.ConfirmationStatusPending, tx.ConfirmationStatus)
+		}
+
+		// Two new blocks are mined: blocks 11 and 12 become final.
+		for n, block := range chainOfBlocks(15, 16, chain[14].Hash, "a", transactions) {
+			chain[n] = block
+		}
+		ethMock.MostRecentBlock = 16
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 12, p.GetCurrentBlock())
+		require.Len(t, p.GetTransactions(observedAddress), 12)
+
+		require.NoError(t, p.processBlocks(ctx))
+
+		unconfirmed = p.GetUnconfirmedTransactions(observedAddress)
+		require.Len(t, unconfirmed, 4)
+		for _, tx := range unconfirmed {
+			require.Greater(t, tx.BlockNumber, uint64(12))
+		}
+	})
+
+	t.Run("parser should fetch the unconfirmed blocks with a single batch call", func(t *testing.T) {
+		batches := &mock.BatchRequests{}
+		ethMock := &mock.BatchEthereumClient{
+			EthereumClient: mock.EthereumClient{
+				MostRecentBlock: 14,
+				BlocksByNumber:  chainOfBlocks(1, 14, "", "a", transactions),
+			},
+			Batches: batches,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithMaxBlocksToProcessInParallel(20),
+			WithConfirmations(4),
+			WithUnconfirmedTransactions(true),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.NoError(t, p.processBlocks(ctx))
+
+		require.Len(t, p.GetUnconfirmedTransactions(observedAddress), 4)
+		require.Equal(t, []int{10, 4}, batches.Sizes())
+	})
+}
+
+// chainOfBlocks returns a chain of linked blocks from `from` to `to` (included). The fork name is part
+// of the block hashes so that two chains with a different fork name are different chains.
+// Every block contains a copy of the given transactions bound to the block.
+func chainOfBlocks(
+	from, to uint64,
+	parentHash string,
+	fork string,
+	transactions []types.Transaction,
+) map[uint64]types.Block {
+	blocks := make(map[uint64]types.Block)
+
+	for n := from; n <= to; n++ {
+		hash := fmt.Sprintf("0x%s%d", fork, n)
+
+		blockTransactions := make([]types.Transaction, len(transactions))
+		for i, tx := range transactions {
+			tx.BlockHash = hash
+			tx.BlockNumber = n
+			tx.Hash = fmt.Sprintf("%s%s%d", tx.Hash, fork, n)
+			blockTransactions[i] = tx
+		}
+
+		blocks[n] = types.Block{
+			Number:       n,
+			Hash:         hash,
+			ParentHash:   parentHash,
+			Transactions: blockTransactions,
+		}
+
+		parentHash = hash
+	}
+
+	return blocks
+}
+
+func TestParser_contractCreations(t *testing.T) {
+	ctx := context.TODO()
+
+	deployer := "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"
+	contract := "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8" // created with nonce 1
+
+	block := types.Block{
+		Number: 1,
+		Hash:   "0xb1",
+		Transactions: []types.Transaction{{
+			Kind: types.TransactionKindExternal, BlockHash: "0xb1", BlockNumber: 1, Hash: "0x1",
+			From: "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0", Nonce: 1,
+		}},
+	}
+
+	newParser := func(t *testing.T, client EthereumClient, address string) *Parser {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(client))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(address))
+
+		return p
+	}
+
+	t.Run("should index the deployment under the computed contract address", func(t *testing.T) {
+		p := newParser(t, mock.EthereumClient{}, deployer)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		transactions := p.GetTransactions(deployer)
+		require.Len(t, transactions, 1)
+		require.Equal(t, contract, transactions[0].ContractAddress)
+		require.Equal(t, transactions, p.GetTransactions(contract))
+		require.Empty(t, p.GetTransactions(""), "should not index the deployment under the missing recipient")
+	})
+
+	t.Run("should keep the deployment of an observed contract", func(t *testing.T) {
+		p := newParser(t, mock.EthereumClient{}, contract)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		require.Len(t, p.GetTransactions(contract), 1)
+	})
+
+	t.Run("should deliver the deployment to the webhooks of the contract", func(t *testing.T) {
+		p := newParser(t, mock.EthereumClient{}, contract)
+		require.NoError(t, p.RegisterWebhook(ctx, contract, "https://example.com/hook", "secret"))
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, contract)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, "0x1", deliveries[0].TransactionHash)
+	})
+
+	t.Run("should prefer the contract address of the receipt", func(t *testing.T) {
+		client := mock.ReceiptsEthereumClient{Receipts: map[string]types.Receipt{
+			"0x1": {TransactionHash: "0x1", ContractAddress: "0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d"},
+		}}
+		p := newParser(t, client, deployer)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		transactions := p.GetTransactions(deployer)
+		require.Len(t, transactions, 1)
+		require.Equal(t, "0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d", transactions[0].ContractAddress)
+	})
+
+	t.Run("should skip the deployment when the sender is missing", func(t *testing.T) {
+		log := &mock.Logger{}
+		malformed := block
+		malformed.Transactions = []types.Transaction{{Kind: types.TransactionKindExternal, Hash: "0x1"}}
+
+		p, err := NewParser(endpoint, log, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		require.NoError(t, p.processBlock(ctx, malformed, p.addressesRepository.IsAddressObserved, false))
+		require.Contains(t, log.GotErrors(), "skipping malformed transaction")
+	})
+}
+
+func TestParser_malformedTransactions(t *testing.T) {
+	observedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	transactions := []types.Transaction{
+		{Hash: "0x1", From: "0xnode", To: observedAddress, Value: *big.NewInt(1)},
+		{Hash: "0x2", From: "0x225295d8c90fe127932c6fe78dae6d5a4b975098", To: observedAddress, Value: *big.NewInt(2)},
+	}
+
+	t.Run("parser should skip the malformed transactions and keep processing blocks", func(t *testing.T) {
+		log := &mock.Logger{}
+		chain := chainOfBlocks(1, 2, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			log,
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 2, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(context.TODO()))
+		require.Equal(t, 2, p.GetCurrentBlock(), "should not stall at the block of the malformed transaction")
+
+		saved := p.GetTransactions(observedAddress)
+		require.Len(t, saved, 2)
+		for _, tx := range saved {
+			require.Contains(t, tx.Hash, "0x2", "should treat the malformed transactions as unobserved")
+		}
+
+		require.Contains(t, log.GotErrors(), "skipping malformed transaction")
+	})
+}
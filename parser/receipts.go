@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// attachReceipts fetches the receipts of the observed transactions of a block and attaches them to the
+// transactions, when the Ethereum client can fetch receipts (see EthereumReceiptsClient). Only the receipts of
+// the observed transactions are requested, and blocks without observed transactions do not cost any call.
+func (p *Parser) attachReceipts(ctx context.Context, blockHash string, observedTx []types.Transaction) error {
+	receiptsClient, ok := p.ethClient.(EthereumReceiptsClient)
+	if !ok || len(observedTx) == 0 {
+		return nil
+	}
+
+	txHashes := make([]string, len(observedTx))
+	for i, tx := range observedTx {
+		txHashes[i] = tx.Hash
+	}
+
+	receipts, err := receiptsClient.GetTransactionReceipts(ctx, blockHash, txHashes)
+	if err != nil {
+		return fmt.Errorf("could not get receipts of block %s: %w", blockHash, err)
+	}
+
+	for i, tx := range observedTx {
+		receipt, ok := receipts[tx.Hash]
+		if !ok {
+			return fmt.Errorf("missing receipt of transaction %s", tx.Hash)
+		}
+
+		observedTx[i].Receipt = &receipt
+
+		// The receipt is authoritative for the address of the deployed contract.
+		if tx.IsContractCreation() && receipt.ContractAddress != "" {
+			contractAddress, err := parseAddress(receipt.ContractAddress)
+			if err != nil {
+				// The computed address is kept rather than failing the block on every retry.
+				p.logger.Error("ignoring malformed contract address of receipt", "error",
+					fmt.Errorf("could not parse contract address of transaction %s: %w", tx.Hash, err))
+				continue
+			}
+
+			observedTx[i].ContractAddress = contractAddress
+		}
+	}
+
+	return nil
+}
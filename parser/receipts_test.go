@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_attachReceipts(t *testing.T) {
+	observedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	counterpart := "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+	otherSender := "0x335295d8c90fe127932c6fe78dae6d5a4b975098"
+	otherRecipient := "0x445295d8c90fe127932c6fe78dae6d5a4b975098"
+
+	block := types.Block{
+		Number: 1,
+		Hash:   "0xb1",
+		Transactions: []types.Transaction{
+			{Hash: "0x1", BlockHash: "0xb1", From: observedAddress, To: counterpart, Value: *big.NewInt(1)},
+			{Hash: "0x2", BlockHash: "0xb1", From: otherSender, To: otherRecipient, Value: *big.NewInt(1)},
+			{Hash: "0x3", BlockHash: "0xb1", From: counterpart, To: observedAddress, Value: *big.NewInt(1)},
+		},
+	}
+
+	failed := types.Receipt{TransactionHash: "0x3", Status: types.ReceiptStatusFailed, GasUsed: 21000}
+
+	t.Run("should attach the receipts of the observed transactions only", func(t *testing.T) {
+		requests := &mock.ReceiptRequests{}
+
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.ReceiptsEthereumClient{
+			Receipts:        map[string]types.Receipt{"0x3": failed},
+			ReceiptRequests: requests,
+		}))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlock(context.TODO(), block, p.addressesRepository.IsAddressObserved, false))
+		require.Equal(t, []string{"0x1", "0x3"}, requests.Hashes())
+
+		transactions := p.GetTransactions(observedAddress)
+		require.Len(t, transactions, 2)
+
+		for _, tx := range transactions {
+			require.NotNil(t, tx.Receipt, tx.Hash)
+
+			if tx.Hash == "0x3" {
+				require.Equal(t, failed, *tx.Receipt)
+			} else {
+				require.Equal(t, types.ReceiptStatusSuccessful, tx.Receipt.Status)
+			}
+		}
+	})
+
+	t.Run("should not fetch receipts for blocks without observed transactions", func(t *testing.T) {
+		requests := &mock.ReceiptRequests{}
+
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.ReceiptsEthereumClient{
+			ReceiptRequests: requests,
+		}))
+		require.NoError(t, err)
+
+		require.NoError(t, p.processBlock(context.TODO(), block, p.addressesRepository.IsAddressObserved, false))
+		require.Empty(t, requests.Hashes())
+	})
+
+	t.Run("should fail the block when the receipts cannot be fetched", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.ReceiptsEthereumClient{
+			ReceiptsError: errors.New("receipts error"),
+		}))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		err = p.processBlock(context.TODO(), block, p.addressesRepository.IsAddressObserved, false)
+		require.ErrorContains(t, err, "receipts error")
+		require.Empty(t, p.GetTransactions(observedAddress))
+	})
+
+	t.Run("should not attach receipts when the client cannot fetch them", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlock(context.TODO(), block, p.addressesRepository.IsAddressObserved, false))
+
+		for _, tx := range p.GetTransactions(observedAddress) {
+			require.Nil(t, tx.Receipt)
+		}
+	})
+}
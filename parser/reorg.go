@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+var errReorgDetected = errors.New("chain reorganization detected")
+
+// checkBlocksSequence verifies that the fetched blocks build a chain on top of the last processed block.
+// It returns errReorgDetected when the first block does not point to the remembered hash of the
+// last processed block, meaning that the already processed blocks are not canonical anymore.
+// Otherwise, it returns how many blocks from the beginning of the sequence are linked together. A broken
+// link between two blocks of the sequence means that the chain changed while the blocks were being fetched.
+func (p *Parser) checkBlocksSequence(firstBlockNumber uint64, blocks []types.Block) (int, error) {
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+
+	if parentHash, ok := p.getBlockHash(firstBlockNumber - 1); ok && blocks[0].ParentHash != parentHash {
+		return 0, errReorgDetected
+	}
+
+	return linkedBlocksCount(blocks), nil
+}
+
+// linkedBlocksCount returns how many blocks from the beginning of the sequence are linked together
+// through their parent hash.
+func linkedBlocksCount(blocks []types.Block) int {
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].ParentHash != blocks[i-1].Hash {
+			return i
+		}
+	}
+
+	return len(blocks)
+}
+
+// handleReorg walks back from the last processed block until it finds a block whose remembered hash
+// matches the canonical one: the common ancestor. Transactions and token transfers of the orphaned blocks are
+// removed from the repositories and the sequence is moved back to the common ancestor so that the canonical chain
+// is processed again in the next iterations.
+// If the reorg is deeper than the remembered history, the parser rolls back all the remembered blocks.
+func (p *Parser) handleReorg(ctx context.Context) error {
+	lastProcessedBlock := uint64(p.GetCurrentBlock())
+	commonAncestor := lastProcessedBlock
+
+	for ; commonAncestor > 0; commonAncestor-- {
+		rememberedHash, ok := p.getBlockHash(commonAncestor)
+		if !ok {
+			p.logger.Error("reorg is deeper than the remembered history", "block", commonAncestor)
+			break
+		}
+
+		canonical, err := p.ethClient.GetBlockByNumber(ctx, commonAncestor)
+		if err != nil {
+			return fmt.Errorf("could not get block by number while looking for common ancestor: %w", err)
+		}
+
+		if canonical.Hash == rememberedHash {
+			break
+		}
+	}
+
+	for blockNumber := lastProcessedBlock; blockNumber > commonAncestor; blockNumber-- {
+		orphanedHash, ok := p.getBlockHash(blockNumber)
+		if !ok {
+			continue
+		}
+
+		if err := p.transactionsRepo.RemoveTransactionsByBlockHash(ctx, orphanedHash); err != nil {
+			return fmt.Errorf("could not remove transactions of orphaned block %d: %w", blockNumber, err)
+		}
+
+		if err := p.tokenTransfersRepo.RemoveTokenTransfersByBlockHash(ctx, orphanedHash); err != nil {
+			return fmt.Errorf("could not remove token transfers of orphaned block %d: %w", blockNumber, err)
+		}
+
+		p.forgetBlockHash(blockNumber)
+	}
+
+	if err := p.transactionsRepo.SaveLastProcessedBlock(ctx, commonAncestor); err != nil {
+		return fmt.Errorf("could not save common ancestor as last processed block: %w", err)
+	}
+
+	p.setLastProcessedBlock(commonAncestor)
+
+	p.logger.Info("rolled back to common ancestor",
+		"commonAncestor", commonAncestor, "orphanedBlocks", lastProcessedBlock-commonAncestor)
+
+	p.publishEvent(types.Event{
+		Type:           types.EventTypeReorg,
+		BlockNumber:    commonAncestor,
+		OrphanedBlocks: lastProcessedBlock - commonAncestor,
+	})
+
+	return nil
+}
+
+// rememberBlockHash stores the hash of a processed block and forgets the ones that are older
+// than the maximum reorg depth.
+func (p *Parser) rememberBlockHash(blockNumber uint64, hash string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.blockHashes[blockNumber] = hash
+
+	if blockNumber <= uint64(p.maxReorgDepth) {
+		return
+	}
+
+	for n := range p.blockHashes {
+		if n <= blockNumber-uint64(p.maxReorgDepth) {
+			delete(p.blockHashes, n)
+		}
+	}
+}
+
+func (p *Parser) getBlockHash(blockNumber uint64) (string, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	hash, ok := p.blockHashes[blockNumber]
+
+	return hash, ok
+}
+
+func (p *Parser) forgetBlockHash(blockNumber uint64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.blockHashes, blockNumber)
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_handleReorg(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("parser should roll back orphaned blocks and process the canonical chain", func(t *testing.T) {
+		chain := chainOfBlocks(1, 14, "", "a", transactions)
+		ethMock := &mock.EthereumClient{
+			MostRecentBlock: 14,
+			BlocksByNumber:  chain,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithMaxBlocksToProcessInParallel(20),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 14, p.GetCurrentBlock())
+		require.Len(t, p.GetTransactions(observedAddress), 14)
+
+		// The chain is reorganized starting from block 11.
+		for n, block := range chainOfBlocks(11, 16, chain[10].Hash, "b", transactions) {
+			chain[n] = block
+		}
+		ethMock.MostRecentBlock = 16
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 10, p.GetCurrentBlock(), "should roll back to the common ancestor")
+		require.Len(t, p.GetTransactions(observedAddress), 10)
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 16, p.GetCurrentBlock())
+
+		observed := p.GetTransactions(observedAddress)
+		require.Len(t, observed, 16)
+		for _, tx := range observed {
+			require.Equal(t, chain[tx.BlockNumber].Hash, tx.BlockHash, "should only contain canonical transactions")
+		}
+	})
+
+	t.Run("parser should roll back the remembered history when the reorg is too deep", func(t *testing.T) {
+		log := &mock.Logger{}
+		chain := chainOfBlocks(1, 14, "", "a", transactions)
+		ethMock := &mock.EthereumClient{
+			MostRecentBlock: 14,
+			BlocksByNumber:  chain,
+		}
+
+		p, err := NewParser(
+			endpoint,
+			log,
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(ethMock),
+			WithMaxBlocksToProcessInParallel(20),
+			WithMaxReorgDepth(2),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 14, p.GetCurrentBlock())
+
+		// The chain is reorganized starting from block 5, deeper than the remembered history.
+		for n, block := range chainOfBlocks(5, 15, chain[4].Hash, "b", transactions) {
+			chain[n] = block
+		}
+		ethMock.MostRecentBlock = 15
+
+		require.NoError(t, p.processBlocks(ctx))
+		require.Equal(t, 12, p.GetCurrentBlock())
+		require.Contains(t, log.GotErrors(), "reorg is deeper than the remembered history")
+		require.Len(t, p.GetTransactions(observedAddress), 12)
+	})
+
+	t.Run("parser should only process the linked part of a sequence", func(t *testing.T) {
+		chain := chainOfBlocks(1, 5, "", "a", transactions)
+		for n, block := range chainOfBlocks(3, 5, "0xunknown", "b", transactions) {
+			chain[n] = block
+		}
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 5, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		err = p.processBlocks(ctx)
+		require.ErrorContains(t, err, "block 3 is not a child of block 2")
+		require.Equal(t, 1, p.GetCurrentBlock())
+		require.Len(t, p.GetTransactions(observedAddress), 1)
+	})
+}
@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// SubscribeWithMetadata adds an address to the list of addresses to watch for transactions, attaching
+// the label and the owner of the subscription. The address is observed in its canonical lowercase form, and
+// malformed addresses are rejected with ethutil.ErrInvalidAddress. The creation time defaults to now when not set.
+func (p *Parser) SubscribeWithMetadata(ctx context.Context, subscription types.Subscription) error {
+	address, err := parseAddress(subscription.Address)
+	if err != nil {
+		return err
+	}
+
+	subscription.Address = address
+
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+
+	if err := p.addressesRepository.ObserveAddress(ctx, subscription); err != nil {
+		return fmt.Errorf("could not observe address: %w", err)
+	}
+
+	p.logger.Info("started observing address", "address", subscription.Address)
+
+	return nil
+}
+
+// Unsubscribe removes an address from the list of addresses to watch for transactions. When purge is true,
+// the transactions and token transfers history of the address is removed from the repositories as well,
+// otherwise it is kept and still returned by GetTransactions and ListTokenTransfers.
+// It returns types.ErrAddressNotFound if the address is not observed, and ethutil.ErrInvalidAddress if it is
+// malformed.
+func (p *Parser) Unsubscribe(ctx context.Context, address string, purge bool) error {
+	address, err := parseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if err := p.addressesRepository.UnobserveAddress(ctx, address); err != nil {
+		return fmt.Errorf("could not unobserve address: %w", err)
+	}
+
+	p.logger.Info("stopped observing address", "address", address)
+
+	if !purge {
+		return nil
+	}
+
+	if err := p.transactionsRepo.RemoveTransactionsByAddress(ctx, address); err != nil {
+		return fmt.Errorf("could not purge transactions of address: %w", err)
+	}
+
+	if err := p.tokenTransfersRepo.RemoveTokenTransfersByAddress(ctx, address); err != nil {
+		return fmt.Errorf("could not purge token transfers of address: %w", err)
+	}
+
+	p.logger.Info("purged transactions of address", "address", address)
+
+	return nil
+}
+
+// GetSubscription returns the subscription of an observed address. Subscribing to an address again keeps its
+// original creation time, so it can differ from the one of the last subscription.
+// It returns types.ErrAddressNotFound if the address is not observed, and ethutil.ErrInvalidAddress if it is
+// malformed.
+func (p *Parser) GetSubscription(ctx context.Context, address string) (types.Subscription, error) {
+	address, err := parseAddress(address)
+	if err != nil {
+		return types.Subscription{}, err
+	}
+
+	subscription, err := p.addressesRepository.GetObservedAddress(ctx, address)
+	if err != nil {
+		return types.Subscription{}, fmt.Errorf("could not get observed address: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListSubscriptions returns a page of the observed addresses with their metadata.
+func (p *Parser) ListSubscriptions(ctx context.Context, offset, limit int) ([]types.Subscription, error) {
+	subscriptions, err := p.addressesRepository.ListObservedAddresses(ctx, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not list observed addresses: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// addressBackfillJob is a backfill of the history of a single address.
+type addressBackfillJob struct {
+	address    string
+	sinceBlock uint64
+}
+
+// SubscribeSince adds an address to the list of addresses to watch for transactions, like Subscribe does.
+// In addition, it schedules a background backfill of the already processed blocks starting from sinceBlock,
+// so that the past transactions of the address are returned by GetTransactions as well.
+// Backfills are executed one at a time while the parser is running, the status of the backfill of an address
+// can be queried with GetBackfillStatus. It returns false if the address could not be observed or if too many
+// backfills are already scheduled.
+func (p *Parser) SubscribeSince(address string, sinceBlock uint64) bool {
+	address, err := parseAddress(address)
+	if err != nil {
+		p.logger.Error("could not observe address", "error", err)
+		return false
+	}
+
+	if !p.Subscribe(address) {
+		return false
+	}
+
+	status := types.AddressBackfillStatus{
+		Address:    address,
+		SinceBlock: sinceBlock,
+		State:      types.AddressBackfillStateQueued,
+	}
+	p.setAddressBackfillStatus(status)
+
+	select {
+	case p.addressBackfills <- addressBackfillJob{address: address, sinceBlock: sinceBlock}:
+	default:
+		p.logger.Error("could not schedule address backfill, too many backfills queued", "address", address)
+
+		status.State = types.AddressBackfillStateFailed
+		status.Error = "too many backfills queued"
+		p.setAddressBackfillStatus(status)
+
+		return false
+	}
+
+	p.logger.Info("scheduled address backfill", "address", address, "sinceBlock", sinceBlock)
+
+	return true
+}
+
+// GetBackfillStatus returns the status of the last backfill scheduled for an address with SubscribeSince.
+// The returned bool is false if no backfill was ever scheduled for the address, or if it is malformed.
+func (p *Parser) GetBackfillStatus(address string) (types.AddressBackfillStatus, bool) {
+	address, err := parseAddress(address)
+	if err != nil {
+		return types.AddressBackfillStatus{}, false
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	status, ok := p.addressBackfillStatuses[address]
+
+	return status, ok
+}
+
+// runAddressBackfills executes the scheduled address backfills until the context is canceled.
+func (p *Parser) runAddressBackfills(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.addressBackfills:
+			p.runAddressBackfill(ctx, job)
+		}
+	}
+}
+
+// runAddressBackfill processes the blocks from the job starting block to the last processed block, saving
+// only the transactions of the job address. Newer blocks are processed by the live loop, which is already
+// observing the address.
+func (p *Parser) runAddressBackfill(ctx context.Context, job addressBackfillJob) {
+	status := types.AddressBackfillStatus{
+		Address:    job.address,
+		SinceBlock: job.sinceBlock,
+		State:      types.AddressBackfillStateRunning,
+	}
+
+	lastProcessedBlock := uint64(p.GetCurrentBlock())
+	if lastProcessedBlock < job.sinceBlock {
+		status.State = types.AddressBackfillStateCompleted
+		p.setAddressBackfillStatus(status)
+		return
+	}
+
+	p.setAddressBackfillStatus(status)
+
+	isJobAddress := func(_ context.Context, address string) (bool, error) {
+		return address == job.address, nil
+	}
+
+	err := p.backfill(ctx, job.sinceBlock, lastProcessedBlock, isJobAddress, func(progress types.BackfillProgress) {
+		status.Progress = progress
+		p.setAddressBackfillStatus(status)
+	})
+	if err != nil {
+		p.logger.Error("could not backfill address", "address", job.address, "error", err)
+
+		status.State = types.AddressBackfillStateFailed
+		status.Error = err.Error()
+		p.setAddressBackfillStatus(status)
+
+		return
+	}
+
+	status.State = types.AddressBackfillStateCompleted
+	p.setAddressBackfillStatus(status)
+}
+
+func (p *Parser) setAddressBackfillStatus(status types.AddressBackfillStatus) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.addressBackfillStatuses[status.Address] = status
+}
@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_SubscribeSince(t *testing.T) {
+	address0 := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	address1 := "0x995295d8C90Fe127932C6Fe78dae6D5A4B975099"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  address0,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975099",
+			From:  address1,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975099",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("parser should backfill the history of a new address", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithNoNewBlocksPause(time.Millisecond),
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{
+				MostRecentBlock: 20,
+				BlocksByNumber:  chainOfBlocks(1, 20, "", "a", transactions),
+			}),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			err := p.Run(ctx)
+			require.NoError(t, err)
+			wg.Done()
+		}()
+
+		require.Eventually(t, func() bool {
+			return p.GetCurrentBlock() == 20
+		}, time.Second*2, time.Millisecond*10)
+
+		require.Empty(t, p.GetTransactions(address0))
+
+		_, ok := p.GetBackfillStatus(address0)
+		require.False(t, ok)
+
+		require.True(t, p.SubscribeSince(address0, 5))
+
+		require.Eventually(t, func() bool {
+			status, ok := p.GetBackfillStatus(address0)
+			return ok && status.State == types.AddressBackfillStateCompleted
+		}, time.Second*2, time.Millisecond*10)
+
+		status, _ := p.GetBackfillStatus(address0)
+		require.Equal(t, types.BackfillProgress{
+			From:               5,
+			To:                 20,
+			LastProcessedBlock: 20,
+			ProcessedBlocks:    16,
+		}, status.Progress)
+		require.Empty(t, status.Error)
+
+		require.Len(t, p.GetTransactions(address0), 16)
+		require.Empty(t, p.GetTransactions(address1), "only the subscribed address should be backfilled")
+
+		cancel()
+		wg.Wait()
+	})
+
+	t.Run("backfill should be queued until the parser runs", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		require.True(t, p.SubscribeSince(address0, 5))
+
+		status, ok := p.GetBackfillStatus(address0)
+		require.True(t, ok)
+		require.Equal(t, types.AddressBackfillStateQueued, status.State)
+		require.Equal(t, uint64(5), status.SinceBlock)
+	})
+
+	t.Run("backfill should complete when there is nothing to process", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		p.runAddressBackfill(context.TODO(), addressBackfillJob{address: strings.ToLower(address0), sinceBlock: 5})
+
+		status, ok := p.GetBackfillStatus(address0)
+		require.True(t, ok)
+		require.Equal(t, types.AddressBackfillStateCompleted, status.State)
+	})
+
+	t.Run("parser should not schedule too many backfills", func(t *testing.T) {
+		log := &mock.Logger{}
+
+		p, err := NewParser(endpoint, log, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+
+		p.addressBackfills = make(chan addressBackfillJob, 1)
+
+		require.True(t, p.SubscribeSince(address0, 5))
+		require.False(t, p.SubscribeSince(address1, 5))
+		require.Contains(t, log.GotErrors(), "could not schedule address backfill, too many backfills queued")
+
+		status, ok := p.GetBackfillStatus(address1)
+		require.True(t, ok)
+		require.Equal(t, types.AddressBackfillStateFailed, status.State)
+	})
+}
+
+func TestParser_subscriptionLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	address0 := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	address1 := "0x225295d8C90fe127932c6fe78dae6D5a4B975098"
+	tx := types.Transaction{
+		Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+		From:  address0,
+		To:    address1,
+		Value: *big.NewInt(123),
+	}
+
+	newParser := func(t *testing.T) *Parser {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{}),
+		)
+		require.NoError(t, err)
+
+		return p
+	}
+
+	t.Run("parser should subscribe with metadata", func(t *testing.T) {
+		p := newParser(t)
+
+		err := p.SubscribeWithMetadata(ctx, types.Subscription{Address: address0, Label: "hot wallet", Owner: "treasury"})
+		require.NoError(t, err)
+
+		subscriptions, err := p.ListSubscriptions(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		require.Equal(t, "hot wallet", subscriptions[0].Label)
+		require.Equal(t, "treasury", subscriptions[0].Owner)
+		require.False(t, subscriptions[0].CreatedAt.IsZero())
+
+		subscription, err := p.GetSubscription(ctx, address0)
+		require.NoError(t, err)
+		require.Equal(t, subscriptions[0], subscription)
+
+		_, err = p.GetSubscription(ctx, address1)
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+
+		_, err = p.GetSubscription(ctx, "0xowner")
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+	})
+
+	t.Run("parser should observe the canonical form of the address", func(t *testing.T) {
+		p := newParser(t)
+		require.True(t, p.Subscribe(address0))
+
+		subscriptions, err := p.ListSubscriptions(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		require.Equal(t, strings.ToLower(address0), subscriptions[0].Address)
+	})
+
+	t.Run("parser should reject malformed addresses", func(t *testing.T) {
+		p := newParser(t)
+
+		for _, address := range []string{"", "0xowner", "995295d8c90fe127932c6fe78dae6d5a4b975098",
+			"0x995295d8C90Fe127932C6fE78daE6D5a4B975098"} {
+			err := p.SubscribeWithMetadata(ctx, types.Subscription{Address: address})
+			require.ErrorIs(t, err, ethutil.ErrInvalidAddress, address)
+			require.False(t, p.Subscribe(address), address)
+			require.False(t, p.SubscribeSince(address, 1), address)
+		}
+
+		require.ErrorIs(t, p.Unsubscribe(ctx, "0xowner", false), ethutil.ErrInvalidAddress)
+
+		_, ok := p.GetBackfillStatus("0xowner")
+		require.False(t, ok)
+
+		subscriptions, err := p.ListSubscriptions(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, subscriptions)
+	})
+
+	t.Run("parser should unsubscribe and keep the history", func(t *testing.T) {
+		p := newParser(t)
+		require.True(t, p.Subscribe(address0))
+		require.NoError(t, p.processBlock(ctx, types.Block{Transactions: []types.Transaction{tx}},
+			p.addressesRepository.IsAddressObserved, false))
+
+		require.NoError(t, p.Unsubscribe(ctx, address0, false))
+		require.Len(t, p.GetTransactions(address0), 1)
+
+		subscriptions, err := p.ListSubscriptions(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Empty(t, subscriptions)
+	})
+
+	t.Run("parser should unsubscribe and purge the history", func(t *testing.T) {
+		p := newParser(t)
+		require.True(t, p.Subscribe(address0))
+		require.NoError(t, p.processBlock(ctx, types.Block{Transactions: []types.Transaction{tx}},
+			p.addressesRepository.IsAddressObserved, false))
+
+		require.NoError(t, p.Unsubscribe(ctx, address0, true))
+		require.Empty(t, p.GetTransactions(address0))
+		require.Len(t, p.GetTransactions(address1), 1, "counterpart history should be kept")
+	})
+
+	t.Run("parser should error when unsubscribing an unknown address", func(t *testing.T) {
+		p := newParser(t)
+
+		err := p.Unsubscribe(ctx, address0, true)
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+	})
+
+	t.Run("parser should error when purging fails", func(t *testing.T) {
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(mock.TransactionsRepository{RemoveError: errors.New("remove error")}),
+			WithEthereumClient(mock.EthereumClient{}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(address0))
+
+		err = p.Unsubscribe(ctx, address0, true)
+		require.ErrorContains(t, err, "could not purge transactions of address: remove error")
+	})
+}
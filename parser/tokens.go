@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// processTokenTransfers fetches the token transfers of a block and saves the ones sent or received by an
+// observed address. It does nothing unless token transfers are enabled (see WithTokenTransfers).
+func (p *Parser) processTokenTransfers(
+	ctx context.Context,
+	blockHash string,
+	isObserved addressFilter,
+) ([]types.TokenTransfer, error) {
+	if !p.tokenTransfersEnabled {
+		return nil, nil
+	}
+
+	transfers, err := p.ethClient.(EthereumTokenTransfersClient).GetTokenTransfers(ctx, blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("could not get token transfers of block %s: %w", blockHash, err)
+	}
+
+	var observed []types.TokenTransfer
+
+	for _, transfer := range transfers {
+		// Like malformed transactions, malformed transfers are treated as unobserved instead of failing the block.
+		if transfer.From, err = parseAddress(transfer.From); err != nil {
+			p.logger.Error("skipping malformed token transfer", "error",
+				fmt.Errorf("could not parse sender of token transfer %s: %w", transfer.TransactionHash, err))
+			continue
+		}
+
+		if transfer.To, err = parseAddress(transfer.To); err != nil {
+			p.logger.Error("skipping malformed token transfer", "error",
+				fmt.Errorf("could not parse recipient of token transfer %s: %w", transfer.TransactionHash, err))
+			continue
+		}
+
+		okFrom, err := isObserved(ctx, transfer.From)
+		if err != nil {
+			return nil, fmt.Errorf("could not check if address `from` is observed: %w", err)
+		}
+
+		okTo, err := isObserved(ctx, transfer.To)
+		if err != nil {
+			return nil, fmt.Errorf("could not check if address `to` is observed: %w", err)
+		}
+
+		if okFrom || okTo {
+			observed = append(observed, transfer)
+		}
+	}
+
+	if err := p.tokenTransfersRepo.SaveTokenTransfers(ctx, observed); err != nil {
+		return nil, fmt.Errorf("could not save token transfers: %w", err)
+	}
+
+	return observed, nil
+}
+
+// GetTokenTransfers returns the token transfers sent or received by an address.
+// It always returns nil if the parser was not created with the WithTokenTransfers option, or if the address
+// is malformed.
+func (p *Parser) GetTokenTransfers(address string) []types.TokenTransfer {
+	address, err := parseAddress(address)
+	if err != nil {
+		p.logger.Error("could not get token transfers", "error", err)
+		return nil
+	}
+
+	transfers, err := p.tokenTransfersRepo.GetTokenTransfers(context.Background(), address)
+	if err != nil {
+		if errors.Is(err, types.ErrAddressNotFound) {
+			return nil
+		}
+
+		p.logger.Error("could not get token transfers", "error", err)
+		return nil
+	}
+
+	return transfers
+}
+
+// ListTokenTransfers returns a page of the token transfers of an address ordered by block number and position
+// in the block, so that pages are stable while new blocks are processed.
+// It returns types.ErrAddressNotFound if there are no token transfers for the address, and
+// ethutil.ErrInvalidAddress if it is malformed.
+func (p *Parser) ListTokenTransfers(
+	ctx context.Context,
+	address string,
+	offset, limit int,
+) ([]types.TokenTransfer, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("%w: offset and limit must not be negative", types.ErrInvalidPagination)
+	}
+
+	address, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := p.tokenTransfersRepo.GetTokenTransfers(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not get token transfers: %w", err)
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+
+		if transfers[i].LogIndex != transfers[j].LogIndex {
+			return transfers[i].LogIndex < transfers[j].LogIndex
+		}
+
+		return transfers[i].BatchIndex < transfers[j].BatchIndex
+	})
+
+	if offset >= len(transfers) {
+		return []types.TokenTransfer{}, nil
+	}
+
+	end := min(offset+limit, len(transfers))
+
+	return transfers[offset:end], nil
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_tokenTransfers(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	sender := "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+	otherSender := "0x335295d8c90fe127932c6fe78dae6d5a4b975098"
+	otherRecipient := "0x445295d8c90fe127932c6fe78dae6d5a4b975098"
+	block := types.Block{Number: 1, Hash: "0xb1"}
+
+	// The token contract is the `to` of the transactions, so only the logs tell that the observed
+	// address received tokens.
+	transfers := []types.TokenTransfer{
+		{
+			BlockHash: "0xb1", BlockNumber: 1, TransactionHash: "0x1", LogIndex: 4, BatchIndex: 1,
+			Token: "0xtoken", Standard: types.TokenStandardERC1155, From: sender, To: observedAddress,
+			Amount: *big.NewInt(1), TokenID: *big.NewInt(8),
+		},
+		{
+			BlockHash: "0xb1", BlockNumber: 1, TransactionHash: "0x2", LogIndex: 1,
+			Token: "0xtoken", Standard: types.TokenStandardERC20, From: otherSender, To: otherRecipient,
+			Amount: *big.NewInt(10),
+		},
+		{
+			BlockHash: "0xb1", BlockNumber: 1, TransactionHash: "0x1", LogIndex: 4,
+			Token: "0xtoken", Standard: types.TokenStandardERC1155, From: sender, To: observedAddress,
+			Amount: *big.NewInt(5), TokenID: *big.NewInt(7),
+		},
+	}
+
+	newParser := func(t *testing.T, client EthereumClient) *Parser {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(client), WithTokenTransfers(true))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		return p
+	}
+
+	tokensClient := mock.TokenTransfersEthereumClient{
+		EthereumClient: mock.EthereumClient{BlockByNumber: types.Block{Number: 1, Hash: "0xreorged"}},
+		TokenTransfers: map[string][]types.TokenTransfer{"0xb1": transfers},
+	}
+
+	t.Run("should error when the client cannot fetch token transfers", func(t *testing.T) {
+		_, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}), WithTokenTransfers(true))
+		require.ErrorContains(t, err, "does not support token transfers")
+	})
+
+	t.Run("should save and publish the transfers of the observed addresses", func(t *testing.T) {
+		p := newParser(t, tokensClient)
+
+		eventsCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events := p.Events(eventsCtx)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		listed, err := p.ListTokenTransfers(ctx, observedAddress, 0, 10)
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{transfers[2], transfers[0]}, listed, "should be ordered")
+
+		listed, err = p.ListTokenTransfers(ctx, observedAddress, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, []types.TokenTransfer{transfers[0]}, listed)
+
+		require.Empty(t, p.GetTokenTransfers(otherRecipient), "should ignore the transfers of other addresses")
+
+		var published []types.TokenTransfer
+		for range transfers[:2] {
+			event := <-events
+			require.Equal(t, types.EventTypeTokenTransfer, event.Type)
+			published = append(published, event.TokenTransfer)
+		}
+		require.ElementsMatch(t, []types.TokenTransfer{transfers[0], transfers[2]}, published)
+	})
+
+	t.Run("should error because of invalid pagination, malformed or unknown address", func(t *testing.T) {
+		p := newParser(t, tokensClient)
+
+		_, err := p.ListTokenTransfers(ctx, observedAddress, -1, 10)
+		require.ErrorIs(t, err, types.ErrInvalidPagination)
+
+		_, err = p.ListTokenTransfers(ctx, observedAddress, 0, 10)
+		require.ErrorIs(t, err, types.ErrAddressNotFound)
+
+		_, err = p.ListTokenTransfers(ctx, "0xtoken", 0, 10)
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+	})
+
+	t.Run("should skip the malformed transfers", func(t *testing.T) {
+		malformed := transfers[0]
+		malformed.From = "0xnode"
+
+		p := newParser(t, mock.TokenTransfersEthereumClient{
+			TokenTransfers: map[string][]types.TokenTransfer{"0xb1": {malformed, transfers[2]}},
+		})
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+		require.Equal(t, []types.TokenTransfer{transfers[2]}, p.GetTokenTransfers(observedAddress))
+	})
+
+	t.Run("should fail the block when the transfers cannot be fetched", func(t *testing.T) {
+		p := newParser(t, mock.TokenTransfersEthereumClient{TokenTransfersError: errors.New("logs error")})
+
+		err := p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false)
+		require.ErrorContains(t, err, "logs error")
+	})
+
+	t.Run("should not fetch transfers when disabled", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.TokenTransfersEthereumClient{
+			TokenTransfersError: errors.New("logs error"),
+		}))
+		require.NoError(t, err)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+	})
+
+	t.Run("should remove the transfers of orphaned blocks", func(t *testing.T) {
+		p := newParser(t, tokensClient)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+		p.rememberBlockHash(1, "0xb1")
+		p.setLastProcessedBlock(1)
+
+		require.NoError(t, p.handleReorg(ctx))
+		require.Empty(t, p.GetTokenTransfers(observedAddress))
+	})
+
+	t.Run("should purge the transfers of an unsubscribed address", func(t *testing.T) {
+		p := newParser(t, tokensClient)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+		require.NoError(t, p.Unsubscribe(ctx, observedAddress, true))
+		require.Empty(t, p.GetTokenTransfers(observedAddress))
+		require.Len(t, p.GetTokenTransfers(sender), 2, "counterpart history should be kept")
+	})
+}
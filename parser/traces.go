@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// processInternalTransfers traces the transactions of a block and returns the internal transfers sent or received
+// by an observed address. It does nothing unless internal transfers are enabled (see WithInternalTransfers).
+func (p *Parser) processInternalTransfers(
+	ctx context.Context,
+	block types.Block,
+	isObserved addressFilter,
+) ([]types.Transaction, error) {
+	if !p.internalTransfersEnabled || len(block.Transactions) == 0 {
+		return nil, nil
+	}
+
+	transfers, err := p.ethClient.(EthereumTracesClient).GetInternalTransfers(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("could not get internal transfers of block %d: %w", block.Number, err)
+	}
+
+	return p.processAndFilterObservedTransactions(ctx, transfers, isObserved)
+}
+
+func isInternal(tx types.Transaction) bool {
+	return tx.Kind == types.TransactionKindInternal
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_internalTransfers(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	owner := "0x1e0049783f008a0085193e00003d00cd54003c71"
+	fee := "0x28c6c06298d514db089934071355e5743bf21d60"
+	multisig := "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+
+	// The observed address is paid by the multisig while executing a transaction sent by another account.
+	withdrawal := types.Transaction{
+		Kind: types.TransactionKindExternal, BlockHash: "0xb1", BlockNumber: 1, Hash: "0x1",
+		From: owner, To: multisig,
+	}
+	block := types.Block{Number: 1, Hash: "0xb1", Transactions: []types.Transaction{withdrawal}}
+
+	internal := func(traceIndex int, from, to string) types.Transaction {
+		return types.Transaction{
+			Kind: types.TransactionKindInternal, BlockHash: "0xb1", BlockNumber: 1, Hash: "0x1",
+			TraceIndex: traceIndex, From: from, To: to, Value: *big.NewInt(int64(traceIndex + 1)),
+		}
+	}
+
+	tracesClient := mock.TracesEthereumClient{
+		InternalTransfers: map[string][]types.Transaction{"0xb1": {
+			internal(0, multisig, fee),
+			internal(1, multisig, observedAddress),
+			internal(2, multisig, observedAddress),
+		}},
+	}
+
+	newParser := func(t *testing.T, client EthereumClient) *Parser {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(client), WithInternalTransfers(true))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		return p
+	}
+
+	t.Run("should error when the client cannot trace", func(t *testing.T) {
+		_, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}),
+			WithInternalTransfers(true))
+		require.ErrorContains(t, err, "does not support traces")
+	})
+
+	t.Run("should save the internal transfers of the observed addresses", func(t *testing.T) {
+		p := newParser(t, tracesClient)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		transactions, err := p.ListTransactions(ctx, observedAddress, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, transactions, 2, "transfers of the same transaction should not overwrite each other")
+
+		for i, tx := range transactions {
+			expected := internal(i+1, multisig, observedAddress)
+			expected.ConfirmationStatus = types.ConfirmationStatusFinal
+			require.Equal(t, expected, tx)
+		}
+
+		require.Empty(t, p.GetTransactions(fee), "should ignore the transfers of other addresses")
+	})
+
+	t.Run("should list a transaction before its internal transfers", func(t *testing.T) {
+		p := newParser(t, tracesClient)
+		require.True(t, p.Subscribe(owner))
+		require.True(t, p.Subscribe(fee))
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		transactions, err := p.ListTransactions(ctx, multisig, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, transactions, 4)
+		require.Equal(t, types.TransactionKindExternal, transactions[0].Kind)
+
+		for i, tx := range transactions[1:] {
+			require.Equal(t, types.TransactionKindInternal, tx.Kind)
+			require.Equal(t, i, tx.TraceIndex)
+		}
+	})
+
+	t.Run("should deliver every internal transfer to the webhooks", func(t *testing.T) {
+		p := newParser(t, tracesClient)
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, "https://example.com/hook", "secret"))
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.NotEqual(t, deliveries[0].ID, deliveries[1].ID)
+	})
+
+	t.Run("should fail the block when the transactions cannot be traced", func(t *testing.T) {
+		p := newParser(t, mock.TracesEthereumClient{TracesError: errors.New("tracing not enabled")})
+
+		err := p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false)
+		require.ErrorContains(t, err, "tracing not enabled")
+	})
+
+	t.Run("should not trace when disabled", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.TracesEthereumClient{
+			TracesError: errors.New("tracing not enabled"),
+		}))
+		require.NoError(t, err)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+	})
+}
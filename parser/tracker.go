@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// blockFailure keeps track of the failed attempts to fetch a block.
+type blockFailure struct {
+	attempts int
+	retryAt  time.Time
+}
+
+// blocksTracker tracks the progress of the blocks that are ahead of the last processed block.
+// Fetched blocks are kept until they become part of the contiguous sequence that follows the last
+// processed block, so that they are never fetched twice. Blocks that could not be fetched are
+// retried with an exponential backoff.
+type blocksTracker struct {
+	fetched    map[uint64]types.Block
+	failures   map[uint64]blockFailure
+	backoff    time.Duration
+	maxBackoff time.Duration
+	sync.RWMutex
+}
+
+func newBlocksTracker(backoff, maxBackoff time.Duration) *blocksTracker {
+	return &blocksTracker{
+		fetched:    make(map[uint64]types.Block),
+		failures:   make(map[uint64]blockFailure),
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// blocksToFetch returns the block numbers in the range [from, to] that are neither fetched
+// nor waiting for their retry backoff to expire.
+func (b *blocksTracker) blocksToFetch(from, to uint64, now time.Time) []uint64 {
+	b.RLock()
+	defer b.RUnlock()
+
+	var blockNumbers []uint64
+
+	for n := from; n <= to; n++ {
+		if _, ok := b.fetched[n]; ok {
+			continue
+		}
+
+		if failure, ok := b.failures[n]; ok && now.Before(failure.retryAt) {
+			continue
+		}
+
+		blockNumbers = append(blockNumbers, n)
+	}
+
+	return blockNumbers
+}
+
+// contiguousBlocks returns the fetched blocks in the range [from, to] that form a contiguous
+// sequence starting from `from`. It stops at the first block that has not been fetched yet.
+func (b *blocksTracker) contiguousBlocks(from, to uint64) []types.Block {
+	b.RLock()
+	defer b.RUnlock()
+
+	var blocks []types.Block
+
+	for n := from; n <= to; n++ {
+		block, ok := b.fetched[n]
+		if !ok {
+			break
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+func (b *blocksTracker) setFetched(blockNumber uint64, block types.Block) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.fetched[blockNumber] = block
+	delete(b.failures, blockNumber)
+}
+
+// setFailed records a failed attempt and schedules the next one with an exponential backoff.
+// A block that was fetched but could not be used is fetched again after the backoff.
+func (b *blocksTracker) setFailed(blockNumber uint64, now time.Time) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.fetched, blockNumber)
+
+	failure := b.failures[blockNumber]
+	failure.attempts++
+
+	failure.retryAt = now.Add(exponentialBackoff(b.backoff, b.maxBackoff, failure.attempts))
+	b.failures[blockNumber] = failure
+}
+
+// nextRetryIn returns how long to wait before the first failed block can be retried.
+func (b *blocksTracker) nextRetryIn(now time.Time) (time.Duration, bool) {
+	b.RLock()
+	defer b.RUnlock()
+
+	var (
+		next  time.Duration
+		found bool
+	)
+
+	for _, failure := range b.failures {
+		wait := failure.retryAt.Sub(now)
+		if !found || wait < next {
+			next = wait
+			found = true
+		}
+	}
+
+	return next, found
+}
+
+// forget drops the tracked state of a block, it will be fetched again if needed.
+func (b *blocksTracker) forget(blockNumber uint64) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.fetched, blockNumber)
+	delete(b.failures, blockNumber)
+}
+
+// reset drops the tracked state of all the blocks.
+func (b *blocksTracker) reset() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.fetched = make(map[uint64]types.Block)
+	b.failures = make(map[uint64]blockFailure)
+}
+
+// exponentialBackoff returns the delay before the next attempt: the backoff doubles after
+// each failed attempt, up to maxBackoff.
+func exponentialBackoff(backoff, maxBackoff time.Duration, attempts int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay
+}
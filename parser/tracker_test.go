@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestBlocksTracker(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should return the blocks to fetch", func(t *testing.T) {
+		tracker := newBlocksTracker(time.Second, time.Minute)
+		tracker.setFetched(2, types.Block{Number: 2})
+		tracker.setFailed(3, now)
+
+		require.Equal(t, []uint64{1, 4}, tracker.blocksToFetch(1, 4, now))
+		require.Equal(t, []uint64{1, 3, 4}, tracker.blocksToFetch(1, 4, now.Add(time.Second)))
+	})
+
+	t.Run("should return the contiguous fetched blocks", func(t *testing.T) {
+		tracker := newBlocksTracker(time.Second, time.Minute)
+		tracker.setFetched(1, types.Block{Number: 1})
+		tracker.setFetched(2, types.Block{Number: 2})
+		tracker.setFetched(4, types.Block{Number: 4})
+
+		require.Equal(t, []types.Block{{Number: 1}, {Number: 2}}, tracker.contiguousBlocks(1, 4))
+		require.Empty(t, tracker.contiguousBlocks(3, 4))
+
+		tracker.forget(2)
+		require.Equal(t, []types.Block{{Number: 1}}, tracker.contiguousBlocks(1, 4))
+
+		tracker.reset()
+		require.Empty(t, tracker.contiguousBlocks(1, 4))
+	})
+
+	t.Run("should back off exponentially up to the max backoff", func(t *testing.T) {
+		tracker := newBlocksTracker(time.Second, time.Second*5)
+
+		expectedWaits := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5}
+		for _, expected := range expectedWaits {
+			tracker.setFailed(1, now)
+
+			wait, ok := tracker.nextRetryIn(now)
+			require.True(t, ok)
+			require.Equal(t, expected, wait)
+		}
+
+		tracker.setFetched(1, types.Block{Number: 1})
+
+		_, ok := tracker.nextRetryIn(now)
+		require.False(t, ok, "fetched blocks should not be retried")
+	})
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/internal/webhook"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// RegisterWebhook registers a URL that receives a signed JSON payload for every transaction involving the
+// address, including the ones indexed by backfills. The payload is signed with an HMAC-SHA256 computed with
+// the secret and sent in the `X-Ethparser-Signature` header. Deliveries are retried with an exponential
+// backoff and dead-lettered after the configured number of attempts (see WithWebhookRetries).
+// It returns ethutil.ErrInvalidAddress if the address is malformed.
+func (p *Parser) RegisterWebhook(ctx context.Context, address, webhookURL, secret string) error {
+	address, err := parseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", types.ErrInvalidWebhook)
+	}
+
+	if secret == "" {
+		return fmt.Errorf("%w: secret is required", types.ErrInvalidWebhook)
+	}
+
+	if err := p.webhooksRepo.SaveWebhook(ctx, types.Webhook{
+		Address:   address,
+		URL:       webhookURL,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("could not save webhook: %w", err)
+	}
+
+	p.logger.Info("registered webhook", "address", address, "url", webhookURL)
+
+	return nil
+}
+
+// RemoveWebhook removes a webhook of an address. Its pending deliveries are dead-lettered.
+// It returns ethutil.ErrInvalidAddress if the address is malformed.
+func (p *Parser) RemoveWebhook(ctx context.Context, address, webhookURL string) error {
+	address, err := parseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if err := p.webhooksRepo.RemoveWebhook(ctx, address, webhookURL); err != nil {
+		return fmt.Errorf("could not remove webhook: %w", err)
+	}
+
+	p.logger.Info("removed webhook", "address", address, "url", webhookURL)
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the delivery log of the webhooks of an address.
+// It returns ethutil.ErrInvalidAddress if the address is malformed.
+func (p *Parser) GetWebhookDeliveries(ctx context.Context, address string) ([]types.WebhookDelivery, error) {
+	address, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := p.webhooksRepo.GetDeliveries(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// enqueueWebhookDeliveries adds to the outbox a delivery for every webhook of the addresses involved in
+// the observed transactions, for the blocks processed by the live loop and by backfills alike. It is part of
+// the block processing, so a failure causes the block to be processed again: the deterministic delivery IDs make
+// sure that a transaction is never delivered twice to a webhook, even when a backfill and the live loop both
+// process its block.
+func (p *Parser) enqueueWebhookDeliveries(ctx context.Context, transactions []types.Transaction) error {
+	var deliveries []types.WebhookDelivery
+
+	now := time.Now()
+
+	for _, tx := range transactions {
+		for _, address := range involvedAddresses(tx) {
+			webhooks, err := p.webhooksRepo.GetWebhooks(ctx, address)
+			if err != nil {
+				return fmt.Errorf("could not get webhooks: %w", err)
+			}
+
+			for _, w := range webhooks {
+				deliveryID := webhook.DeliveryID(address, w.URL, tx.ID())
+
+				payload, err := webhook.NewPayload(deliveryID, address, tx)
+				if err != nil {
+					return fmt.Errorf("could not create webhook payload: %w", err)
+				}
+
+				deliveries = append(deliveries, types.WebhookDelivery{
+					ID:              deliveryID,
+					Address:         address,
+					URL:             w.URL,
+					TransactionHash: tx.Hash,
+					Payload:         payload,
+					Status:          types.WebhookDeliveryStatusPending,
+					NextAttemptAt:   now,
+					CreatedAt:       now,
+				})
+			}
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := p.webhooksRepo.EnqueueDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("could not enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// involvedAddresses returns the distinct addresses involved in a transaction: the sender, when there is one, and
+// the recipient, or the deployed contract for the contract creations.
+func involvedAddresses(tx types.Transaction) []string {
+	recipient := tx.To
+	if recipient == "" {
+		recipient = tx.ContractAddress
+	}
+
+	var addresses []string
+	if tx.From != "" {
+		addresses = append(addresses, tx.From)
+	}
+
+	if recipient != "" && recipient != tx.From {
+		addresses = append(addresses, recipient)
+	}
+
+	return addresses
+}
+
+// runWebhookDeliveries periodically delivers the due webhook deliveries until the context is canceled.
+func (p *Parser) runWebhookDeliveries(ctx context.Context) {
+	ticker := time.NewTicker(p.webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.deliverDueWebhooks(ctx); err != nil {
+				p.logger.Error("could not deliver webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// deliverDueWebhooks sends the due deliveries of the outbox one by one and records the outcome of each attempt.
+func (p *Parser) deliverDueWebhooks(ctx context.Context) error {
+	deliveries, err := p.webhooksRepo.GetDueDeliveries(ctx, time.Now(), defaultWebhookDeliveriesBatchSize)
+	if err != nil {
+		return fmt.Errorf("could not get due deliveries: %w", err)
+	}
+
+	var errs []error
+	for _, delivery := range deliveries {
+		if err := p.deliverWebhook(ctx, delivery); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Parser) deliverWebhook(ctx context.Context, delivery types.WebhookDelivery) error {
+	delivery.Attempts++
+
+	sendErr := p.sendWebhook(ctx, delivery)
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = types.WebhookDeliveryStatusDelivered
+		delivery.DeliveredAt = time.Now()
+		delivery.LastError = ""
+	case delivery.Attempts >= p.webhookMaxAttempts || errors.Is(sendErr, types.ErrWebhookNotFound):
+		p.logger.Error("webhook delivery dead-lettered",
+			"id", delivery.ID, "url", delivery.URL, "attempts", delivery.Attempts, "error", sendErr)
+
+		delivery.Status = types.WebhookDeliveryStatusDead
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = time.Now().Add(
+			exponentialBackoff(p.webhookRetryBackoff, p.maxWebhookRetryBackoff, delivery.Attempts),
+		)
+	}
+
+	if err := p.webhooksRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("could not update delivery %s: %w", delivery.ID, err)
+	}
+
+	return nil
+}
+
+// sendWebhook looks up the webhook of the delivery, to get its current secret, and sends the delivery.
+func (p *Parser) sendWebhook(ctx context.Context, delivery types.WebhookDelivery) error {
+	webhooks, err := p.webhooksRepo.GetWebhooks(ctx, delivery.Address)
+	if err != nil {
+		return fmt.Errorf("could not get webhooks: %w", err)
+	}
+
+	for _, w := range webhooks {
+		if w.URL == delivery.URL {
+			return p.webhookSender.Send(ctx, w, delivery)
+		}
+	}
+
+	return types.ErrWebhookNotFound
+}
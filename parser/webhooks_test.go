@@ -0,0 +1,270 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/internal/webhook"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// webhookReceiver is a test webhook endpoint that records the signed payloads it receives.
+type webhookReceiver struct {
+	statusCode int
+	payloads   [][]byte
+	signatures []string
+	sync.Mutex
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	r.payloads = append(r.payloads, body)
+	r.signatures = append(r.signatures, req.Header.Get(webhook.SignatureHeader))
+
+	w.WriteHeader(r.statusCode)
+}
+
+func (r *webhookReceiver) received() int {
+	r.Lock()
+	defer r.Unlock()
+
+	return len(r.payloads)
+}
+
+func TestParser_RegisterWebhook(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+
+	t.Run("should register and remove a webhook", func(t *testing.T) {
+		repo := storage.NewWebhooksRepository()
+
+		p, err := NewParser(endpoint, &mock.Logger{}, WithWebhooksRepo(repo))
+		require.NoError(t, err)
+
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, "https://example.com/hook", "secret"))
+
+		webhooks, err := repo.GetWebhooks(ctx, strings.ToLower(observedAddress))
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		require.Equal(t, "https://example.com/hook", webhooks[0].URL)
+		require.False(t, webhooks[0].CreatedAt.IsZero())
+
+		require.NoError(t, p.RemoveWebhook(ctx, observedAddress, "https://example.com/hook"))
+		require.ErrorIs(t, p.RemoveWebhook(ctx, observedAddress, "https://example.com/hook"), types.ErrWebhookNotFound)
+	})
+
+	t.Run("should reject invalid webhooks", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{})
+		require.NoError(t, err)
+
+		for _, webhookURL := range []string{"", "example.com", "ftp://example.com", "http://", "://bad"} {
+			err := p.RegisterWebhook(ctx, observedAddress, webhookURL, "secret")
+			require.ErrorIs(t, err, types.ErrInvalidWebhook, webhookURL)
+		}
+
+		err = p.RegisterWebhook(ctx, observedAddress, "https://example.com/hook", "")
+		require.ErrorIs(t, err, types.ErrInvalidWebhook)
+	})
+
+	t.Run("should reject malformed addresses", func(t *testing.T) {
+		p, err := NewParser(endpoint, &mock.Logger{})
+		require.NoError(t, err)
+
+		err = p.RegisterWebhook(ctx, "0xowner", "https://example.com/hook", "secret")
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+
+		err = p.RemoveWebhook(ctx, "0xowner", "https://example.com/hook")
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+
+		_, err = p.GetWebhookDeliveries(ctx, "0xowner")
+		require.ErrorIs(t, err, ethutil.ErrInvalidAddress)
+	})
+}
+
+func TestParser_webhookDeliveries(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	transactions := []types.Transaction{
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975098",
+			From:  observedAddress,
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975098",
+			Value: *big.NewInt(123),
+		},
+		{
+			Hash:  "0x005295d8C90Fe127932C6fE78daE6D5a4B975099",
+			From:  "0x995295d8c90fe127932c6fe78dae6d5a4b975099",
+			To:    "0x225295d8c90fe127932c6fe78dae6d5a4b975099",
+			Value: *big.NewInt(123),
+		},
+	}
+
+	t.Run("should deliver signed payloads of observed transactions once", func(t *testing.T) {
+		receiver := &webhookReceiver{statusCode: http.StatusOK}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		chain := chainOfBlocks(1, 3, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 3, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, server.URL, "secret"))
+
+		require.NoError(t, p.processBlocks(ctx))
+		// Processing the same blocks again must not enqueue the deliveries twice.
+		for _, block := range chain {
+			require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+		}
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+		for _, delivery := range deliveries {
+			require.Equal(t, types.WebhookDeliveryStatusPending, delivery.Status)
+		}
+
+		require.NoError(t, p.deliverDueWebhooks(ctx))
+		require.Equal(t, 3, receiver.received())
+
+		for i, payload := range receiver.payloads {
+			require.Equal(t, webhook.Sign("secret", payload), receiver.signatures[i])
+			require.Contains(t, string(payload), `"value":"123"`)
+		}
+
+		deliveries, err = p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		for _, delivery := range deliveries {
+			require.Equal(t, types.WebhookDeliveryStatusDelivered, delivery.Status)
+			require.Equal(t, 1, delivery.Attempts)
+			require.False(t, delivery.DeliveredAt.IsZero())
+		}
+
+		// Delivered payloads are not sent again.
+		require.NoError(t, p.deliverDueWebhooks(ctx))
+		require.Equal(t, 3, receiver.received())
+	})
+
+	t.Run("should retry failed deliveries and dead-letter them", func(t *testing.T) {
+		log := &mock.Logger{}
+		receiver := &webhookReceiver{statusCode: http.StatusInternalServerError}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		chain := chainOfBlocks(1, 1, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			log,
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 1, BlocksByNumber: chain}),
+			WithWebhookRetries(3, time.Millisecond, time.Millisecond),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, server.URL, "secret"))
+		require.NoError(t, p.processBlocks(ctx))
+
+		require.NoError(t, p.deliverDueWebhooks(ctx))
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, types.WebhookDeliveryStatusPending, deliveries[0].Status)
+		require.Equal(t, 1, deliveries[0].Attempts)
+		require.Contains(t, deliveries[0].LastError, "unexpected response status code: 500")
+		require.True(t, deliveries[0].NextAttemptAt.After(deliveries[0].CreatedAt))
+
+		for i := 0; i < 5; i++ {
+			time.Sleep(2 * time.Millisecond)
+			require.NoError(t, p.deliverDueWebhooks(ctx))
+		}
+
+		deliveries, err = p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Equal(t, types.WebhookDeliveryStatusDead, deliveries[0].Status)
+		require.Equal(t, 3, deliveries[0].Attempts)
+		require.Equal(t, 3, receiver.received(), "should not retry dead deliveries")
+		require.Contains(t, log.GotErrors(), "webhook delivery dead-lettered")
+	})
+
+	t.Run("should dead-letter deliveries of removed webhooks", func(t *testing.T) {
+		receiver := &webhookReceiver{statusCode: http.StatusOK}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		chain := chainOfBlocks(1, 1, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 1, BlocksByNumber: chain}),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, server.URL, "secret"))
+		require.NoError(t, p.processBlocks(ctx))
+		require.NoError(t, p.RemoveWebhook(ctx, observedAddress, server.URL))
+
+		require.NoError(t, p.deliverDueWebhooks(ctx))
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, types.WebhookDeliveryStatusDead, deliveries[0].Status)
+		require.Equal(t, types.ErrWebhookNotFound.Error(), deliveries[0].LastError)
+		require.Zero(t, receiver.received())
+	})
+
+	t.Run("parser should deliver webhooks while running", func(t *testing.T) {
+		receiver := &webhookReceiver{statusCode: http.StatusOK}
+		server := httptest.NewServer(receiver)
+		defer server.Close()
+
+		chain := chainOfBlocks(1, 2, "", "a", transactions)
+
+		p, err := NewParser(
+			endpoint,
+			&mock.Logger{},
+			WithTransactionsRepo(storage.NewTransactionRepository()),
+			WithEthereumClient(mock.EthereumClient{MostRecentBlock: 2, BlocksByNumber: chain}),
+			WithWebhookPollInterval(time.Millisecond),
+			WithNoNewBlocksPause(time.Millisecond),
+		)
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, server.URL, "secret"))
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go func() {
+			_ = p.Run(runCtx)
+		}()
+
+		require.Eventually(t, func() bool {
+			return receiver.received() == 2
+		}, time.Second, time.Millisecond)
+	})
+}
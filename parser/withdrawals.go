@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// weiPerGwei converts the withdrawn amounts, which the beacon chain counts in gwei.
+var weiPerGwei = big.NewInt(1_000_000_000)
+
+// processWithdrawals returns the withdrawals of a block credited to an observed address, as transactions of kind
+// types.TransactionKindWithdrawal.
+func (p *Parser) processWithdrawals(
+	ctx context.Context,
+	block types.Block,
+	isObserved addressFilter,
+) ([]types.Transaction, error) {
+	if len(block.Withdrawals) == 0 {
+		return nil, nil
+	}
+
+	return p.processAndFilterObservedTransactions(ctx, newWithdrawalTransactions(block), isObserved)
+}
+
+// newWithdrawalTransactions converts the withdrawals of a block to transactions from the beacon chain, which have
+// no sender, to the withdrawal address.
+func newWithdrawalTransactions(block types.Block) []types.Transaction {
+	transactions := make([]types.Transaction, len(block.Withdrawals))
+
+	for i, w := range block.Withdrawals {
+		transactions[i] = types.Transaction{
+			Kind:        types.TransactionKindWithdrawal,
+			BlockHash:   block.Hash,
+			BlockNumber: block.Number,
+			To:          w.Address,
+			Withdrawal:  &w,
+		}
+		transactions[i].Value.Mul(new(big.Int).SetUint64(w.Amount), weiPerGwei)
+	}
+
+	return transactions
+}
+
+func isWithdrawal(tx types.Transaction) bool {
+	return tx.Kind == types.TransactionKindWithdrawal
+}
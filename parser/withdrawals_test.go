@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestParser_withdrawals(t *testing.T) {
+	ctx := context.TODO()
+	observedAddress := "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	depositContract := "0x00000000219ab540356cbb839cbe05303d7705fa"
+	validator := "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+
+	block := types.Block{
+		Number: 1,
+		Hash:   "0xb1",
+		Transactions: []types.Transaction{{
+			Kind: types.TransactionKindExternal, BlockHash: "0xb1", BlockNumber: 1, Hash: "0xff",
+			From: observedAddress, To: depositContract,
+		}},
+		Withdrawals: []types.Withdrawal{
+			{Index: 8, ValidatorIndex: 100, Address: observedAddress, Amount: 2},
+			{Index: 9, ValidatorIndex: 101, Address: validator, Amount: 3},
+			{Index: 7, ValidatorIndex: 102, Address: observedAddress, Amount: 32_000_000_000},
+		},
+	}
+
+	newParser := func(t *testing.T) *Parser {
+		p, err := NewParser(endpoint, &mock.Logger{}, WithEthereumClient(mock.EthereumClient{}))
+		require.NoError(t, err)
+		require.True(t, p.Subscribe(observedAddress))
+
+		return p
+	}
+
+	t.Run("should save the withdrawals of the observed addresses in wei", func(t *testing.T) {
+		p := newParser(t)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		transactions, err := p.ListTransactions(ctx, observedAddress, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, transactions, 3)
+		require.Equal(t, types.TransactionKindExternal, transactions[0].Kind, "withdrawals come after transactions")
+
+		thirtyTwoETH, _ := new(big.Int).SetString("32000000000000000000", 10)
+
+		require.Equal(t, types.Transaction{
+			Kind:               types.TransactionKindWithdrawal,
+			BlockHash:          "0xb1",
+			BlockNumber:        1,
+			To:                 observedAddress,
+			Value:              *thirtyTwoETH,
+			ConfirmationStatus: types.ConfirmationStatusFinal,
+			Withdrawal:         &types.Withdrawal{Index: 7, ValidatorIndex: 102, Address: observedAddress, Amount: 32e9},
+		}, transactions[1])
+		require.Equal(t, uint64(8), transactions[2].Withdrawal.Index)
+		require.Equal(t, *big.NewInt(2_000_000_000), transactions[2].Value)
+
+		require.Empty(t, p.GetTransactions(validator), "should ignore the withdrawals of other addresses")
+		require.Empty(t, p.GetTransactions(""), "should not index the withdrawals under their missing sender")
+	})
+
+	t.Run("should deliver every withdrawal to the webhooks", func(t *testing.T) {
+		p := newParser(t)
+		require.NoError(t, p.RegisterWebhook(ctx, observedAddress, "https://example.com/hook", "secret"))
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+
+		deliveries, err := p.GetWebhookDeliveries(ctx, observedAddress)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+	})
+
+	t.Run("should remove the withdrawals of a reorged block", func(t *testing.T) {
+		p := newParser(t)
+
+		require.NoError(t, p.processBlock(ctx, block, p.addressesRepository.IsAddressObserved, false))
+		require.NoError(t, p.transactionsRepo.RemoveTransactionsByBlockHash(ctx, "0xb1"))
+
+		require.Empty(t, p.GetTransactions(observedAddress))
+	})
+}
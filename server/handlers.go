@@ -0,0 +1,261 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/ethutil"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+var errInvalidRequest = errors.New("invalid request")
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /v1/block", s.handleCurrentBlock)
+	mux.HandleFunc("GET /v1/subscriptions", s.handleListSubscriptions)
+	mux.HandleFunc("POST /v1/subscriptions", s.handleSubscribe)
+	mux.HandleFunc("DELETE /v1/subscriptions/{address}", s.handleUnsubscribe)
+	mux.HandleFunc("GET /v1/addresses/{address}/transactions", s.handleListTransactions)
+	mux.HandleFunc("GET /v1/addresses/{address}/token-transfers", s.handleListTokenTransfers)
+
+	return mux
+}
+
+// handleHealth reports that the process is alive.
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+// handleReady reports whether the parser is running and can serve up-to-date data.
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if !s.parser.IsRunning() {
+		s.writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: "parser not running"})
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+func (s *Server) handleCurrentBlock(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, http.StatusOK, currentBlockResponse{CurrentBlock: s.parser.GetCurrentBlock()})
+}
+
+func (s *Server) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := s.pagination(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	subscriptions, err := s.parser.ListSubscriptions(r.Context(), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	response := subscriptionsResponse{
+		Subscriptions: make([]subscriptionResponse, 0, len(subscriptions)),
+		Offset:        offset,
+		Limit:         limit,
+	}
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, newSubscriptionResponse(subscription))
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	var request subscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.writeError(w, fmt.Errorf("%w: could not decode body: %s", errInvalidRequest, err))
+		return
+	}
+
+	if request.Address == "" {
+		s.writeError(w, fmt.Errorf("%w: address is required", errInvalidRequest))
+		return
+	}
+
+	address, err := ethutil.ParseAddress(request.Address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	subscription := types.Subscription{
+		Address:   address.String(),
+		Label:     request.Label,
+		Owner:     request.Owner,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.parser.SubscribeWithMetadata(r.Context(), subscription); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	// The stored subscription keeps the creation time of an address subscribed again.
+	stored, err := s.parser.GetSubscription(r.Context(), subscription.Address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusCreated, newSubscriptionResponse(stored))
+}
+
+// handleUnsubscribe stops observing an address. The transactions of the address are removed as well
+// when the `purge` query parameter is true.
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	purge := false
+
+	if value := r.URL.Query().Get("purge"); value != "" {
+		var err error
+		if purge, err = strconv.ParseBool(value); err != nil {
+			s.writeError(w, fmt.Errorf("%w: purge must be a boolean", errInvalidRequest))
+			return
+		}
+	}
+
+	if err := s.parser.Unsubscribe(r.Context(), r.PathValue("address"), purge); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListTransactions(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := s.pagination(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	transactions, err := s.parser.ListTransactions(r.Context(), r.PathValue("address"), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	response := transactionsResponse{
+		Transactions: make([]transactionResponse, 0, len(transactions)),
+		Offset:       offset,
+		Limit:        limit,
+	}
+	for _, tx := range transactions {
+		response.Transactions = append(response.Transactions, newTransactionResponse(tx))
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleListTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	offset, limit, err := s.pagination(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	transfers, err := s.parser.ListTokenTransfers(r.Context(), r.PathValue("address"), offset, limit)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	response := tokenTransfersResponse{
+		TokenTransfers: make([]tokenTransferResponse, 0, len(transfers)),
+		Offset:         offset,
+		Limit:          limit,
+	}
+	for _, transfer := range transfers {
+		response.TokenTransfers = append(response.TokenTransfers, newTokenTransferResponse(transfer))
+	}
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+// pagination reads the `offset` and `limit` query parameters. The limit defaults to defaultPageSize and
+// cannot exceed the maximum page size.
+func (s *Server) pagination(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultPageSize
+	if limit > s.maxPageSize {
+		limit = s.maxPageSize
+	}
+
+	query := r.URL.Query()
+
+	if value := query.Get("offset"); value != "" {
+		var err error
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("%w: offset must be a non negative integer", types.ErrInvalidPagination)
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 || limit > s.maxPageSize {
+			return 0, 0, fmt.Errorf("%w: limit must be an integer between 0 and %d",
+				types.ErrInvalidPagination, s.maxPageSize)
+		}
+	}
+
+	return offset, limit, nil
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("could not write response", "error", err)
+	}
+}
+
+// writeError writes the error as JSON with the status code of the error. Internal errors are logged
+// and their details are not exposed to the client.
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		s.logger.Error("could not handle request", "error", err)
+		message = http.StatusText(status)
+	}
+
+	s.writeJSON(w, status, errorResponse{Error: errorBody{Code: code, Message: message}})
+}
+
+// errorStatus maps an error to the HTTP status code and the error code returned to the client.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, types.ErrAddressNotFound):
+		return http.StatusNotFound, "address_not_found"
+	case errors.Is(err, types.ErrWebhookNotFound):
+		return http.StatusNotFound, "webhook_not_found"
+	case errors.Is(err, types.ErrInvalidPagination):
+		return http.StatusBadRequest, "invalid_pagination"
+	case errors.Is(err, types.ErrInvalidRange):
+		return http.StatusBadRequest, "invalid_range"
+	case errors.Is(err, types.ErrInvalidWebhook):
+		return http.StatusBadRequest, "invalid_webhook"
+	case errors.Is(err, ethutil.ErrInvalidAddress):
+		return http.StatusBadRequest, "invalid_address"
+	case errors.Is(err, errInvalidRequest):
+		return http.StatusBadRequest, "invalid_request"
+	case errors.Is(err, types.ErrAlreadyRunning):
+		return http.StatusConflict, "already_running"
+	default:
+		return http.StatusInternalServerError, "internal_error"
+	}
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/internal/storage"
+	"github.com/ilkamo/ethparser-go/parser"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	endpoint        = "https://test:80"
+	observedAddress = "0x995295D8C90fE127932c6fE78Dae6D5A4B975098"
+	// storedAddress is the canonical form of observedAddress, the one the repositories are keyed by.
+	storedAddress = "0x995295d8c90fe127932c6fe78dae6d5a4b975098"
+	counterpart   = "0x225295d8c90fe127932c6fe78dae6d5a4b975098"
+	unknown       = "0x115295d8c90fe127932c6fe78dae6d5a4b975098"
+)
+
+func newTestParser(t *testing.T) *parser.Parser {
+	t.Helper()
+
+	repo := storage.NewTransactionRepositoryWithLatestBlock(3)
+	require.NoError(t, repo.SaveTransactions(context.TODO(), []types.Transaction{
+		{BlockNumber: 2, Hash: "0x2", From: storedAddress, To: counterpart, Value: *big.NewInt(2)},
+		{BlockNumber: 1, Hash: "0x1", From: counterpart, To: storedAddress, Value: *big.NewInt(1), Receipt: &types.Receipt{
+			TransactionHash:   "0x1",
+			Status:            types.ReceiptStatusFailed,
+			GasUsed:           21000,
+			EffectiveGasPrice: *big.NewInt(7),
+			Logs:              []types.Log{{Address: "0xtoken", Topics: []string{"0xtopic"}, Data: "0x", Index: 4}},
+		}},
+		{BlockNumber: 3, Hash: "0x3", From: storedAddress, To: counterpart, Value: *big.NewInt(3)},
+	}))
+
+	p, err := parser.NewParser(
+		endpoint,
+		&mock.Logger{},
+		parser.WithTransactionsRepo(repo),
+		parser.WithEthereumClient(mock.EthereumClient{MostRecentBlock: 3}),
+		parser.WithNoNewBlocksPause(time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	return p
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var requestBody bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&requestBody).Encode(body))
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, &requestBody))
+
+	return recorder
+}
+
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var response T
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+
+	return response
+}
+
+func TestServer_health(t *testing.T) {
+	t.Run("should report health and readiness", func(t *testing.T) {
+		p := newTestParser(t)
+		handler := New(p, &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodGet, "/healthz", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodGet, "/readyz", nil)
+		require.Equal(t, http.StatusServiceUnavailable, resp.Code, "should not be ready before the parser runs")
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		go func() {
+			_ = p.Run(ctx)
+		}()
+
+		require.Eventually(t, func() bool {
+			return doRequest(t, handler, http.MethodGet, "/readyz", nil).Code == http.StatusOK
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func TestServer_currentBlock(t *testing.T) {
+	t.Run("should return the current block", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodGet, "/v1/block", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, 0, decode[currentBlockResponse](t, resp).CurrentBlock)
+	})
+}
+
+func TestServer_subscriptions(t *testing.T) {
+	t.Run("should subscribe, list and unsubscribe addresses", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodPost, "/v1/subscriptions",
+			subscribeRequest{Address: observedAddress, Label: "treasury"})
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		created := decode[subscriptionResponse](t, resp)
+		require.Equal(t, storedAddress, created.Address, "address should be normalised")
+		require.Equal(t, "treasury", created.Label)
+		require.False(t, created.CreatedAt.IsZero())
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/subscriptions", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		listed := decode[subscriptionsResponse](t, resp)
+		require.Len(t, listed.Subscriptions, 1)
+		require.Equal(t, "treasury", listed.Subscriptions[0].Label)
+		require.Equal(t, defaultPageSize, listed.Limit)
+
+		resp = doRequest(t, handler, http.MethodDelete, "/v1/subscriptions/"+observedAddress, nil)
+		require.Equal(t, http.StatusNoContent, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodDelete, "/v1/subscriptions/"+observedAddress, nil)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+		require.Equal(t, "address_not_found", decode[errorResponse](t, resp).Error.Code)
+	})
+
+	t.Run("should return the stored subscription when subscribing again", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodPost, "/v1/subscriptions", subscribeRequest{Address: observedAddress})
+		require.Equal(t, http.StatusCreated, resp.Code)
+		created := decode[subscriptionResponse](t, resp)
+
+		resp = doRequest(t, handler, http.MethodPost, "/v1/subscriptions",
+			subscribeRequest{Address: observedAddress, Label: "treasury"})
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		updated := decode[subscriptionResponse](t, resp)
+		require.Equal(t, "treasury", updated.Label)
+		require.True(t, created.CreatedAt.Equal(updated.CreatedAt), "should keep the original creation time")
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/subscriptions", nil)
+		listed := decode[subscriptionsResponse](t, resp)
+		require.Len(t, listed.Subscriptions, 1)
+		require.Equal(t, updated, listed.Subscriptions[0])
+	})
+
+	t.Run("should purge the transactions when unsubscribing", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodPost, "/v1/subscriptions", subscribeRequest{Address: observedAddress})
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodDelete, "/v1/subscriptions/"+observedAddress+"?purge=true", nil)
+		require.Equal(t, http.StatusNoContent, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/addresses/"+observedAddress+"/transactions", nil)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodPost, "/v1/subscriptions", subscribeRequest{})
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "invalid_request", decode[errorResponse](t, resp).Error.Code)
+
+		resp = doRequest(t, handler, http.MethodPost, "/v1/subscriptions", "not an object")
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodPost, "/v1/subscriptions", subscribeRequest{Address: "0x1234"})
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "invalid_address", decode[errorResponse](t, resp).Error.Code)
+
+		resp = doRequest(t, handler, http.MethodDelete, "/v1/subscriptions/not-an-address", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "invalid_address", decode[errorResponse](t, resp).Error.Code)
+
+		resp = doRequest(t, handler, http.MethodDelete, "/v1/subscriptions/"+observedAddress+"?purge=maybe", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/subscriptions?limit=-1", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "invalid_pagination", decode[errorResponse](t, resp).Error.Code)
+	})
+}
+
+func TestServer_transactions(t *testing.T) {
+	t.Run("should return the transactions of an address page by page", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}, WithMaxPageSize(2)).Handler()
+		target := "/v1/addresses/" + observedAddress + "/transactions"
+
+		resp := doRequest(t, handler, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		page := decode[transactionsResponse](t, resp)
+		require.Equal(t, 2, page.Limit, "should default to the max page size")
+		require.Len(t, page.Transactions, 2)
+		require.Equal(t, "0x1", page.Transactions[0].Hash)
+		require.Equal(t, "1", page.Transactions[0].Value)
+		require.Equal(t, &receiptResponse{
+			Status:            "failed",
+			GasUsed:           21000,
+			EffectiveGasPrice: "7",
+			Logs:              []logResponse{{Address: "0xtoken", Topics: []string{"0xtopic"}, Data: "0x", Index: 4}},
+		}, page.Transactions[0].Receipt)
+		require.Equal(t, "0x2", page.Transactions[1].Hash)
+		require.Nil(t, page.Transactions[1].Receipt)
+
+		resp = doRequest(t, handler, http.MethodGet, target+"?offset=2&limit=2", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		page = decode[transactionsResponse](t, resp)
+		require.Len(t, page.Transactions, 1)
+		require.Equal(t, "0x3", page.Transactions[0].Hash)
+
+		resp = doRequest(t, handler, http.MethodGet, target+"?limit=3", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code, "should not exceed the max page size")
+
+		resp = doRequest(t, handler, http.MethodGet, target+"?offset=abc", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should return not found for unknown addresses", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodGet, "/v1/addresses/"+unknown+"/transactions", nil)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+
+		body := decode[errorResponse](t, resp)
+		require.Equal(t, "address_not_found", body.Error.Code)
+		require.Contains(t, body.Error.Message, types.ErrAddressNotFound.Error())
+	})
+
+	t.Run("should return bad request for malformed addresses", func(t *testing.T) {
+		handler := New(newTestParser(t), &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodGet, "/v1/addresses/0xunknown/transactions", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "invalid_address", decode[errorResponse](t, resp).Error.Code)
+	})
+}
+
+func TestServer_tokenTransfers(t *testing.T) {
+	t.Run("should return the token transfers of an address", func(t *testing.T) {
+		repo := storage.NewTokenTransfersRepository()
+		require.NoError(t, repo.SaveTokenTransfers(context.TODO(), []types.TokenTransfer{
+			{
+				BlockNumber: 2, TransactionHash: "0x2", Token: "0xnft", Standard: types.TokenStandardERC721,
+				From: counterpart, To: storedAddress, TokenID: *big.NewInt(42),
+			},
+			{
+				BlockNumber: 1, TransactionHash: "0x1", LogIndex: 3, Token: "0xtoken", Standard: types.TokenStandardERC20,
+				From: storedAddress, To: counterpart, Amount: *big.NewInt(1000),
+			},
+		}))
+
+		p, err := parser.NewParser(
+			endpoint,
+			&mock.Logger{},
+			parser.WithTokenTransfersRepo(repo),
+			parser.WithEthereumClient(mock.EthereumClient{}),
+		)
+		require.NoError(t, err)
+
+		handler := New(p, &mock.Logger{}).Handler()
+
+		resp := doRequest(t, handler, http.MethodGet, "/v1/addresses/"+observedAddress+"/token-transfers", nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		page := decode[tokenTransfersResponse](t, resp)
+		require.Equal(t, []tokenTransferResponse{
+			{
+				TransactionHash: "0x1", BlockNumber: 1, LogIndex: 3, Token: "0xtoken", Standard: "erc20",
+				From: storedAddress, To: counterpart, Amount: "1000",
+			},
+			{
+				TransactionHash: "0x2", BlockNumber: 2, Token: "0xnft", Standard: "erc721",
+				From: counterpart, To: storedAddress, TokenID: "42",
+			},
+		}, page.TokenTransfers)
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/addresses/"+unknown+"/token-transfers", nil)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+
+		resp = doRequest(t, handler, http.MethodGet, "/v1/addresses/0xunknown/token-transfers", nil)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestErrorStatus(t *testing.T) {
+	t.Run("should hide the details of internal errors", func(t *testing.T) {
+		log := &mock.Logger{}
+		s := New(nil, log)
+
+		recorder := httptest.NewRecorder()
+		s.writeError(recorder, context.DeadlineExceeded)
+
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+		body := decode[errorResponse](t, recorder)
+		require.Equal(t, "internal_error", body.Error.Code)
+		require.Equal(t, "Internal Server Error", body.Error.Message)
+		require.Contains(t, log.GotErrors(), "could not handle request")
+	})
+}
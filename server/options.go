@@ -0,0 +1,26 @@
+package server
+
+import "time"
+
+type Option func(s *Server)
+
+// WithAddress sets the TCP address the server listens on, ":8080" by default.
+func WithAddress(address string) Option {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithShutdownTimeout sets how long in-flight requests are given to complete when the server stops.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithMaxPageSize sets the maximum number of items returned by the paginated endpoints.
+func WithMaxPageSize(size int) Option {
+	return func(s *Server) {
+		s.maxPageSize = size
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAddress(t *testing.T) {
+	t.Run("set address opt", func(t *testing.T) {
+		s := New(nil, nil, WithAddress(":9090"))
+		require.Equal(t, ":9090", s.address)
+	})
+}
+
+func TestWithShutdownTimeout(t *testing.T) {
+	t.Run("set shutdown timeout opt", func(t *testing.T) {
+		s := New(nil, nil, WithShutdownTimeout(time.Minute))
+		require.Equal(t, time.Minute, s.shutdownTimeout)
+	})
+}
+
+func TestWithMaxPageSize(t *testing.T) {
+	t.Run("set max page size opt", func(t *testing.T) {
+		s := New(nil, nil, WithMaxPageSize(10))
+		require.Equal(t, 10, s.maxPageSize)
+	})
+}
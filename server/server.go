@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+const (
+	defaultAddress           = ":8080"
+	defaultShutdownTimeout   = 10 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultPageSize          = 50
+	defaultMaxPageSize       = 1000
+)
+
+// Parser is the part of the parser exposed by the server.
+type Parser interface {
+	Run(ctx context.Context) error
+	IsRunning() bool
+	GetCurrentBlock() int
+	SubscribeWithMetadata(ctx context.Context, subscription types.Subscription) error
+	GetSubscription(ctx context.Context, address string) (types.Subscription, error)
+	Unsubscribe(ctx context.Context, address string, purge bool) error
+	ListSubscriptions(ctx context.Context, offset, limit int) ([]types.Subscription, error)
+	ListTransactions(ctx context.Context, address string, offset, limit int) ([]types.Transaction, error)
+	ListTokenTransfers(ctx context.Context, address string, offset, limit int) ([]types.TokenTransfer, error)
+}
+
+// Server exposes the parser through a REST API and runs it.
+type Server struct {
+	parser          Parser
+	logger          types.Logger
+	address         string
+	shutdownTimeout time.Duration
+	maxPageSize     int
+}
+
+func New(parser Parser, logger types.Logger, opts ...Option) *Server {
+	s := &Server{
+		parser:          parser,
+		logger:          logger,
+		address:         defaultAddress,
+		shutdownTimeout: defaultShutdownTimeout,
+		maxPageSize:     defaultMaxPageSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run listens on the configured address and serves the API while running the parser.
+// See Serve for the shutdown behaviour.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %w", s.address, err)
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the API on the listener and runs the parser until the context is canceled or one of
+// them stops because of an error. Then it stops both gracefully: the parser is stopped by canceling the
+// context passed to Parser.Run, while in-flight requests are given the shutdown timeout to complete.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	httpServer := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: defaultReadHeaderTimeout,
+	}
+
+	parserErrs := make(chan error, 1)
+	go func() {
+		parserErrs <- s.parser.Run(ctx)
+	}()
+
+	serverErrs := make(chan error, 1)
+	go func() {
+		serverErrs <- httpServer.Serve(listener)
+	}()
+
+	s.logger.Info("server started", "address", listener.Addr().String())
+
+	var errs []error
+	parserStopped := false
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErrs:
+		errs = append(errs, fmt.Errorf("http server stopped: %w", err))
+	case err := <-parserErrs:
+		parserStopped = true
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parser stopped: %w", err))
+		}
+	}
+
+	s.logger.Info("stopping server")
+
+	cancel()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancelShutdown()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("could not shutdown http server: %w", err))
+	}
+
+	if !parserStopped {
+		if err := <-parserErrs; err != nil {
+			errs = append(errs, fmt.Errorf("parser stopped: %w", err))
+		}
+	}
+
+	s.logger.Info("server stopped")
+
+	return errors.Join(errs...)
+}
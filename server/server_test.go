@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ilkamo/ethparser-go/internal/mock"
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+func TestServer_Serve(t *testing.T) {
+	t.Run("should serve the api and stop gracefully with the parser", func(t *testing.T) {
+		p := newTestParser(t)
+		log := &mock.Logger{}
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		stopped := make(chan error, 1)
+		go func() {
+			stopped <- New(p, log).Serve(ctx, listener)
+		}()
+
+		url := fmt.Sprintf("http://%s/readyz", listener.Addr().String())
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(url)
+			if err != nil {
+				return false
+			}
+			_ = resp.Body.Close()
+
+			return resp.StatusCode == http.StatusOK
+		}, time.Second, time.Millisecond)
+
+		cancel()
+
+		select {
+		case err := <-stopped:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("server did not stop")
+		}
+
+		require.False(t, p.IsRunning(), "parser should be stopped")
+		require.Contains(t, log.GotInfos(), "server stopped")
+
+		_, err = http.Get(url)
+		require.Error(t, err, "server should not accept requests anymore")
+	})
+
+	t.Run("should return error when the parser stops", func(t *testing.T) {
+		p := newTestParser(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		// The parser is already running, so the server cannot run it.
+		go func() {
+			_ = p.Run(ctx)
+		}()
+		require.Eventually(t, p.IsRunning, time.Second, time.Millisecond)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		err = New(p, &mock.Logger{}).Serve(ctx, listener)
+		require.ErrorContains(t, err, "parser stopped")
+		require.ErrorIs(t, err, types.ErrAlreadyRunning)
+	})
+}
+
+func TestServer_Run(t *testing.T) {
+	t.Run("should return error when the address cannot be listened on", func(t *testing.T) {
+		err := New(newTestParser(t), &mock.Logger{}, WithAddress("invalid:address")).Run(context.TODO())
+		require.ErrorContains(t, err, "could not listen on invalid:address")
+	})
+}
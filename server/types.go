@@ -0,0 +1,222 @@
+package server
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ilkamo/ethparser-go/types"
+)
+
+// Transport layer data structures of the API.
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+type currentBlockResponse struct {
+	CurrentBlock int `json:"currentBlock"`
+}
+
+type subscribeRequest struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+}
+
+type subscriptionResponse struct {
+	Address   string    `json:"address"`
+	Label     string    `json:"label,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newSubscriptionResponse(subscription types.Subscription) subscriptionResponse {
+	return subscriptionResponse{
+		Address:   subscription.Address,
+		Label:     subscription.Label,
+		Owner:     subscription.Owner,
+		CreatedAt: subscription.CreatedAt,
+	}
+}
+
+type subscriptionsResponse struct {
+	Subscriptions []subscriptionResponse `json:"subscriptions"`
+	Offset        int                    `json:"offset"`
+	Limit         int                    `json:"limit"`
+}
+
+type transactionResponse struct {
+	Kind            string `json:"kind,omitempty"`
+	Hash            string `json:"hash,omitempty"`       // withdrawals have no hash
+	TraceIndex      *int   `json:"traceIndex,omitempty"` // only set for internal transfers
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	ContractAddress string `json:"contractAddress,omitempty"` // only set for contract creations
+	Value           string `json:"value"`                     // decimal string, it does not fit in a JSON number
+	// The fields of the signed transaction, only set for the external transactions.
+	Type                 *uint8              `json:"type,omitempty"`
+	Nonce                *uint64             `json:"nonce,omitempty"`
+	Gas                  uint64              `json:"gas,omitempty"`
+	GasPrice             string              `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string              `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string              `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string              `json:"maxFeePerBlobGas,omitempty"`
+	ConfirmationStatus   string              `json:"confirmationStatus,omitempty"`
+	Receipt              *receiptResponse    `json:"receipt,omitempty"`
+	Withdrawal           *withdrawalResponse `json:"withdrawal,omitempty"`
+}
+
+func newTransactionResponse(tx types.Transaction) transactionResponse {
+	response := transactionResponse{
+		Kind:               string(tx.Kind),
+		Hash:               tx.Hash,
+		BlockHash:          tx.BlockHash,
+		BlockNumber:        tx.BlockNumber,
+		From:               tx.From,
+		To:                 tx.To,
+		ContractAddress:    tx.ContractAddress,
+		Value:              tx.Value.String(),
+		ConfirmationStatus: string(tx.ConfirmationStatus),
+	}
+
+	if tx.Kind == types.TransactionKindInternal {
+		traceIndex := tx.TraceIndex
+		response.TraceIndex = &traceIndex
+	}
+
+	if tx.Kind == types.TransactionKindExternal {
+		txType, nonce := uint8(tx.Type), tx.Nonce
+		response.Type = &txType
+		response.Nonce = &nonce
+		response.Gas = tx.Gas
+		response.GasPrice = tx.GasPrice.String()
+		response.MaxFeePerGas = bigIntString(tx.MaxFeePerGas)
+		response.MaxPriorityFeePerGas = bigIntString(tx.MaxPriorityFeePerGas)
+		response.MaxFeePerBlobGas = bigIntString(tx.MaxFeePerBlobGas)
+	}
+
+	if tx.Receipt != nil {
+		receipt := newReceiptResponse(*tx.Receipt)
+		response.Receipt = &receipt
+	}
+
+	if tx.Withdrawal != nil {
+		response.Withdrawal = &withdrawalResponse{
+			Index:          tx.Withdrawal.Index,
+			ValidatorIndex: tx.Withdrawal.ValidatorIndex,
+			Amount:         tx.Withdrawal.Amount,
+		}
+	}
+
+	return response
+}
+
+type withdrawalResponse struct {
+	Index          uint64 `json:"index"`
+	ValidatorIndex uint64 `json:"validatorIndex"`
+	Amount         uint64 `json:"amount"` // gwei
+}
+
+type receiptResponse struct {
+	Status            string        `json:"status,omitempty"`
+	GasUsed           uint64        `json:"gasUsed"`
+	EffectiveGasPrice string        `json:"effectiveGasPrice"` // decimal string, in wei
+	ContractAddress   string        `json:"contractAddress,omitempty"`
+	Logs              []logResponse `json:"logs"`
+}
+
+func newReceiptResponse(receipt types.Receipt) receiptResponse {
+	response := receiptResponse{
+		Status:            string(receipt.Status),
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: receipt.EffectiveGasPrice.String(),
+		ContractAddress:   receipt.ContractAddress,
+		Logs:              make([]logResponse, 0, len(receipt.Logs)),
+	}
+
+	for _, l := range receipt.Logs {
+		response.Logs = append(response.Logs, logResponse{
+			Address: l.Address,
+			Topics:  l.Topics,
+			Data:    l.Data,
+			Index:   l.Index,
+		})
+	}
+
+	return response
+}
+
+type logResponse struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+	Index   uint64   `json:"logIndex"`
+}
+
+type transactionsResponse struct {
+	Transactions []transactionResponse `json:"transactions"`
+	Offset       int                   `json:"offset"`
+	Limit        int                   `json:"limit"`
+}
+
+type tokenTransferResponse struct {
+	TransactionHash string `json:"transactionHash"`
+	BlockHash       string `json:"blockHash"`
+	BlockNumber     uint64 `json:"blockNumber"`
+	LogIndex        uint64 `json:"logIndex"`
+	Token           string `json:"token"`
+	Standard        string `json:"standard"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Amount          string `json:"amount,omitempty"`  // decimal string, not set for ERC-721 tokens
+	TokenID         string `json:"tokenId,omitempty"` // decimal string, not set for ERC-20 tokens
+}
+
+func newTokenTransferResponse(transfer types.TokenTransfer) tokenTransferResponse {
+	response := tokenTransferResponse{
+		TransactionHash: transfer.TransactionHash,
+		BlockHash:       transfer.BlockHash,
+		BlockNumber:     transfer.BlockNumber,
+		LogIndex:        transfer.LogIndex,
+		Token:           transfer.Token,
+		Standard:        string(transfer.Standard),
+		From:            transfer.From,
+		To:              transfer.To,
+	}
+
+	if transfer.Standard != types.TokenStandardERC721 {
+		response.Amount = transfer.Amount.String()
+	}
+
+	if transfer.Standard != types.TokenStandardERC20 {
+		response.TokenID = transfer.TokenID.String()
+	}
+
+	return response
+}
+
+type tokenTransfersResponse struct {
+	TokenTransfers []tokenTransferResponse `json:"tokenTransfers"`
+	Offset         int                     `json:"offset"`
+	Limit          int                     `json:"limit"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+// bigIntString returns the decimal string of n, empty when n is nil.
+func bigIntString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+
+	return n.String()
+}
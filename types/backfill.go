@@ -0,0 +1,33 @@
+package types
+
+// BackfillProgress describes the progress of a backfill over the range of blocks [From, To].
+type BackfillProgress struct {
+	From               uint64
+	To                 uint64
+	LastProcessedBlock uint64
+	ProcessedBlocks    uint64
+}
+
+// Done returns true when all the blocks of the range were processed.
+func (b BackfillProgress) Done() bool {
+	return b.ProcessedBlocks == b.To-b.From+1
+}
+
+// AddressBackfillState is the state of the backfill scheduled for a newly subscribed address.
+type AddressBackfillState string
+
+const (
+	AddressBackfillStateQueued    AddressBackfillState = "queued"
+	AddressBackfillStateRunning   AddressBackfillState = "running"
+	AddressBackfillStateCompleted AddressBackfillState = "completed"
+	AddressBackfillStateFailed    AddressBackfillState = "failed"
+)
+
+// AddressBackfillStatus describes the backfill of the history of a single address.
+type AddressBackfillStatus struct {
+	Address    string
+	SinceBlock uint64
+	State      AddressBackfillState
+	Progress   BackfillProgress
+	Error      string
+}
@@ -1,24 +1,120 @@
 package types
 
 import (
+	"fmt"
 	"math/big"
 	"time"
 )
 
+// Block is a block of the chain with its header fields. The fields introduced by a fork are empty for the blocks
+// before it.
 type Block struct {
-	Number       uint64
-	Hash         string
-	ParentHash   string
-	Timestamp    time.Time
-	Transactions []Transaction
+	Number           uint64
+	Hash             string
+	ParentHash       string
+	Timestamp        time.Time
+	Miner            string // fee recipient, the address receiving the priority fees
+	GasLimit         uint64
+	GasUsed          uint64
+	BaseFeePerGas    *big.Int // London (EIP-1559)
+	LogsBloom        string
+	StateRoot        string
+	ReceiptsRoot     string
+	TransactionsRoot string
+	Transactions     []Transaction
+	Withdrawals      []Withdrawal // Shanghai (EIP-4895)
+	WithdrawalsRoot  string       // Shanghai (EIP-4895)
+	BlobGasUsed      *uint64      // Cancun (EIP-4844)
+	ExcessBlobGas    *uint64      // Cancun (EIP-4844)
+	// ParentBeaconBlockRoot is the root of the parent beacon block, Cancun (EIP-4788).
+	ParentBeaconBlockRoot string
 }
 
+// Withdrawal is a withdrawal of ETH from the beacon chain to the execution layer, included in a block without
+// a transaction.
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        string
+	Amount         uint64 // gwei
+}
+
+// ConfirmationStatus tells if a transaction is included in a block that the parser considers final
+// or in a block that has not reached the configured number of confirmations yet.
+type ConfirmationStatus string
+
+const (
+	ConfirmationStatusPending ConfirmationStatus = "pending"
+	ConfirmationStatusFinal   ConfirmationStatus = "final"
+)
+
+// TransactionKind tells how ETH was transferred between the addresses of a transaction.
+type TransactionKind string
+
+const (
+	// TransactionKindExternal is a transaction signed by an account and included in a block.
+	TransactionKindExternal TransactionKind = "external"
+	// TransactionKindInternal is a transfer of ETH made by a contract while executing a transaction,
+	// e.g. the withdrawal of a multisig. It shares the hash of its transaction.
+	TransactionKindInternal TransactionKind = "internal"
+	// TransactionKindWithdrawal is a withdrawal of ETH from the beacon chain, credited to an address without
+	// a transaction. It has no hash and no sender.
+	TransactionKindWithdrawal TransactionKind = "withdrawal"
+)
+
 type Transaction struct {
-	BlockHash   string
-	BlockNumber uint64
-	Hash        string
-	From        string
-	To          string
-	Value       big.Int // ideally a decimal.Decimal but I cannot use external libraries for this exercise.
-	// ... other fields omitted for the scope of this exercise
+	Kind             TransactionKind
+	Type             TransactionType
+	BlockHash        string
+	BlockNumber      uint64
+	Hash             string
+	TransactionIndex uint64 // position of the transaction in the block
+	TraceIndex       int    // position of an internal transfer among the internal transfers of its transaction
+	From             string
+	To               string // empty for contract creations
+	// ContractAddress is the address of the contract deployed by a contract creation, empty for the other
+	// transactions.
+	ContractAddress string
+	Value           big.Int // ideally a decimal.Decimal but I cannot use external libraries for this exercise.
+	Nonce           uint64
+	Input           string // hex encoded call data
+	Gas             uint64 // gas limit
+	// GasPrice is the wei paid per unit of gas. For the dynamic fee transactions included in a block, nodes
+	// return the effective gas price: the base fee plus the priority fee, capped by MaxFeePerGas.
+	GasPrice             big.Int
+	MaxFeePerGas         *big.Int // nil for the transactions before EIP-1559
+	MaxPriorityFeePerGas *big.Int // nil for the transactions before EIP-1559
+	MaxFeePerBlobGas     *big.Int // nil for the transactions without blobs
+	BlobVersionedHashes  []string
+	AccessList           []AccessTuple
+	AuthorizationList    []Authorization
+	ChainID              *big.Int // nil for the legacy transactions without replay protection (see EIP-155)
+	V                    big.Int  // y parity for the typed transactions
+	R                    big.Int
+	S                    big.Int
+	ConfirmationStatus   ConfirmationStatus
+	// Receipt is only set for the final transactions involving an observed address, when the Ethereum client
+	// can fetch receipts.
+	Receipt *Receipt
+	// Withdrawal is only set for the withdrawals, whose Value is the withdrawn amount in wei.
+	Withdrawal *Withdrawal
+}
+
+// ID identifies the transaction in the history of an address. It is the hash of the transaction, except for
+// internal transfers which share the hash of their transaction and for withdrawals which have no hash.
+func (t Transaction) ID() string {
+	switch {
+	case t.Kind == TransactionKindInternal:
+		return fmt.Sprintf("%s:internal:%d", t.Hash, t.TraceIndex)
+	case t.Kind == TransactionKindWithdrawal && t.Withdrawal != nil:
+		return fmt.Sprintf("withdrawal:%d", t.Withdrawal.Index)
+	default:
+		return t.Hash
+	}
+}
+
+// IsContractCreation tells if the transaction deploys a contract, i.e. it is an external transaction without
+// recipient.
+func (t Transaction) IsContractCreation() bool {
+	return t.Kind == TransactionKindExternal && t.To == ""
 }
@@ -3,6 +3,10 @@ package types
 import "errors"
 
 var (
-	ErrAddressNotFound = errors.New("address not found")
-	ErrAlreadyRunning  = errors.New("parser is already running")
+	ErrAddressNotFound   = errors.New("address not found")
+	ErrAlreadyRunning    = errors.New("parser is already running")
+	ErrInvalidRange      = errors.New("invalid block range")
+	ErrInvalidPagination = errors.New("invalid pagination")
+	ErrInvalidWebhook    = errors.New("invalid webhook")
+	ErrWebhookNotFound   = errors.New("webhook not found")
 )
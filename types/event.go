@@ -0,0 +1,36 @@
+package types
+
+// EventType is the type of the events published by the parser.
+type EventType string
+
+const (
+	// EventTypeTransaction is published for every transaction involving an observed address.
+	EventTypeTransaction EventType = "transaction"
+	// EventTypeTokenTransfer is published for every token transfer involving an observed address.
+	EventTypeTokenTransfer EventType = "token_transfer"
+	// EventTypeBlockProcessed is published every time the live loop processes a block, never for the blocks
+	// processed by a backfill.
+	EventTypeBlockProcessed EventType = "block_processed"
+	// EventTypeReorg is published when the parser rolls back orphaned blocks after a chain reorganization.
+	EventTypeReorg EventType = "reorg"
+)
+
+// Event is published by the parser while processing blocks.
+type Event struct {
+	Type EventType
+	// BlockNumber is the block of the transaction or transfer, the processed block or the common ancestor
+	// of a reorg.
+	BlockNumber uint64
+	// BlockHash is the hash of the block of the transaction or transfer, or of the processed block.
+	BlockHash string
+	// Transaction is only set for EventTypeTransaction events.
+	Transaction Transaction
+	// TokenTransfer is only set for EventTypeTokenTransfer events.
+	TokenTransfer TokenTransfer
+	// OrphanedBlocks is only set for EventTypeReorg events.
+	OrphanedBlocks uint64
+	// Backfill is true for the transactions and transfers of the historical blocks processed by a backfill
+	// (see Parser.Backfill and Parser.SubscribeSince). They can be published again by the live loop if the backfill
+	// overlaps with the blocks it processes.
+	Backfill bool
+}
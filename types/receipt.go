@@ -0,0 +1,31 @@
+package types
+
+import "math/big"
+
+// ReceiptStatus tells if the execution of a transaction succeeded (see EIP-658). It is empty for the
+// transactions included before the Byzantium fork, whose receipts do not have a status.
+type ReceiptStatus string
+
+const (
+	ReceiptStatusSuccessful ReceiptStatus = "successful"
+	ReceiptStatusFailed     ReceiptStatus = "failed"
+)
+
+// Receipt is the outcome of the execution of a transaction.
+type Receipt struct {
+	TransactionHash   string
+	Status            ReceiptStatus
+	GasUsed           uint64
+	EffectiveGasPrice big.Int // wei paid per unit of gas
+	ContractAddress   string  // address of the created contract, empty for other transactions
+	Logs              []Log
+}
+
+// Log is an event emitted by a contract during the execution of a transaction.
+type Log struct {
+	TransactionHash string
+	Address         string
+	Topics          []string
+	Data            string
+	Index           uint64 // position of the log in the block
+}
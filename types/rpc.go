@@ -0,0 +1,22 @@
+package types
+
+import "encoding/json"
+
+// BatchElem is a request of a JSON-RPC batch call. Once the batch call returns, Result contains the result
+// of the request or Error the reason why it failed.
+type BatchElem struct {
+	Method string
+	Params interface{}
+	Result json.RawMessage
+	Error  error
+}
+
+// RPCSubscription receives the notifications of a JSON-RPC subscription (eth_subscribe).
+type RPCSubscription interface {
+	// Notifications returns the channel receiving the result of every notification.
+	// It is closed when the subscription ends.
+	Notifications() <-chan json.RawMessage
+
+	// Unsubscribe cancels the subscription.
+	Unsubscribe()
+}
@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+// Subscription is an observed address together with the metadata attached when it was subscribed.
+type Subscription struct {
+	Address   string
+	Label     string
+	Owner     string
+	CreatedAt time.Time
+}
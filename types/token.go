@@ -0,0 +1,28 @@
+package types
+
+import "math/big"
+
+// TokenStandard is the standard implemented by the contract of a token.
+type TokenStandard string
+
+const (
+	TokenStandardERC20   TokenStandard = "erc20"
+	TokenStandardERC721  TokenStandard = "erc721"
+	TokenStandardERC1155 TokenStandard = "erc1155"
+)
+
+// TokenTransfer is a transfer of tokens decoded from the Transfer, TransferSingle or TransferBatch event
+// emitted by a token contract. A TransferBatch event results in a transfer per token.
+type TokenTransfer struct {
+	BlockHash       string
+	BlockNumber     uint64
+	TransactionHash string
+	LogIndex        uint64
+	BatchIndex      int    // position of the token in a TransferBatch event, zero for the other events
+	Token           string // address of the token contract
+	Standard        TokenStandard
+	From            string  // zero address for mints
+	To              string  // zero address for burns
+	Amount          big.Int // transferred amount of ERC-20 and ERC-1155 tokens, zero for ERC-721 tokens
+	TokenID         big.Int // transferred ERC-721 or ERC-1155 token, zero for ERC-20 tokens
+}
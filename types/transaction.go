@@ -0,0 +1,32 @@
+package types
+
+import "math/big"
+
+// TransactionType is the EIP-2718 envelope type of a transaction, which defines its fee market and the fields
+// it carries.
+type TransactionType uint8
+
+const (
+	TransactionTypeLegacy     TransactionType = 0x0
+	TransactionTypeAccessList TransactionType = 0x1 // EIP-2930
+	TransactionTypeDynamicFee TransactionType = 0x2 // EIP-1559
+	TransactionTypeBlob       TransactionType = 0x3 // EIP-4844
+	TransactionTypeSetCode    TransactionType = 0x4 // EIP-7702
+)
+
+// AccessTuple is an address and the storage keys that a transaction declares it will access (see EIP-2930).
+type AccessTuple struct {
+	Address     string
+	StorageKeys []string
+}
+
+// Authorization is a signed delegation of the code of an account to the code of another address, carried by
+// the set code transactions (see EIP-7702).
+type Authorization struct {
+	ChainID big.Int // zero when the authorization is valid on any chain
+	Address string  // address whose code is delegated to
+	Nonce   uint64
+	YParity uint8
+	R       big.Int
+	S       big.Int
+}
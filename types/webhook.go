@@ -0,0 +1,38 @@
+package types
+
+import "time"
+
+// Webhook is a URL notified with a signed payload for every transaction involving an address.
+type Webhook struct {
+	Address   string
+	URL       string
+	Secret    string
+	CreatedAt time.Time
+}
+
+// WebhookDeliveryStatus is the status of the delivery of a webhook payload.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending is a delivery waiting for its next attempt.
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryStatusDelivered is a delivery accepted by the receiver.
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryStatusDead is a delivery that failed too many times and will not be retried.
+	WebhookDeliveryStatusDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery is the delivery of a transaction of an observed address to a webhook.
+type WebhookDelivery struct {
+	ID              string
+	Address         string
+	URL             string
+	TransactionHash string
+	Payload         []byte
+	Status          WebhookDeliveryStatus
+	Attempts        int
+	LastError       string
+	NextAttemptAt   time.Time
+	CreatedAt       time.Time
+	DeliveredAt     time.Time
+}